@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+
+	"github.com/guisithos/save-my-read/internal/application"
+	"github.com/guisithos/save-my-read/internal/domain/book"
+)
+
+// Credentials of the account seeded in demo mode
+const (
+	demoEmail    = "demo@example.com"
+	demoPassword = "demo-password"
+)
+
+// demoBooks fills the demo account's library. The IDs are not real Google
+// volumes and covers are left out, so the server starts without network access.
+var demoBooks = []struct {
+	googleID, title, author, category string
+	status                            book.Status
+}{
+	{"demo-dune", "Dune", "Frank Herbert", "Fiction", book.StatusReading},
+	{"demo-fellowship", "The Fellowship of the Ring", "J. R. R. Tolkien", "Fiction", book.StatusCompleted},
+	{"demo-mockingbird", "To Kill a Mockingbird", "Harper Lee", "Fiction", book.StatusToRead},
+	{"demo-sapiens", "Sapiens", "Yuval Noah Harari", "History", book.StatusToRead},
+	{"demo-ulysses", "Ulysses", "James Joyce", "Fiction", book.StatusDNF},
+}
+
+// seedDemo registers the demo account and adds sample books to its library
+func seedDemo(ctx context.Context, auth *application.AuthService, books *application.BookService, logger *slog.Logger) error {
+	resp, err := auth.Register(ctx, demoEmail, demoPassword, "Demo Reader", []string{"fiction", "history"})
+	if err != nil {
+		return fmt.Errorf("error creating demo user: %w", err)
+	}
+
+	for _, b := range demoBooks {
+		_, err := books.AddBookToList(ctx, resp.User.ID, b.googleID, b.title,
+			[]string{b.author}, "", []string{b.category}, "", "", b.status, true)
+		if err != nil {
+			return fmt.Errorf("error adding demo book %q: %w", b.title, err)
+		}
+	}
+
+	// The demo password is public, so it goes in the message where the
+	// redacting handler leaves it alone
+	logger.Info(fmt.Sprintf("demo mode: sign in as %s with password %s; data is lost on exit", demoEmail, demoPassword),
+		"books", len(demoBooks))
+	return nil
+}
+
+// randomSecret returns a JWT secret for demo runs that don't configure one;
+// tokens stop working when the server restarts
+func randomSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error generating JWT secret: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// runEvery calls job right away and then every interval until ctx is done,
+// logging failures as msg. The returned channel is closed once the last run
+// has finished, so the caller can wait before closing the database.
+func runEvery(ctx context.Context, interval time.Duration, logger *slog.Logger, msg string, job func(context.Context) error) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := job(ctx); err != nil && ctx.Err() == nil {
+				logger.Error(msg, "error", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return done
+}
@@ -1,57 +1,295 @@
 package main
 
 import (
-	"database/sql"
+	"context"
+	"flag"
 	"log"
+	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/guisithos/save-my-read/internal/application"
+	"github.com/guisithos/save-my-read/internal/config"
 	"github.com/guisithos/save-my-read/internal/domain/auth"
+	"github.com/guisithos/save-my-read/internal/domain/book"
+	"github.com/guisithos/save-my-read/internal/domain/cover"
+	"github.com/guisithos/save-my-read/internal/domain/event"
+	"github.com/guisithos/save-my-read/internal/domain/webhook"
+	"github.com/guisithos/save-my-read/internal/health"
+	"github.com/guisithos/save-my-read/internal/infrastructure/filestore"
 	"github.com/guisithos/save-my-read/internal/infrastructure/googlebooks"
-	"github.com/guisithos/save-my-read/internal/infrastructure/postgres"
 	"github.com/guisithos/save-my-read/internal/interfaces/http/handlers"
+	"github.com/guisithos/save-my-read/internal/interfaces/http/middleware"
 	"github.com/guisithos/save-my-read/internal/interfaces/http/server"
+	"github.com/guisithos/save-my-read/internal/logging"
+	"github.com/guisithos/save-my-read/internal/metrics"
+	"github.com/guisithos/save-my-read/internal/tracing"
 )
 
 func main() {
-	// Verify environment variables
-	if os.Getenv("GOOGLE_BOOKS_API_KEY") == "" {
-		log.Fatal("GOOGLE_BOOKS_API_KEY environment variable is not set")
+	configPath := flag.String("config", os.Getenv("CONFIG_FILE"), "path to a JSON config file")
+	demo := flag.Bool("demo", false, "serve sample data from an in-memory store instead of the database")
+	flag.Parse()
+
+	// Load and verify configuration
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// The migrate subcommand needs nothing but the database
+	switch flag.Arg(0) {
+	case "":
+	case "migrate":
+		logger, err := logging.New(os.Stderr, cfg.Log.Level, cfg.Log.Format)
+		if err != nil {
+			log.Fatal(err)
+		}
+		os.Exit(runMigrate(cfg.DatabaseURL, flag.Args()[1:], os.Stdout, os.Stderr, logger))
+	default:
+		log.Fatalf("unknown command %q; the only subcommand is migrate", flag.Arg(0))
+	}
+
+	cfg.Demo = cfg.Demo || *demo
+	if cfg.Demo && cfg.JWT.Secret == "" {
+		if cfg.JWT.Secret, err = randomSecret(); err != nil {
+			log.Fatal(err)
+		}
 	}
-	if os.Getenv("JWT_SECRET") == "" {
-		log.Fatal("JWT_SECRET environment variable is not set")
+	if err := cfg.Validate(); err != nil {
+		log.Fatal(err)
 	}
 
-	// Initialize database connection
-	db, err := sql.Open("postgres", os.Getenv("DATABASE_URL"))
+	// Initialize logging; the default logger also catches output from libraries
+	logger, err := logging.New(os.Stderr, cfg.Log.Level, cfg.Log.Format)
 	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
+		log.Fatal(err)
 	}
-	defer db.Close()
+	slog.SetDefault(logger)
+	logger.Debug("configuration loaded", "config", cfg)
 
-	// Initialize repositories
-	bookRepo := postgres.NewBookRepository(db)
-	userRepo := postgres.NewUserRepository(db)
+	// Initialize tracing; without an exporter spans are not recorded
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.Endpoint,
+		ServiceName: cfg.Tracing.ServiceName,
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		fatal(logger, "failed to set up tracing", err)
+	}
+
+	// Initialize repositories on the backend DATABASE_URL selects
+	backend, err := openStorage(cfg)
+	if err != nil {
+		fatal(logger, "failed to connect to database", err)
+	}
+	db, bookRepo, userRepo, uow := backend.db, backend.books, backend.users, backend.uow
+
+	// Refuse a schema this binary doesn't know; AUTO_MIGRATE brings an old one up to date
+	var latestMigration uint
+	if db != nil {
+		if latestMigration, err = prepareSchema(cfg.DatabaseURL, cfg.AutoMigrate, logger); err != nil {
+			fatal(logger, "database schema check failed", err)
+		}
+	}
+
+	// Initialize metrics
+	var appMetrics *metrics.Metrics
+	googleTransport := http.DefaultTransport
+	if cfg.Metrics.Enabled {
+		appMetrics = metrics.New()
+		if db != nil {
+			appMetrics.RegisterDB(db, backend.driver)
+		}
+		googleTransport = appMetrics.InstrumentTransport("google_books", googleTransport)
+	}
 
 	// Initialize Google Books client
-	googleClient, err := googlebooks.NewClient()
+	googleClient, err := googlebooks.NewClient(
+		googlebooks.WithBaseURL(cfg.GoogleBooks.BaseURL),
+		googlebooks.WithAPIKey(cfg.GoogleBooks.APIKey),
+		googlebooks.WithUserAgent(cfg.GoogleBooks.UserAgent),
+		googlebooks.WithCountry(cfg.GoogleBooks.Country),
+		googlebooks.WithHTTPClient(&http.Client{Transport: googleTransport}),
+		googlebooks.WithTimeout(time.Duration(cfg.GoogleBooks.Timeout)),
+		googlebooks.WithLogger(logger),
+	)
 	if err != nil {
-		log.Fatal("Failed to create Google Books client:", err)
+		fatal(logger, "failed to create Google Books client", err)
+	}
+	if appMetrics != nil {
+		appMetrics.RegisterCircuit("google_books", func() float64 {
+			return float64(googleClient.CircuitState())
+		})
 	}
 
-	// Initialize JWT service with 24h token duration
-	jwtService := auth.NewJWTService(os.Getenv("JWT_SECRET"), 24*time.Hour)
+	// Initialize JWT service
+	jwtService := auth.NewJWTService(cfg.JWT.Secret, time.Duration(cfg.JWT.Duration))
+
+	// Initialize cover cache
+	fileStore, err := filestore.NewStore(cfg.Covers.Dir)
+	if err != nil {
+		fatal(logger, "failed to create cover store", err)
+	}
+	var coverStore cover.Store = fileStore
+	if appMetrics != nil {
+		coverStore = appMetrics.InstrumentCoverStore(coverStore)
+	}
 
 	// Initialize services
-	bookService := application.NewBookService(bookRepo, userRepo)
-	authService := application.NewAuthService(userRepo, jwtService)
+	coverService := application.NewCoverService(
+		coverStore,
+		bookRepo,
+		&http.Client{Timeout: time.Duration(cfg.Covers.FetchTimeout)},
+		cfg.Covers.AllowedHosts,
+		logger,
+	)
+	auditService := application.NewAuditService(backend.audit, logger)
+	// Domain events reach in-process subscribers on the bus as soon as a
+	// change commits, and the outbox dispatcher's subscribers afterwards
+	bus := event.NewBus()
+	if appMetrics != nil {
+		bus.Subscribe(func(_ context.Context, e event.Event) error {
+			appMetrics.ObserveEvent(e.EventName())
+			return nil
+		})
+	}
+	// Open clients follow their library live through the hub
+	hub := application.NewEventHub(0)
+	bus.Subscribe(hub.Publish, book.EventAdded, book.EventStatusChanged, book.EventDeleted, book.EventRestored, book.EventMerged)
+	dispatcher := application.NewOutboxDispatcher(backend.outbox, application.OutboxOptions{
+		MaxAttempts: cfg.Outbox.MaxAttempts,
+	}, logger)
+
+	bookService := application.NewBookService(bookRepo, userRepo, uow, coverService, auditService, bus, logger)
+	authService := application.NewAuthService(userRepo, uow, jwtService, auditService, logger)
+	webhookService := application.NewWebhookService(backend.webhooks, webhookClient(cfg.Webhooks, appMetrics), auditService, logger)
+	dispatcher.Subscribe(webhookService.Deliver, webhook.Events...)
+	dispatcher.Subscribe(coverService.CacheCover, book.EventAdded)
+
+	if cfg.Demo {
+		if err := seedDemo(context.Background(), authService, bookService, logger); err != nil {
+			fatal(logger, "failed to seed demo data", err)
+		}
+	}
 
 	// Initialize handlers
 	bookHandler := handlers.NewBookHandler(bookService, googleClient)
 	authHandler := handlers.NewAuthHandler(authService)
+	coverHandler := handlers.NewCoverHandler(coverService)
+	auditHandler := handlers.NewAuditHandler(auditService)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+	streamHandler := handlers.NewStreamHandler(hub)
+
+	// Readiness checks; Google being down degrades search but nothing else
+	checker := health.NewChecker(2 * time.Second)
+	if db != nil {
+		checker.Add("database", true, db.PingContext)
+		checker.Add("migrations", true, backend.migrationCheck(db, latestMigration))
+	}
+	checker.Add("google_books", false, func(context.Context) error {
+		if googleClient.CircuitState() == googlebooks.CircuitOpen {
+			return googlebooks.ErrCircuitOpen
+		}
+		return nil
+	})
+	healthHandler := handlers.NewHealthHandler(checker, logger)
 
 	// Initialize and start server
-	srv := server.NewServer(authHandler, bookHandler, jwtService, "8080")
-	log.Fatal(srv.Start())
+	srv := server.NewServer(authHandler, bookHandler, coverHandler, healthHandler, auditHandler, webhookHandler, streamHandler, jwtService, server.Options{
+		Addr:              cfg.Server.ListenAddr(),
+		ReadTimeout:       time.Duration(cfg.Server.ReadTimeout),
+		ReadHeaderTimeout: time.Duration(cfg.Server.ReadHeaderTimeout),
+		WriteTimeout:      time.Duration(cfg.Server.WriteTimeout),
+		IdleTimeout:       time.Duration(cfg.Server.IdleTimeout),
+		ShutdownTimeout:   time.Duration(cfg.Server.ShutdownTimeout),
+		TLSCertFile:       cfg.Server.TLSCertFile,
+		TLSKeyFile:        cfg.Server.TLSKeyFile,
+		CORS: middleware.CORSOptions{
+			AllowedOrigins: cfg.CORS.AllowedOrigins,
+			MaxAge:         time.Duration(cfg.CORS.MaxAge),
+		},
+		AuditSource: middleware.AuditSourceOptions{
+			TrustedProxies: cfg.Server.TrustedProxyPrefixes(),
+		},
+		Logger:    logger,
+		Metrics:   appMetrics,
+		AdminAddr: cfg.Metrics.AdminAddr,
+		Idempotency: middleware.IdempotencyOptions{
+			Store:  backend.idempotency,
+			TTL:    time.Duration(cfg.Idempotency.TTL),
+			Logger: logger,
+		},
+	})
+
+	// Serve until SIGINT or SIGTERM, then drain requests before closing the pool
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Background jobs, stopped along with the server
+	retention := time.Duration(cfg.Trash.Retention)
+	purged := runEvery(ctx, time.Duration(cfg.Trash.PurgeInterval), logger, "failed to purge trash", func(ctx context.Context) error {
+		_, err := bookService.PurgeTrash(ctx, retention)
+		return err
+	})
+	cleaned := runEvery(ctx, time.Duration(cfg.Idempotency.CleanupInterval), logger, "failed to delete expired idempotency keys", func(ctx context.Context) error {
+		_, err := backend.idempotency.DeleteExpired(ctx, time.Now())
+		return err
+	})
+
+	dispatched := runEvery(ctx, time.Duration(cfg.Outbox.PollInterval), logger, "failed to dispatch outbox events", func(ctx context.Context) error {
+		_, err := dispatcher.Dispatch(ctx)
+		return err
+	})
+	outboxRetention := time.Duration(cfg.Outbox.Retention)
+	prunedOutbox := runEvery(ctx, time.Duration(cfg.Outbox.CleanupInterval), logger, "failed to delete dispatched outbox events", func(ctx context.Context) error {
+		_, err := dispatcher.Cleanup(ctx, outboxRetention)
+		return err
+	})
+	deliveryRetention := time.Duration(cfg.Webhooks.DeliveryRetention)
+	prunedDeliveries := runEvery(ctx, time.Duration(cfg.Outbox.CleanupInterval), logger, "failed to delete old webhook deliveries", func(ctx context.Context) error {
+		_, err := webhookService.PruneDeliveries(ctx, deliveryRetention)
+		return err
+	})
+
+	// End open event streams on shutdown instead of waiting them out
+	go func() {
+		<-ctx.Done()
+		hub.Close()
+	}()
+
+	runErr := srv.Run(ctx)
+	stop()
+	<-purged
+	<-cleaned
+	<-dispatched
+	<-prunedOutbox
+	<-prunedDeliveries
+	auditService.Close()
+	if db != nil {
+		if err := db.Close(); err != nil {
+			logger.Error("failed to close database", "error", err)
+		}
+	}
+
+	// Flush spans from the last requests before exiting
+	flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if err := shutdownTracing(flushCtx); err != nil {
+		logger.Error("failed to flush traces", "error", err)
+	}
+	cancel()
+	if runErr != nil {
+		fatal(logger, "server failed", runErr)
+	}
+	logger.Info("server stopped")
+}
+
+// fatal logs err and exits; deferred functions do not run
+func fatal(logger *slog.Logger, msg string, err error) {
+	logger.Error(msg, "error", err)
+	os.Exit(1)
 }
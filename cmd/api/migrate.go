@@ -0,0 +1,129 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+
+	"github.com/guisithos/save-my-read/internal/migration"
+)
+
+const migrateUsage = `usage: api migrate <command>
+
+commands:
+  up         apply all pending migrations
+  down N     roll back the last N migrations
+  version    print the current schema version
+  force V    mark version V as applied without running it, after fixing a failed migration`
+
+// runMigrate runs the migrate subcommand and returns the exit code
+func runMigrate(databaseURL string, args []string, stdout, stderr io.Writer, logger *slog.Logger) int {
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, migrateUsage)
+		return 2
+	}
+
+	// Parse arguments before connecting so typos fail fast
+	var n int
+	switch args[0] {
+	case "up", "version":
+		if len(args) != 1 {
+			fmt.Fprintln(stderr, migrateUsage)
+			return 2
+		}
+	case "down", "force":
+		if len(args) != 2 {
+			fmt.Fprintln(stderr, migrateUsage)
+			return 2
+		}
+		var err error
+		if n, err = strconv.Atoi(args[1]); err != nil {
+			fmt.Fprintf(stderr, "invalid number %q\n", args[1])
+			return 2
+		}
+	default:
+		fmt.Fprintf(stderr, "unknown migrate command %q\n\n%s\n", args[0], migrateUsage)
+		return 2
+	}
+
+	if databaseURL == "" {
+		fmt.Fprintln(stderr, "DATABASE_URL is required")
+		return 1
+	}
+	m, err := migration.Open(databaseURL, logger)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	defer m.Close()
+
+	switch args[0] {
+	case "up":
+		err = m.Up()
+	case "down":
+		err = m.Down(n)
+	case "force":
+		err = m.Force(n)
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	version, dirty, err := m.Version()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	switch {
+	case dirty:
+		fmt.Fprintf(stdout, "schema version %d (dirty), latest %d\n", version, m.Latest())
+	default:
+		fmt.Fprintf(stdout, "schema version %d, latest %d\n", version, m.Latest())
+	}
+	return 0
+}
+
+// prepareSchema checks the schema before the server starts and returns the
+// version the binary expects. It refuses a schema that is newer than the
+// binary or was left dirty by a failed migration, and applies pending
+// migrations when autoMigrate is set; otherwise a schema that is behind
+// keeps the readiness check failing until it is migrated.
+func prepareSchema(databaseURL string, autoMigrate bool, logger *slog.Logger) (uint, error) {
+	m, err := migration.Open(databaseURL, logger)
+	if err != nil {
+		return 0, err
+	}
+	defer m.Close()
+
+	version, dirty, err := m.Version()
+	if err != nil {
+		return 0, err
+	}
+	latest := m.Latest()
+	switch {
+	case version > latest:
+		return 0, fmt.Errorf("schema is at version %d but this binary only knows up to %d; deploy a newer binary or roll the schema back", version, latest)
+	case dirty:
+		return 0, fmt.Errorf("migration %d failed part way; fix the schema and run \"api migrate force %d\"", version, version)
+	case version == latest:
+		return latest, nil
+	case !autoMigrate:
+		logger.Warn("schema is behind, run \"api migrate up\" or set AUTO_MIGRATE", "version", version, "latest", latest)
+		return latest, nil
+	}
+
+	logger.Info("applying migrations", "from", version, "to", latest)
+	if err := m.Up(); err != nil {
+		return 0, err
+	}
+	if version, _, err = m.Version(); err != nil {
+		return 0, err
+	}
+	if version != latest {
+		return 0, errors.New("schema did not reach the latest version after migrating")
+	}
+	return latest, nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/guisithos/save-my-read/internal/application"
+	"github.com/guisithos/save-my-read/internal/config"
+	"github.com/guisithos/save-my-read/internal/domain/audit"
+	"github.com/guisithos/save-my-read/internal/domain/book"
+	"github.com/guisithos/save-my-read/internal/domain/event"
+	"github.com/guisithos/save-my-read/internal/domain/idempotency"
+	"github.com/guisithos/save-my-read/internal/domain/user"
+	"github.com/guisithos/save-my-read/internal/domain/webhook"
+	"github.com/guisithos/save-my-read/internal/infrastructure/memory"
+	"github.com/guisithos/save-my-read/internal/infrastructure/postgres"
+	"github.com/guisithos/save-my-read/internal/infrastructure/sqlite"
+)
+
+// storage is the backend behind the repositories: PostgreSQL, a SQLite
+// file chosen by a sqlite:// DATABASE_URL, or memory in demo mode
+type storage struct {
+	// db is nil in demo mode
+	db *sql.DB
+	// driver names the database in metrics
+	driver string
+	books  book.Repository
+	users  user.Repository
+	uow    application.UnitOfWork
+	// idempotency keeps responses to writes sent with an Idempotency-Key
+	idempotency idempotency.Store
+	audit       audit.Store
+	// outbox holds the events written by the unit of work until dispatched
+	outbox   event.OutboxStore
+	webhooks webhook.Repository
+	// migrationCheck builds the readiness check for the schema version
+	migrationCheck func(db *sql.DB, want uint) func(ctx context.Context) error
+}
+
+func openStorage(cfg *config.Config) (*storage, error) {
+	timeout := time.Duration(cfg.QueryTimeout)
+
+	switch {
+	case cfg.Demo:
+		store := memory.NewStore()
+		return &storage{
+			driver:      "memory",
+			books:       memory.NewBookRepository(store),
+			users:       memory.NewUserRepository(store),
+			uow:         memory.NewUnitOfWork(store),
+			idempotency: memory.NewIdempotencyStore(),
+			audit:       memory.NewAuditStore(),
+			outbox:      memory.NewOutboxStore(store),
+			webhooks:    memory.NewWebhookRepository(),
+		}, nil
+
+	case sqlite.IsURL(cfg.DatabaseURL):
+		db, err := sqlite.Open(cfg.DatabaseURL)
+		if err != nil {
+			return nil, err
+		}
+		return &storage{
+			db:             db,
+			driver:         "sqlite",
+			books:          sqlite.NewBookRepository(db, timeout),
+			users:          sqlite.NewUserRepository(db, timeout),
+			uow:            sqlite.NewUnitOfWork(db, timeout),
+			idempotency:    sqlite.NewIdempotencyStore(db, timeout),
+			audit:          sqlite.NewAuditStore(db, timeout),
+			outbox:         sqlite.NewOutboxStore(db, timeout),
+			webhooks:       sqlite.NewWebhookRepository(db, timeout),
+			migrationCheck: sqlite.MigrationCheck,
+		}, nil
+
+	default:
+		db, err := sql.Open("postgres", cfg.DatabaseURL)
+		if err != nil {
+			return nil, fmt.Errorf("error opening database: %w", err)
+		}
+		return &storage{
+			db:             db,
+			driver:         "postgres",
+			books:          postgres.NewBookRepository(db, timeout),
+			users:          postgres.NewUserRepository(db, timeout),
+			uow:            postgres.NewUnitOfWork(db, timeout),
+			idempotency:    postgres.NewIdempotencyStore(db, timeout),
+			audit:          postgres.NewAuditStore(db, timeout),
+			outbox:         postgres.NewOutboxStore(db, timeout),
+			webhooks:       postgres.NewWebhookRepository(db, timeout),
+			migrationCheck: postgres.MigrationCheck,
+		}, nil
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/guisithos/save-my-read/internal/config"
+	"github.com/guisithos/save-my-read/internal/metrics"
+	"github.com/guisithos/save-my-read/internal/netguard"
+)
+
+// webhookClient builds the client webhooks are delivered with. Users pick
+// the URLs, so unless configured otherwise it refuses to connect to
+// non-public addresses, ignores proxy settings that would hide the real
+// destination and does not follow redirects.
+func webhookClient(cfg config.WebhooksConfig, m *metrics.Metrics) *http.Client {
+	transport := netguard.Transport()
+	if cfg.AllowPrivateAddresses {
+		transport.DialContext = (&net.Dialer{Timeout: 5 * time.Second, KeepAlive: 30 * time.Second}).DialContext
+	}
+
+	var rt http.RoundTripper = transport
+	if m != nil {
+		rt = m.InstrumentTransport("webhooks", rt)
+	}
+	return &http.Client{
+		Transport: rt,
+		Timeout:   time.Duration(cfg.Timeout),
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
@@ -1,28 +1,51 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"time"
 
+	"github.com/guisithos/save-my-read/internal/config"
 	"github.com/guisithos/save-my-read/internal/infrastructure/googlebooks"
 )
 
 func main() {
+	configPath := flag.String("config", os.Getenv("CONFIG_FILE"), "path to a JSON config file")
+	flag.Parse()
+
+	// Load and verify configuration
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := cfg.GoogleBooks.Validate(); err != nil {
+		log.Fatal(err)
+	}
+
 	// Initialize Google Books client
-	client, err := googlebooks.NewClient()
+	client, err := googlebooks.NewClient(
+		googlebooks.WithBaseURL(cfg.GoogleBooks.BaseURL),
+		googlebooks.WithAPIKey(cfg.GoogleBooks.APIKey),
+		googlebooks.WithUserAgent(cfg.GoogleBooks.UserAgent),
+		googlebooks.WithCountry(cfg.GoogleBooks.Country),
+		googlebooks.WithHTTPClient(&http.Client{Timeout: time.Duration(cfg.GoogleBooks.Timeout)}),
+	)
 	if err != nil {
 		log.Fatalf("Failed to create client: %v", err)
 	}
 
 	// Get search query from command line
-	if len(os.Args) < 2 {
+	if flag.NArg() < 1 {
 		log.Fatal("Please provide a search query")
 	}
-	query := os.Args[1]
+	query := flag.Arg(0)
 
 	// Search for books
-	response, err := client.SearchBooks(query)
+	response, err := client.SearchBooks(context.Background(), query)
 	if err != nil {
 		log.Fatalf("Search failed: %v", err)
 	}
@@ -0,0 +1,136 @@
+package application
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guisithos/save-my-read/internal/domain/audit"
+	"github.com/guisithos/save-my-read/internal/logging"
+	"github.com/guisithos/save-my-read/internal/tracing"
+)
+
+const (
+	// auditQueueSize bounds the events waiting to be written
+	auditQueueSize = 1024
+	// auditBatchSize bounds the events written in one statement
+	auditBatchSize = 100
+
+	defaultAuditLimit = 50
+	maxAuditLimit     = 200
+)
+
+// AuditService writes audit events in the background, so recording one
+// never slows a request down, and reads the log back
+type AuditService struct {
+	store  audit.Store
+	logger *slog.Logger
+
+	mu     sync.RWMutex
+	closed bool
+	queue  chan *audit.Event
+	done   chan struct{}
+}
+
+// NewAuditService creates an AuditService writing to store and starts its
+// writer; call Close to flush it. A nil logger uses slog.Default().
+func NewAuditService(store audit.Store, logger *slog.Logger) *AuditService {
+	s := &AuditService{
+		store:  store,
+		logger: logging.OrDefault(logger),
+		queue:  make(chan *audit.Event, auditQueueSize),
+		done:   make(chan struct{}),
+	}
+	go s.write()
+	return s
+}
+
+// Record stamps e with an ID, the time and the request's source, and
+// queues it for writing. When the queue is full the event is logged and
+// dropped rather than holding up the request.
+func (s *AuditService) Record(ctx context.Context, e *audit.Event) {
+	if e.ID == "" {
+		e.ID = uuid.NewString()
+	}
+	if e.OccurredAt.IsZero() {
+		e.OccurredAt = time.Now()
+	}
+	src := audit.SourceFrom(ctx)
+	e.IP, e.UserAgent, e.RequestID = src.IP, src.UserAgent, src.RequestID
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		s.logger.ErrorContext(ctx, "audit log closed, dropping event", "action", e.Action, "user_id", e.UserID)
+		return
+	}
+	select {
+	case s.queue <- e:
+	default:
+		s.logger.ErrorContext(ctx, "audit queue full, dropping event", "action", e.Action, "user_id", e.UserID)
+	}
+}
+
+// Close stops accepting events and waits until the queued ones are written
+func (s *AuditService) Close() {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.queue)
+	}
+	s.mu.Unlock()
+	<-s.done
+}
+
+// write appends queued events in batches until the queue is closed
+func (s *AuditService) write() {
+	defer close(s.done)
+	for e := range s.queue {
+		batch := []*audit.Event{e}
+	fill:
+		for len(batch) < auditBatchSize {
+			select {
+			case e, ok := <-s.queue:
+				if !ok {
+					break fill
+				}
+				batch = append(batch, e)
+			default:
+				break fill
+			}
+		}
+		if err := s.store.Append(context.Background(), batch...); err != nil {
+			s.logger.Error("failed to write audit events", "events", len(batch), "error", err)
+		}
+	}
+}
+
+// Activity returns the user's own events, newest first
+func (s *AuditService) Activity(ctx context.Context, userID string, f audit.Filter) (_ []*audit.Event, err error) {
+	ctx, span := tracer.Start(ctx, "AuditService.Activity")
+	defer func() { tracing.End(span, err) }()
+
+	f.UserID = userID
+	return s.store.List(ctx, withAuditLimit(f))
+}
+
+// Events returns events of every user, newest first, for administrators
+func (s *AuditService) Events(ctx context.Context, f audit.Filter) (_ []*audit.Event, err error) {
+	ctx, span := tracer.Start(ctx, "AuditService.Events")
+	defer func() { tracing.End(span, err) }()
+
+	return s.store.List(ctx, withAuditLimit(f))
+}
+
+// withAuditLimit applies the default page size and caps larger ones
+func withAuditLimit(f audit.Filter) audit.Filter {
+	switch {
+	case f.Limit <= 0:
+		f.Limit = defaultAuditLimit
+	case f.Limit > maxAuditLimit:
+		f.Limit = maxAuditLimit
+	}
+	return f
+}
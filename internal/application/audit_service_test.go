@@ -0,0 +1,90 @@
+package application
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/guisithos/save-my-read/internal/domain/audit"
+	"github.com/guisithos/save-my-read/internal/logging"
+)
+
+type mockAuditStore struct {
+	mu     sync.Mutex
+	events []*audit.Event
+	filter audit.Filter
+}
+
+func (m *mockAuditStore) Append(_ context.Context, events ...*audit.Event) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events = append(m.events, events...)
+	return nil
+}
+
+func (m *mockAuditStore) List(_ context.Context, f audit.Filter) ([]*audit.Event, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.filter = f
+	var events []*audit.Event
+	for _, e := range m.events {
+		if f.UserID == "" || e.UserID == f.UserID {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+// eventRecorder keeps recorded events in memory
+type eventRecorder struct {
+	events []*audit.Event
+}
+
+func (r *eventRecorder) Record(_ context.Context, e *audit.Event) {
+	r.events = append(r.events, e)
+}
+
+func TestAuditService_RecordAndClose(t *testing.T) {
+	store := &mockAuditStore{}
+	svc := NewAuditService(store, logging.Discard())
+
+	ctx := audit.WithSource(context.Background(), audit.Source{IP: "192.0.2.1", UserAgent: "test-agent", RequestID: "req-1"})
+	for i := 0; i < 3; i++ {
+		svc.Record(ctx, &audit.Event{Action: audit.ActionLogin, UserID: "alice"})
+	}
+	svc.Record(ctx, &audit.Event{Action: audit.ActionLogin, UserID: "bob"})
+	svc.Close()
+	svc.Close()
+
+	if len(store.events) != 4 {
+		t.Fatalf("expected Close to flush 4 events, got %d", len(store.events))
+	}
+	e := store.events[0]
+	if e.ID == "" || e.OccurredAt.IsZero() {
+		t.Errorf("expected an ID and time, got %+v", e)
+	}
+	if e.IP != "192.0.2.1" || e.UserAgent != "test-agent" || e.RequestID != "req-1" {
+		t.Errorf("expected the request's source, got %+v", e)
+	}
+
+	// Events after Close are dropped rather than panicking
+	svc.Record(ctx, &audit.Event{Action: audit.ActionLogin})
+
+	events, err := svc.Activity(context.Background(), "alice", audit.Filter{UserID: "bob", Limit: 1000})
+	if err != nil {
+		t.Fatalf("Activity() error = %v", err)
+	}
+	if len(events) != 3 {
+		t.Errorf("expected only alice's events, got %d", len(events))
+	}
+	if store.filter.Limit != maxAuditLimit {
+		t.Errorf("expected the limit capped at %d, got %d", maxAuditLimit, store.filter.Limit)
+	}
+
+	if _, err := svc.Events(context.Background(), audit.Filter{}); err != nil {
+		t.Fatalf("Events() error = %v", err)
+	}
+	if store.filter.Limit != defaultAuditLimit {
+		t.Errorf("expected the default limit %d, got %d", defaultAuditLimit, store.filter.Limit)
+	}
+}
@@ -1,64 +1,92 @@
 package application
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"log/slog"
+	"strings"
 
+	"github.com/guisithos/save-my-read/internal/domain/audit"
 	"github.com/guisithos/save-my-read/internal/domain/auth"
 	"github.com/guisithos/save-my-read/internal/domain/user"
+	"github.com/guisithos/save-my-read/internal/logging"
+	"github.com/guisithos/save-my-read/internal/tracing"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type AuthService struct {
 	userRepo     user.Repository
+	uow          UnitOfWork
 	tokenService auth.TokenService
+	audit        audit.Recorder
+	logger       *slog.Logger
 }
 
-func NewAuthService(userRepo user.Repository, tokenService auth.TokenService) *AuthService {
+// NewAuthService creates a new AuthService that registers users in uow and
+// records registrations and logins with recorder. A nil recorder discards
+// them; a nil logger uses slog.Default().
+func NewAuthService(userRepo user.Repository, uow UnitOfWork, tokenService auth.TokenService, recorder audit.Recorder, logger *slog.Logger) *AuthService {
+	if recorder == nil {
+		recorder = audit.Discard
+	}
 	return &AuthService{
 		userRepo:     userRepo,
+		uow:          uow,
 		tokenService: tokenService,
+		audit:        recorder,
+		logger:       logging.OrDefault(logger),
 	}
 }
 
-func (s *AuthService) Register(email, password, name string, genres []string) (*auth.LoginResponse, error) {
-	fmt.Printf("Starting registration process for email: %s, name: %s\n", email, name)
-
-	// Check if user already exists
-	existing, err := s.userRepo.FindByEmail(email)
-	if err != nil {
-		fmt.Printf("Error checking existing user: %v\n", err)
-	}
-	if existing != nil {
-		fmt.Println("User already exists with this email")
-		return nil, errors.New("email already registered")
-	}
+func (s *AuthService) Register(ctx context.Context, email, password, name string, genres []string) (_ *auth.LoginResponse, err error) {
+	ctx, span := tracer.Start(ctx, "AuthService.Register")
+	defer func() { tracing.End(span, err) }()
 
 	// Create new user
-	fmt.Println("Creating new user...")
 	newUser, err := user.NewUser(email, password, name, genres)
 	if err != nil {
-		fmt.Printf("Error creating new user: %v\n", err)
-		return nil, fmt.Errorf("failed to create user: %w", err)
+		return nil, err
 	}
-	fmt.Printf("User created with ID: %s\n", newUser.ID)
 
-	// Save user
-	fmt.Println("Saving user to database...")
-	if err := s.userRepo.Save(newUser); err != nil {
-		fmt.Printf("Error saving user to database: %v\n", err)
-		return nil, fmt.Errorf("failed to save user: %w", err)
+	// Check for an existing account and save in one transaction
+	err = s.uow.Do(ctx, func(ctx context.Context, repos Repositories) error {
+		existing, err := repos.Users.FindByEmail(ctx, email)
+		if err != nil && !errors.Is(err, user.ErrNotFound) {
+			return fmt.Errorf("failed to check existing user: %w", err)
+		}
+		if existing != nil {
+			return auth.ErrEmailAlreadyExists
+		}
+
+		if err := repos.Users.Save(ctx, newUser); err != nil {
+			if errors.Is(err, auth.ErrEmailAlreadyExists) {
+				return err
+			}
+			return fmt.Errorf("failed to save user: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	fmt.Println("User saved successfully")
+	s.logger.InfoContext(ctx, "user registered", "user_id", newUser.ID)
+	s.audit.Record(ctx, &audit.Event{
+		Action:     audit.ActionRegister,
+		UserID:     newUser.ID,
+		ActorID:    newUser.ID,
+		TargetType: audit.TargetUser,
+		TargetID:   newUser.ID,
+		Details:    map[string]string{"email": newUser.Email},
+	})
 
 	// Generate token
-	fmt.Println("Generating authentication token...")
-	token, err := s.tokenService.GenerateToken(newUser.ID, newUser.Email)
+	token, err := s.issueToken(ctx, newUser, "register")
 	if err != nil {
-		fmt.Printf("Error generating token: %v\n", err)
-		return nil, fmt.Errorf("failed to generate token: %w", err)
+		return nil, err
 	}
-	fmt.Println("Token generated successfully")
 
 	return &auth.LoginResponse{
 		Token: token,
@@ -70,34 +98,83 @@ func (s *AuthService) Register(email, password, name string, genres []string) (*
 	}, nil
 }
 
-func (s *AuthService) Login(email, password string) (*auth.LoginResponse, error) {
+func (s *AuthService) Login(ctx context.Context, email, password string) (_ *auth.LoginResponse, err error) {
+	ctx, span := tracer.Start(ctx, "AuthService.Login")
+	defer func() { tracing.End(span, err) }()
+
 	// Find user by email
-	user, err := s.userRepo.FindByEmail(email)
+	u, err := s.userRepo.FindByEmail(ctx, email)
 	if err != nil {
-		// Log the error but don't expose internal details
-		fmt.Printf("Error finding user: %v\n", err)
+		// Don't reveal whether the email exists
+		if !errors.Is(err, user.ErrNotFound) {
+			s.logger.ErrorContext(ctx, "login lookup failed", "error", err)
+		} else {
+			s.logger.InfoContext(ctx, "login failed", "reason", "unknown_email")
+			s.audit.Record(ctx, &audit.Event{
+				Action:  audit.ActionLoginFailed,
+				Details: map[string]string{"email_sha256": emailDigest(email), "reason": "unknown_email"},
+			})
+		}
 		return nil, auth.ErrInvalidCredentials
 	}
 
 	// Validate password using bcrypt
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
-		fmt.Printf("Invalid password for user %s\n", email)
+	if err := bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password)); err != nil {
+		s.logger.InfoContext(ctx, "login failed", "reason", "wrong_password", "user_id", u.ID)
+		s.audit.Record(ctx, &audit.Event{
+			Action:     audit.ActionLoginFailed,
+			UserID:     u.ID,
+			TargetType: audit.TargetUser,
+			TargetID:   u.ID,
+			Details:    map[string]string{"reason": "wrong_password"},
+		})
 		return nil, auth.ErrInvalidCredentials
 	}
+	s.audit.Record(ctx, &audit.Event{
+		Action:     audit.ActionLogin,
+		UserID:     u.ID,
+		ActorID:    u.ID,
+		TargetType: audit.TargetUser,
+		TargetID:   u.ID,
+	})
 
 	// Generate JWT token
-	token, err := s.tokenService.GenerateToken(user.ID, user.Email)
+	token, err := s.issueToken(ctx, u, "login")
 	if err != nil {
-		fmt.Printf("Error generating token: %v\n", err)
-		return nil, fmt.Errorf("failed to generate token: %w", err)
+		return nil, err
 	}
 
 	return &auth.LoginResponse{
 		Token: token,
 		User: auth.UserResponse{
-			ID:    user.ID,
-			Email: user.Email,
-			Name:  user.Name,
+			ID:    u.ID,
+			Email: u.Email,
+			Name:  u.Name,
 		},
 	}, nil
 }
+
+// issueToken generates a token for u and records that it was issued and why
+func (s *AuthService) issueToken(ctx context.Context, u *user.User, reason string) (string, error) {
+	token, err := s.tokenService.GenerateToken(u.ID, u.Email)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	s.audit.Record(ctx, &audit.Event{
+		Action:     audit.ActionTokenIssued,
+		UserID:     u.ID,
+		ActorID:    u.ID,
+		TargetType: audit.TargetUser,
+		TargetID:   u.ID,
+		Details:    map[string]string{"reason": reason},
+	})
+	return token, nil
+}
+
+// emailDigest stands in for an email that matched no account in the audit
+// log: repeated attempts on one address can be told apart without keeping
+// what was typed, which may be another secret such as a password
+func emailDigest(email string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(email))))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,60 @@
+package application_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/guisithos/save-my-read/internal/application"
+	"github.com/guisithos/save-my-read/internal/domain/audit"
+	"github.com/guisithos/save-my-read/internal/domain/auth"
+	"github.com/guisithos/save-my-read/internal/domain/user"
+	"github.com/guisithos/save-my-read/internal/infrastructure/memory"
+	"github.com/guisithos/save-my-read/internal/logging"
+)
+
+// recorded keeps the audit events in memory
+type recorded []*audit.Event
+
+func (r *recorded) Record(_ context.Context, e *audit.Event) { *r = append(*r, e) }
+
+func TestAuthService_FailedLoginKeepsNoEmail(t *testing.T) {
+	store := memory.NewStore()
+	users := memory.NewUserRepository(store)
+	u, err := user.NewUser("ann@example.com", "password123", "Ann", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := users.Save(context.Background(), u); err != nil {
+		t.Fatal(err)
+	}
+	var events recorded
+	svc := application.NewAuthService(users, memory.NewUnitOfWork(store), nil, &events, logging.Discard())
+
+	for _, email := range []string{"ann@example.com", "password123@example.com"} {
+		if _, err := svc.Login(context.Background(), email, "wrong-password"); !errors.Is(err, auth.ErrInvalidCredentials) {
+			t.Fatalf("Login(%s) error = %v, want ErrInvalidCredentials", email, err)
+		}
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("expected 2 audit events, got %d", len(events))
+	}
+	for _, e := range events {
+		if e.Action != audit.ActionLoginFailed {
+			t.Errorf("expected %s, got %s", audit.ActionLoginFailed, e.Action)
+		}
+		for k, v := range e.Details {
+			if strings.Contains(v, "@example.com") {
+				t.Errorf("detail %s keeps the email typed: %q", k, v)
+			}
+		}
+	}
+	if events[0].UserID != u.ID || events[0].Details["reason"] != "wrong_password" {
+		t.Errorf("expected a wrong password for %s, got %+v", u.ID, events[0])
+	}
+	if events[1].UserID != "" || events[1].Details["email_sha256"] == "" {
+		t.Errorf("expected an unknown email recorded by digest, got %+v", events[1])
+	}
+}
@@ -1,36 +1,66 @@
 package application
 
 import (
+	"context"
 	"errors"
+	"log/slog"
+	"time"
 
+	"github.com/guisithos/save-my-read/internal/domain/audit"
 	"github.com/guisithos/save-my-read/internal/domain/book"
+	"github.com/guisithos/save-my-read/internal/domain/domainerr"
+	"github.com/guisithos/save-my-read/internal/domain/event"
 	"github.com/guisithos/save-my-read/internal/domain/user"
+	"github.com/guisithos/save-my-read/internal/logging"
+	"github.com/guisithos/save-my-read/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // BookService handles the application logic for books
 type BookService struct {
-	bookRepo book.Repository
-	userRepo user.Repository
+	bookRepo     book.Repository
+	userRepo     user.Repository
+	uow          UnitOfWork
+	coverService *CoverService
+	audit        audit.Recorder
+	bus          *event.Bus
+	logger       *slog.Logger
 }
 
-// NewBookService creates a new BookService
-func NewBookService(bookRepo book.Repository, userRepo user.Repository) *BookService {
+// NewBookService creates a new BookService. Reads go through bookRepo and
+// userRepo; changes spanning several statements run in uow. coverService
+// removes the covers of purged books and may be nil. Changes users make are recorded with recorder, or discarded when it is
+// nil. The events books raise are written to the outbox with the change and
+// published on bus once it commits; bus may be nil. A nil logger uses
+// slog.Default().
+func NewBookService(bookRepo book.Repository, userRepo user.Repository, uow UnitOfWork, coverService *CoverService, recorder audit.Recorder, bus *event.Bus, logger *slog.Logger) *BookService {
+	if recorder == nil {
+		recorder = audit.Discard
+	}
+	if bus == nil {
+		bus = event.NewBus()
+	}
 	return &BookService{
-		bookRepo: bookRepo,
-		userRepo: userRepo,
+		bookRepo:     bookRepo,
+		userRepo:     userRepo,
+		uow:          uow,
+		coverService: coverService,
+		audit:        recorder,
+		bus:          bus,
+		logger:       logging.OrDefault(logger),
 	}
 }
 
-// AddBookToList adds a book to user's reading list
-func (s *BookService) AddBookToList(userID, googleBookID, title string,
+// AddBookToList adds a book to user's reading list. It refuses to add a
+// volume already in the library and, unless allowSimilar is set, entries
+// that look like the same book from another provider.
+func (s *BookService) AddBookToList(ctx context.Context, userID, googleBookID, title string,
 	authors []string, description string, categories []string,
-	imageURL string, status book.Status) (*book.Book, error) {
+	imageURL, isbn string, status book.Status, allowSimilar bool) (_ *book.Book, err error) {
 
-	// Verify user exists
-	_, err := s.userRepo.FindByID(userID)
-	if err != nil {
-		return nil, errors.New("user not found")
-	}
+	ctx, span := s.startSpan(ctx, "BookService.AddBookToList", userID)
+	defer func() { tracing.End(span, err) }()
 
 	// Create new book
 	newBook, err := book.NewBook(
@@ -46,37 +76,328 @@ func (s *BookService) AddBookToList(userID, googleBookID, title string,
 	if err != nil {
 		return nil, err
 	}
+	newBook.ISBN = book.NormalizeISBN(isbn)
+	// Taken before the uow, which may run more than once
+	events := newBook.PullEvents()
 
-	// Save book
-	err = s.bookRepo.Save(newBook)
+	// Check the user and duplicates in the same transaction as the insert,
+	// so two similar books added at once can't both get in
+	err = s.uow.Do(ctx, func(ctx context.Context, repos Repositories) error {
+		if _, err := repos.Users.FindByID(ctx, userID); err != nil {
+			return err
+		}
+		if err := checkDuplicate(ctx, repos.Books, newBook, allowSimilar); err != nil {
+			return err
+		}
+		if err := repos.Books.Save(ctx, newBook); err != nil {
+			return err
+		}
+		return stage(ctx, repos, events)
+	})
+	var dupErr *book.DuplicateError
+	if errors.Is(err, book.ErrDuplicate) && !errors.As(err, &dupErr) {
+		// Another request committed the same volume first
+		return nil, s.duplicateOf(ctx, newBook)
+	}
 	if err != nil {
 		return nil, err
 	}
+	s.audit.Record(ctx, bookEvent(audit.ActionBookCreate, userID, newBook.ID, nil, newBook))
+	s.publish(ctx, events)
 
 	return newBook, nil
 }
 
 // GetUserBooks retrieves all books for a user
-func (s *BookService) GetUserBooks(userID string) ([]*book.Book, error) {
-	return s.bookRepo.FindByUserID(userID)
+func (s *BookService) GetUserBooks(ctx context.Context, userID string) (_ []*book.Book, err error) {
+	ctx, span := s.startSpan(ctx, "BookService.GetUserBooks", userID)
+	defer func() { tracing.End(span, err) }()
+
+	return s.bookRepo.FindByUserID(ctx, userID)
 }
 
 // GetUserBooksByStatus retrieves books for a user filtered by status
-func (s *BookService) GetUserBooksByStatus(userID string, status book.Status) ([]*book.Book, error) {
-	return s.bookRepo.FindByUserIDAndStatus(userID, status)
+func (s *BookService) GetUserBooksByStatus(ctx context.Context, userID string, status book.Status) (_ []*book.Book, err error) {
+	ctx, span := s.startSpan(ctx, "BookService.GetUserBooksByStatus", userID)
+	defer func() { tracing.End(span, err) }()
+
+	return s.bookRepo.FindByUserIDAndStatus(ctx, userID, status)
+}
+
+// GetBook retrieves a single book from the user's list
+func (s *BookService) GetBook(ctx context.Context, userID, bookID string) (_ *book.Book, err error) {
+	ctx, span := s.startSpan(ctx, "BookService.GetBook", userID)
+	defer func() { tracing.End(span, err) }()
+
+	return s.bookRepo.FindByIDAndUserID(ctx, bookID, userID)
 }
 
-// UpdateBookStatus changes the reading status of a book
-func (s *BookService) UpdateBookStatus(bookID string, status book.Status) error {
-	book, err := s.bookRepo.FindByID(bookID)
+// DeleteBook moves a book from the user's list to the trash. Its cached
+// cover is kept until the book is purged.
+func (s *BookService) DeleteBook(ctx context.Context, userID, bookID string) (err error) {
+	ctx, span := s.startSpan(ctx, "BookService.DeleteBook", userID)
+	defer func() { tracing.End(span, err) }()
+
+	// Load the book first so the audit log shows what was deleted
+	var b *book.Book
+	events := []event.Event{book.Deleted{BookID: bookID, UserID: userID}}
+	err = s.uow.Do(ctx, func(ctx context.Context, repos Repositories) error {
+		var err error
+		b, err = repos.Books.FindByIDAndUserID(ctx, bookID, userID)
+		if err != nil {
+			return err
+		}
+		if err := repos.Books.Delete(ctx, bookID, userID); err != nil {
+			return err
+		}
+		return stage(ctx, repos, events)
+	})
 	if err != nil {
 		return err
 	}
+	s.audit.Record(ctx, bookEvent(audit.ActionBookDelete, userID, bookID, b, nil))
+	s.publish(ctx, events)
+	return nil
+}
+
+// GetTrash retrieves the user's deleted books, most recently deleted first
+func (s *BookService) GetTrash(ctx context.Context, userID string) (_ []*book.Book, err error) {
+	ctx, span := s.startSpan(ctx, "BookService.GetTrash", userID)
+	defer func() { tracing.End(span, err) }()
+
+	return s.bookRepo.FindDeletedByUserID(ctx, userID)
+}
+
+// RestoreBook takes a book out of the trash and returns it. It fails with a
+// DuplicateError when the same volume is back in the user's list.
+func (s *BookService) RestoreBook(ctx context.Context, userID, bookID string) (_ *book.Book, err error) {
+	ctx, span := s.startSpan(ctx, "BookService.RestoreBook", userID)
+	defer func() { tracing.End(span, err) }()
+
+	var restored *book.Book
+	events := []event.Event{book.Restored{BookID: bookID, UserID: userID}}
+	err = s.uow.Do(ctx, func(ctx context.Context, repos Repositories) error {
+		if err := repos.Books.Restore(ctx, bookID, userID); err != nil {
+			return err
+		}
+		var err error
+		restored, err = repos.Books.FindByIDAndUserID(ctx, bookID, userID)
+		if err != nil {
+			return err
+		}
+		return stage(ctx, repos, events)
+	})
+	if errors.Is(err, book.ErrDuplicate) {
+		return nil, s.trashedDuplicateOf(ctx, userID, bookID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, bookEvent(audit.ActionBookRestore, userID, bookID, nil, nil))
+	s.publish(ctx, events)
+
+	return restored, nil
+}
+
+// PurgeTrash permanently removes every book trashed more than retention
+// ago, along with its cached covers, and returns how many were removed
+func (s *BookService) PurgeTrash(ctx context.Context, retention time.Duration) (_ int, err error) {
+	ctx, span := tracer.Start(ctx, "BookService.PurgeTrash")
+	defer func() { tracing.End(span, err) }()
+
+	ids, err := s.bookRepo.PurgeDeleted(ctx, time.Now().Add(-retention))
+	if err != nil {
+		return 0, err
+	}
+
+	if s.coverService != nil {
+		for _, id := range ids {
+			if err := s.coverService.RemoveCovers(id); err != nil {
+				s.logger.WarnContext(ctx, "failed to remove covers", "book_id", id, "error", err)
+			}
+		}
+	}
+
+	if len(ids) > 0 {
+		s.logger.InfoContext(ctx, "purged trash", "books", len(ids))
+	}
+	return len(ids), nil
+}
+
+// UpdateBookStatus changes the reading status of one of the user's books
+// and returns the updated book. version is the version the caller last
+// read; the update fails with book.ErrModified if the book has changed since.
+func (s *BookService) UpdateBookStatus(ctx context.Context, userID, bookID string, status book.Status, version int) (_ *book.Book, err error) {
+	ctx, span := s.startSpan(ctx, "BookService.UpdateBookStatus", userID)
+	defer func() { tracing.End(span, err) }()
+
+	var before book.Book
+	var updated *book.Book
+	var events []event.Event
+	err = s.uow.Do(ctx, func(ctx context.Context, repos Repositories) error {
+		b, err := repos.Books.FindByIDAndUserID(ctx, bookID, userID)
+		if err != nil {
+			return err
+		}
+		if b.Version != version {
+			return book.ErrModified
+		}
+		before = *b
+
+		if err := b.UpdateStatus(status); err != nil {
+			return err
+		}
+		events = b.PullEvents()
 
-	err = book.UpdateStatus(status)
+		if err := repos.Books.Update(ctx, b); err != nil {
+			return err
+		}
+		updated = b
+		return stage(ctx, repos, events)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, bookEvent(audit.ActionBookUpdate, userID, bookID, &before, updated))
+	s.publish(ctx, events)
+
+	return updated, nil
+}
+
+// MergeBooks folds the source entry into the target and moves the source
+// to the trash, returning the merged target
+func (s *BookService) MergeBooks(ctx context.Context, userID, targetID, sourceID string) (_ *book.Book, err error) {
+	ctx, span := s.startSpan(ctx, "BookService.MergeBooks", userID)
+	defer func() { tracing.End(span, err) }()
+
+	if targetID == sourceID {
+		return nil, domainerr.Validation("source_id", "cannot merge a book with itself")
+	}
+
+	// Updating the target and removing the source succeed or fail together
+	var before book.Book
+	var target *book.Book
+	var events []event.Event
+	err = s.uow.Do(ctx, func(ctx context.Context, repos Repositories) error {
+		var err error
+		target, err = repos.Books.FindByIDAndUserID(ctx, targetID, userID)
+		if err != nil {
+			return err
+		}
+		source, err := repos.Books.FindByIDAndUserID(ctx, sourceID, userID)
+		if err != nil {
+			return err
+		}
+
+		before = *target
+		target.Merge(source)
+		events = target.PullEvents()
+
+		if err := repos.Books.Update(ctx, target); err != nil {
+			return err
+		}
+		if err := repos.Books.Delete(ctx, source.ID, userID); err != nil {
+			return err
+		}
+		return stage(ctx, repos, events)
+	})
+	if err != nil {
+		return nil, err
+	}
+	e := bookEvent(audit.ActionBookMerge, userID, targetID, &before, target)
+	e.Details = map[string]string{"source_id": sourceID}
+	s.audit.Record(ctx, e)
+	s.publish(ctx, events)
+
+	return target, nil
+}
+
+// stage adds events to the unit of work's outbox, so they are delivered if
+// and only if its changes commit
+func stage(ctx context.Context, repos Repositories, events []event.Event) error {
+	msgs := make([]*event.Message, 0, len(events))
+	for _, e := range events {
+		m, err := event.NewMessage(e)
+		if err != nil {
+			return err
+		}
+		msgs = append(msgs, m)
+	}
+	return repos.Outbox.Add(ctx, msgs...)
+}
+
+// publish hands committed events to the synchronous subscribers. The change
+// is already saved, so their failures are logged rather than returned.
+func (s *BookService) publish(ctx context.Context, events []event.Event) {
+	if err := s.bus.Publish(ctx, events...); err != nil {
+		s.logger.ErrorContext(ctx, "event subscriber failed", "error", err)
+	}
+}
+
+// bookEvent describes a change a user made to one of their books
+func bookEvent(action audit.Action, userID, bookID string, before, after *book.Book) *audit.Event {
+	return &audit.Event{
+		Action:     action,
+		UserID:     userID,
+		ActorID:    userID,
+		TargetType: audit.TargetBook,
+		TargetID:   bookID,
+		Changes:    audit.Diff(before, after),
+	}
+}
+
+// checkDuplicate returns a DuplicateError if the user's library already
+// holds the same volume or, unless allowSimilar is set, a likely duplicate
+func checkDuplicate(ctx context.Context, books book.Repository, b *book.Book, allowSimilar bool) error {
+	existing, err := books.FindByUserIDAndGoogleID(ctx, b.UserID, b.GoogleID)
+	if err == nil {
+		return &book.DuplicateError{ExistingID: existing.ID, Reason: book.DuplicateGoogleID}
+	}
+	if !errors.Is(err, book.ErrNotFound) {
+		return err
+	}
+
+	if allowSimilar {
+		return nil
+	}
+
+	library, err := books.FindByUserID(ctx, b.UserID)
 	if err != nil {
 		return err
 	}
+	if match, reason, ok := book.FindDuplicate(b, library); ok {
+		return &book.DuplicateError{ExistingID: match.ID, Reason: reason}
+	}
+
+	return nil
+}
+
+// duplicateOf builds the DuplicateError for a book whose save hit the
+// unique (user_id, google_id) constraint
+func (s *BookService) duplicateOf(ctx context.Context, b *book.Book) error {
+	existing, err := s.bookRepo.FindByUserIDAndGoogleID(ctx, b.UserID, b.GoogleID)
+	if err != nil {
+		return book.ErrDuplicate
+	}
+	return &book.DuplicateError{ExistingID: existing.ID, Reason: book.DuplicateGoogleID}
+}
+
+// trashedDuplicateOf builds the DuplicateError for a trashed book that
+// can't be restored because its volume is back in the user's list
+func (s *BookService) trashedDuplicateOf(ctx context.Context, userID, bookID string) error {
+	trash, err := s.bookRepo.FindDeletedByUserID(ctx, userID)
+	if err != nil {
+		return book.ErrDuplicate
+	}
+	for _, b := range trash {
+		if b.ID == bookID {
+			return s.duplicateOf(ctx, b)
+		}
+	}
+	return book.ErrDuplicate
+}
 
-	return s.bookRepo.Update(book)
+// startSpan starts the span for a BookService method on behalf of userID
+func (s *BookService) startSpan(ctx context.Context, name, userID string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithAttributes(attribute.String("user.id", userID)))
 }
@@ -0,0 +1,376 @@
+package application_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/guisithos/save-my-read/internal/application"
+	"github.com/guisithos/save-my-read/internal/domain/audit"
+	"github.com/guisithos/save-my-read/internal/domain/book"
+	"github.com/guisithos/save-my-read/internal/domain/event"
+	"github.com/guisithos/save-my-read/internal/domain/user"
+	"github.com/guisithos/save-my-read/internal/infrastructure/memory"
+	"github.com/guisithos/save-my-read/internal/logging"
+)
+
+// bookServiceSetup is a BookService over an in-memory store
+type bookServiceSetup struct {
+	svc    *application.BookService
+	books  *memory.BookRepository
+	outbox *memory.OutboxStore
+}
+
+func newBookServiceSetup(t *testing.T, recorder audit.Recorder, bus *event.Bus) bookServiceSetup {
+	t.Helper()
+	store := memory.NewStore()
+	books, users := memory.NewBookRepository(store), memory.NewUserRepository(store)
+	for _, id := range []string{"alice", "bob"} {
+		if err := users.Save(context.Background(), &user.User{ID: id, Email: id + "@example.com"}); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+	svc := application.NewBookService(books, users, memory.NewUnitOfWork(store), nil, recorder, bus, logging.Discard())
+	return bookServiceSetup{svc: svc, books: books, outbox: memory.NewOutboxStore(store)}
+}
+
+func newTestBookService(t *testing.T) (*application.BookService, *memory.BookRepository) {
+	t.Helper()
+	s := newBookServiceSetup(t, nil, nil)
+	return s.svc, s.books
+}
+
+func TestBookService_CrossUserAccess(t *testing.T) {
+	svc, repo := newTestBookService(t)
+
+	aliceBook, err := svc.AddBookToList(context.Background(), "alice", "g1", "Dune", []string{"Frank Herbert"}, "", nil, "", "", book.StatusToRead, false)
+	if err != nil {
+		t.Fatalf("AddBookToList() error = %v", err)
+	}
+	aliceOther, err := svc.AddBookToList(context.Background(), "alice", "g2", "Emma", []string{"Jane Austen"}, "", nil, "", "", book.StatusToRead, false)
+	if err != nil {
+		t.Fatalf("AddBookToList() error = %v", err)
+	}
+	bobBook, err := svc.AddBookToList(context.Background(), "bob", "g3", "Hyperion", []string{"Dan Simmons"}, "", nil, "", "", book.StatusToRead, false)
+	if err != nil {
+		t.Fatalf("AddBookToList() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{"get", func() error { _, err := svc.GetBook(context.Background(), "bob", aliceBook.ID); return err }},
+		{"update status", func() error {
+			_, err := svc.UpdateBookStatus(context.Background(), "bob", aliceBook.ID, book.StatusCompleted, aliceBook.Version)
+			return err
+		}},
+		{"delete", func() error { return svc.DeleteBook(context.Background(), "bob", aliceBook.ID) }},
+		{"merge foreign target", func() error {
+			_, err := svc.MergeBooks(context.Background(), "bob", aliceBook.ID, bobBook.ID)
+			return err
+		}},
+		{"merge foreign source", func() error {
+			_, err := svc.MergeBooks(context.Background(), "bob", bobBook.ID, aliceBook.ID)
+			return err
+		}},
+		{"merge two foreign books", func() error {
+			_, err := svc.MergeBooks(context.Background(), "bob", aliceBook.ID, aliceOther.ID)
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); !errors.Is(err, book.ErrNotFound) {
+				t.Errorf("expected ErrNotFound, got %v", err)
+			}
+		})
+	}
+
+	stored, err := repo.FindByID(context.Background(), aliceBook.ID)
+	if err != nil {
+		t.Fatalf("alice's book was deleted by another user: %v", err)
+	}
+	if stored.Status != book.StatusToRead {
+		t.Errorf("alice's book status changed to %s", stored.Status)
+	}
+	if _, err := repo.FindByID(context.Background(), bobBook.ID); err != nil {
+		t.Errorf("bob's book was removed by a failed merge: %v", err)
+	}
+
+	bobBooks, _ := svc.GetUserBooks(context.Background(), "bob")
+	if len(bobBooks) != 1 || bobBooks[0].ID != bobBook.ID {
+		t.Errorf("expected bob to see only his own book, got %v", bobBooks)
+	}
+}
+
+func TestBookService_OwnerAccess(t *testing.T) {
+	svc, repo := newTestBookService(t)
+
+	b, err := svc.AddBookToList(context.Background(), "alice", "g1", "Dune", []string{"Frank Herbert"}, "", nil, "", "", book.StatusToRead, false)
+	if err != nil {
+		t.Fatalf("AddBookToList() error = %v", err)
+	}
+
+	updated, err := svc.UpdateBookStatus(context.Background(), "alice", b.ID, book.StatusReading, b.Version)
+	if err != nil {
+		t.Fatalf("UpdateBookStatus() error = %v", err)
+	}
+	if updated.Version != b.Version+1 {
+		t.Errorf("expected version %d, got %d", b.Version+1, updated.Version)
+	}
+	if _, err := svc.UpdateBookStatus(context.Background(), "alice", b.ID, book.StatusCompleted, b.Version); !errors.Is(err, book.ErrModified) {
+		t.Errorf("expected ErrModified for a stale version, got %v", err)
+	}
+	if stored, _ := repo.FindByID(context.Background(), b.ID); stored == nil || stored.Status != book.StatusReading {
+		t.Errorf("expected status READING, got %+v", stored)
+	}
+
+	if err := svc.DeleteBook(context.Background(), "alice", b.ID); err != nil {
+		t.Fatalf("DeleteBook() error = %v", err)
+	}
+	if _, err := repo.FindByID(context.Background(), b.ID); !errors.Is(err, book.ErrNotFound) {
+		t.Errorf("expected book to be deleted, got %v", err)
+	}
+	if trash, _ := repo.FindDeletedByUserID(context.Background(), "alice"); len(trash) != 1 || trash[0].ID != b.ID {
+		t.Error("expected book to be in the trash")
+	}
+}
+
+func TestBookService_TrashAndRestore(t *testing.T) {
+	svc, _ := newTestBookService(t)
+	ctx := context.Background()
+
+	b, err := svc.AddBookToList(ctx, "alice", "g1", "Dune", []string{"Frank Herbert"}, "", nil, "", "", book.StatusToRead, false)
+	if err != nil {
+		t.Fatalf("AddBookToList() error = %v", err)
+	}
+	if err := svc.DeleteBook(ctx, "alice", b.ID); err != nil {
+		t.Fatalf("DeleteBook() error = %v", err)
+	}
+
+	trash, err := svc.GetTrash(ctx, "alice")
+	if err != nil {
+		t.Fatalf("GetTrash() error = %v", err)
+	}
+	if len(trash) != 1 || trash[0].ID != b.ID {
+		t.Fatalf("expected %s in the trash, got %v", b.ID, trash)
+	}
+	if _, err := svc.RestoreBook(ctx, "bob", b.ID); !errors.Is(err, book.ErrNotFound) {
+		t.Errorf("expected ErrNotFound restoring another user's book, got %v", err)
+	}
+
+	restored, err := svc.RestoreBook(ctx, "alice", b.ID)
+	if err != nil {
+		t.Fatalf("RestoreBook() error = %v", err)
+	}
+	if restored.ID != b.ID || restored.DeletedAt != nil {
+		t.Errorf("unexpected restored book %+v", restored)
+	}
+	if _, err := svc.GetBook(ctx, "alice", b.ID); err != nil {
+		t.Errorf("expected the book back in the list, got %v", err)
+	}
+}
+
+func TestBookService_RestoreDuplicate(t *testing.T) {
+	svc, _ := newTestBookService(t)
+	ctx := context.Background()
+
+	old, err := svc.AddBookToList(ctx, "alice", "g1", "Dune", []string{"Frank Herbert"}, "", nil, "", "", book.StatusToRead, false)
+	if err != nil {
+		t.Fatalf("AddBookToList() error = %v", err)
+	}
+	if err := svc.DeleteBook(ctx, "alice", old.ID); err != nil {
+		t.Fatalf("DeleteBook() error = %v", err)
+	}
+	again, err := svc.AddBookToList(ctx, "alice", "g1", "Dune", []string{"Frank Herbert"}, "", nil, "", "", book.StatusReading, false)
+	if err != nil {
+		t.Fatalf("expected a trashed volume to be addable again, got %v", err)
+	}
+
+	_, err = svc.RestoreBook(ctx, "alice", old.ID)
+	var dupErr *book.DuplicateError
+	if !errors.As(err, &dupErr) || dupErr.ExistingID != again.ID {
+		t.Fatalf("expected DuplicateError pointing at %s, got %v", again.ID, err)
+	}
+}
+
+func TestBookService_PurgeTrash(t *testing.T) {
+	svc, repo := newTestBookService(t)
+	ctx := context.Background()
+
+	var ids []string
+	var between time.Time
+	for _, id := range []string{"g1", "g2"} {
+		b, err := svc.AddBookToList(ctx, "alice", id, "Book "+id, []string{"Author"}, "", nil, "", "", book.StatusToRead, false)
+		if err != nil {
+			t.Fatalf("AddBookToList() error = %v", err)
+		}
+		if err := svc.DeleteBook(ctx, "alice", b.ID); err != nil {
+			t.Fatalf("DeleteBook() error = %v", err)
+		}
+		ids = append(ids, b.ID)
+		if between.IsZero() {
+			between = time.Now()
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	// Only the first book was deleted longer ago than the retention
+	n, err := svc.PurgeTrash(ctx, time.Since(between))
+	if err != nil {
+		t.Fatalf("PurgeTrash() error = %v", err)
+	}
+	if n != 1 {
+		t.Errorf("expected 1 book purged, got %d", n)
+	}
+	trash, _ := repo.FindDeletedByUserID(ctx, "alice")
+	if len(trash) != 1 || trash[0].ID != ids[1] {
+		t.Errorf("expected only the recent book to stay in the trash, got %v", trash)
+	}
+}
+
+func TestBookService_AddDuplicate(t *testing.T) {
+	svc, _ := newTestBookService(t)
+
+	first, err := svc.AddBookToList(context.Background(), "alice", "g1", "Dune", []string{"Frank Herbert"}, "", nil, "", "", book.StatusToRead, false)
+	if err != nil {
+		t.Fatalf("AddBookToList() error = %v", err)
+	}
+
+	_, err = svc.AddBookToList(context.Background(), "alice", "g1", "Dune", []string{"Frank Herbert"}, "", nil, "", "", book.StatusToRead, false)
+	var dupErr *book.DuplicateError
+	if !errors.As(err, &dupErr) || dupErr.ExistingID != first.ID {
+		t.Fatalf("expected DuplicateError pointing at %s, got %v", first.ID, err)
+	}
+
+	// The same volume in another user's library is not a duplicate
+	if _, err := svc.AddBookToList(context.Background(), "bob", "g1", "Dune", []string{"Frank Herbert"}, "", nil, "", "", book.StatusToRead, false); err != nil {
+		t.Errorf("expected bob to add the book, got %v", err)
+	}
+}
+
+func TestBookService_AuditsChanges(t *testing.T) {
+	events := memory.NewAuditStore()
+	recorder := application.NewAuditService(events, logging.Discard())
+	svc := newBookServiceSetup(t, recorder, nil).svc
+	ctx := context.Background()
+
+	b, err := svc.AddBookToList(ctx, "alice", "g1", "Dune", []string{"Frank Herbert"}, "", nil, "", "", book.StatusToRead, false)
+	if err != nil {
+		t.Fatalf("AddBookToList() error = %v", err)
+	}
+	if _, err := svc.UpdateBookStatus(ctx, "alice", b.ID, book.StatusReading, b.Version); err != nil {
+		t.Fatalf("UpdateBookStatus() error = %v", err)
+	}
+	if _, err := svc.UpdateBookStatus(ctx, "alice", b.ID, book.StatusCompleted, b.Version); !errors.Is(err, book.ErrModified) {
+		t.Fatalf("expected ErrModified, got %v", err)
+	}
+	if err := svc.DeleteBook(ctx, "alice", b.ID); err != nil {
+		t.Fatalf("DeleteBook() error = %v", err)
+	}
+
+	recorder.Close()
+	recorded, err := events.List(ctx, audit.Filter{})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	slices.Reverse(recorded)
+
+	want := []audit.Action{audit.ActionBookCreate, audit.ActionBookUpdate, audit.ActionBookDelete}
+	if len(recorded) != len(want) {
+		t.Fatalf("expected %d events for successful changes, got %d", len(want), len(recorded))
+	}
+	for i, e := range recorded {
+		if e.Action != want[i] || e.UserID != "alice" || e.ActorID != "alice" || e.TargetID != b.ID {
+			t.Errorf("event %d: unexpected %+v", i, e)
+		}
+	}
+
+	if c := recorded[0].Changes["title"]; c.From != nil || c.To != "Dune" {
+		t.Errorf("expected the created title, got %+v", c)
+	}
+	update := recorded[1].Changes
+	if c := update["status"]; c.From != string(book.StatusToRead) || c.To != string(book.StatusReading) {
+		t.Errorf("expected the status change, got %+v", c)
+	}
+	if _, ok := update["title"]; ok {
+		t.Errorf("expected unchanged fields left out, got %+v", update)
+	}
+	if c := recorded[2].Changes["title"]; c.From != "Dune" || c.To != nil {
+		t.Errorf("expected the deleted title, got %+v", c)
+	}
+}
+
+func TestBookService_EmitsEvents(t *testing.T) {
+	bus := event.NewBus()
+	var published []string
+	bus.Subscribe(func(_ context.Context, e event.Event) error {
+		published = append(published, e.EventName())
+		return errors.New("subscriber failed")
+	})
+	s := newBookServiceSetup(t, nil, bus)
+	svc := s.svc
+	ctx := context.Background()
+
+	dune, err := svc.AddBookToList(ctx, "alice", "g1", "Dune", []string{"Frank Herbert"}, "", nil, "", "", book.StatusToRead, false)
+	if err != nil {
+		t.Fatalf("AddBookToList() error = %v", err)
+	}
+	emma, err := svc.AddBookToList(ctx, "alice", "g2", "Emma", []string{"Jane Austen"}, "", nil, "", "", book.StatusToRead, false)
+	if err != nil {
+		t.Fatalf("AddBookToList() error = %v", err)
+	}
+	dune, err = svc.UpdateBookStatus(ctx, "alice", dune.ID, book.StatusReading, dune.Version)
+	if err != nil {
+		t.Fatalf("UpdateBookStatus() error = %v", err)
+	}
+	// Setting the same status again is not a change
+	dune, err = svc.UpdateBookStatus(ctx, "alice", dune.ID, book.StatusReading, dune.Version)
+	if err != nil {
+		t.Fatalf("UpdateBookStatus() error = %v", err)
+	}
+	if _, err := svc.MergeBooks(ctx, "alice", dune.ID, emma.ID); err != nil {
+		t.Fatalf("MergeBooks() error = %v", err)
+	}
+	if _, err := svc.RestoreBook(ctx, "alice", emma.ID); err != nil {
+		t.Fatalf("RestoreBook() error = %v", err)
+	}
+	if err := svc.DeleteBook(ctx, "alice", emma.ID); err != nil {
+		t.Fatalf("DeleteBook() error = %v", err)
+	}
+	if err := svc.DeleteBook(ctx, "alice", emma.ID); err == nil {
+		t.Fatal("expected deleting a trashed book to fail")
+	}
+
+	want := []string{
+		book.EventAdded, book.EventAdded, book.EventStatusChanged,
+		book.EventMerged, book.EventRestored, book.EventDeleted,
+	}
+	msgs, err := s.outbox.Claim(ctx, time.Now(), time.Minute, 100)
+	if err != nil {
+		t.Fatalf("Claim() error = %v", err)
+	}
+	var stored []string
+	for _, m := range msgs {
+		stored = append(stored, m.Name)
+	}
+	if !slices.Equal(stored, want) {
+		t.Errorf("expected outbox messages %v, got %v", want, stored)
+	}
+	if !slices.Equal(published, want) {
+		t.Errorf("expected published events %v, got %v", want, published)
+	}
+
+	var changed book.StatusChanged
+	if err := json.Unmarshal(msgs[2].Payload, &changed); err != nil {
+		t.Fatal(err)
+	}
+	if changed.BookID != dune.ID || changed.From != book.StatusToRead || changed.To != book.StatusReading {
+		t.Errorf("unexpected status change payload %+v", changed)
+	}
+}
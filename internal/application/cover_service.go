@@ -0,0 +1,392 @@
+package application
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/guisithos/save-my-read/internal/domain/book"
+	"github.com/guisithos/save-my-read/internal/domain/cover"
+	"github.com/guisithos/save-my-read/internal/domain/domainerr"
+	"github.com/guisithos/save-my-read/internal/domain/event"
+	"github.com/guisithos/save-my-read/internal/logging"
+	"github.com/guisithos/save-my-read/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// maxCoverBytes caps the size of a downloaded cover image
+	maxCoverBytes = 5 << 20
+	// maxCoverPixels caps the dimensions of a cover that is decoded; a small,
+	// highly compressed file can declare enough pixels to exhaust memory
+	maxCoverPixels = 40_000_000
+	// maxCoverRedirects caps the redirects followed downloading a cover
+	maxCoverRedirects = 5
+)
+
+// CoverService caches book covers locally and serves resized variants
+type CoverService struct {
+	store        cover.Store
+	bookRepo     book.Repository
+	httpClient   *http.Client
+	allowedHosts map[string]bool
+	logger       *slog.Logger
+}
+
+// NewCoverService creates a new CoverService that only downloads covers
+// from the given hosts, redirects included; a nil logger uses
+// slog.Default()
+func NewCoverService(store cover.Store, bookRepo book.Repository, httpClient *http.Client, allowedHosts []string, logger *slog.Logger) *CoverService {
+	hosts := make(map[string]bool, len(allowedHosts))
+	for _, h := range allowedHosts {
+		hosts[strings.ToLower(h)] = true
+	}
+
+	s := &CoverService{
+		store:        store,
+		bookRepo:     bookRepo,
+		allowedHosts: hosts,
+		logger:       logging.OrDefault(logger),
+	}
+	// An allowed host must not be able to redirect the download elsewhere
+	client := *httpClient
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= maxCoverRedirects {
+			return errors.New("too many redirects downloading cover")
+		}
+		return s.checkHost(req.URL)
+	}
+	s.httpClient = &client
+	return s
+}
+
+// CacheCover downloads the cover of a book that was added. Subscribe it to
+// an OutboxDispatcher for book.added events, so adding a book does not wait
+// for the download and a failed one is retried. Covers from hosts that are
+// not allowed are skipped, as retrying would not help.
+func (s *CoverService) CacheCover(ctx context.Context, m *event.Message) error {
+	var added book.Added
+	if err := json.Unmarshal(m.Payload, &added); err != nil {
+		return fmt.Errorf("error decoding %s event: %w", m.Name, err)
+	}
+	b, err := s.bookRepo.FindByID(ctx, added.BookID)
+	if errors.Is(err, book.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if b.ImageURL == "" {
+		return nil
+	}
+	if _, err := s.sourceURL(b.ImageURL); err != nil {
+		s.logger.WarnContext(ctx, "not caching cover", "book_id", b.ID, "error", err)
+		return nil
+	}
+	return s.FetchCover(ctx, b.ID, b.ImageURL)
+}
+
+// FetchCover downloads the image at sourceURL and stores it as the book's
+// original cover, discarding any previously generated variants
+func (s *CoverService) FetchCover(ctx context.Context, bookID, sourceURL string) (err error) {
+	ctx, span := tracer.Start(ctx, "CoverService.FetchCover", trace.WithAttributes(attribute.String("book.id", bookID)))
+	defer func() { tracing.End(span, err) }()
+
+	u, err := s.sourceURL(sourceURL)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to build cover request: %w", err)
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to download cover: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code downloading cover: %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxCoverBytes+1))
+	if err != nil {
+		return fmt.Errorf("failed to read cover: %w", err)
+	}
+	if len(data) > maxCoverBytes {
+		return errors.New("cover image is too large")
+	}
+
+	contentType := http.DetectContentType(data)
+	if !strings.HasPrefix(contentType, "image/") {
+		return fmt.Errorf("cover is not an image: %s", contentType)
+	}
+
+	for _, size := range []cover.Size{cover.SizeSmall, cover.SizeMedium, cover.SizeLarge} {
+		if err := s.store.Delete(cover.Key(bookID, size)); err != nil {
+			return err
+		}
+	}
+
+	return s.store.Put(cover.Key(bookID, cover.SizeOriginal), data, contentType)
+}
+
+// GetCover returns a book's cover in the requested size. Variants are
+// generated and cached on first use; when no cover can be found a
+// placeholder showing the title and authors is returned instead.
+func (s *CoverService) GetCover(ctx context.Context, bookID string, size cover.Size) (_ *cover.Image, err error) {
+	ctx, span := tracer.Start(ctx, "CoverService.GetCover", trace.WithAttributes(
+		attribute.String("book.id", bookID),
+		attribute.String("cover.size", string(size)),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	if !size.IsValid() {
+		return nil, domainerr.Validation("size", "unsupported cover size")
+	}
+
+	img, err := s.store.Get(cover.Key(bookID, size))
+	if err == nil {
+		return img, nil
+	}
+	if !errors.Is(err, cover.ErrNotFound) {
+		return nil, err
+	}
+
+	original, err := s.original(ctx, bookID)
+	if err != nil {
+		return nil, err
+	}
+	if original.Placeholder || size == cover.SizeOriginal {
+		return original, nil
+	}
+
+	variant, err := resizeImage(original, size.Width())
+	if err != nil {
+		// Serve the original rather than failing on images we can't decode
+		s.logger.WarnContext(ctx, "failed to resize cover", "book_id", bookID, "size", size, "error", err)
+		return original, nil
+	}
+
+	if err := s.store.Put(cover.Key(bookID, size), variant.Data, variant.ContentType); err != nil {
+		s.logger.WarnContext(ctx, "failed to cache cover variant", "book_id", bookID, "size", size, "error", err)
+	}
+
+	return variant, nil
+}
+
+// RemoveCovers deletes every stored variant of a book's cover
+func (s *CoverService) RemoveCovers(bookID string) error {
+	for _, size := range []cover.Size{cover.SizeOriginal, cover.SizeSmall, cover.SizeMedium, cover.SizeLarge} {
+		if err := s.store.Delete(cover.Key(bookID, size)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// original returns the stored original cover, downloading it from the
+// book's image URL on a cache miss and falling back to a placeholder
+func (s *CoverService) original(ctx context.Context, bookID string) (*cover.Image, error) {
+	img, err := s.store.Get(cover.Key(bookID, cover.SizeOriginal))
+	if err == nil {
+		return img, nil
+	}
+	if !errors.Is(err, cover.ErrNotFound) {
+		return nil, err
+	}
+
+	b, err := s.bookRepo.FindByID(ctx, bookID)
+	if err != nil {
+		return nil, err
+	}
+
+	if b.ImageURL != "" {
+		if err := s.FetchCover(ctx, bookID, b.ImageURL); err != nil {
+			s.logger.WarnContext(ctx, "failed to fetch cover", "book_id", bookID, "error", err)
+		} else if img, err := s.store.Get(cover.Key(bookID, cover.SizeOriginal)); err == nil {
+			return img, nil
+		}
+	}
+
+	return placeholder(b), nil
+}
+
+// sourceURL checks that a cover URL points at an allowed host, upgrading
+// plain http links to https
+func (s *CoverService) sourceURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cover URL: %w", err)
+	}
+	if err := s.checkHost(u); err != nil {
+		return nil, err
+	}
+
+	if u.Scheme == "http" {
+		u.Scheme = "https"
+	}
+	return u, nil
+}
+
+// checkHost refuses URLs that are not http(s) or point outside the allowed
+// hosts
+func (s *CoverService) checkHost(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported cover URL scheme %q", u.Scheme)
+	}
+	if !s.allowedHosts[strings.ToLower(u.Hostname())] {
+		return fmt.Errorf("cover host %q is not allowed", u.Hostname())
+	}
+	return nil
+}
+
+// resizeImage scales an image down to the given width, keeping its aspect
+// ratio. Images already narrower than width are returned unchanged.
+func resizeImage(img *cover.Image, width int) (*cover.Image, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(img.Data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode cover: %w", err)
+	}
+	if int64(cfg.Width)*int64(cfg.Height) > maxCoverPixels {
+		return nil, fmt.Errorf("cover of %dx%d pixels is too large to decode", cfg.Width, cfg.Height)
+	}
+
+	src, format, err := image.Decode(bytes.NewReader(img.Data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode cover: %w", err)
+	}
+
+	bounds := src.Bounds()
+	if bounds.Dx() <= width {
+		return img, nil
+	}
+	height := bounds.Dy() * width / bounds.Dx()
+	if height < 1 {
+		height = 1
+	}
+
+	dst := image.NewRGBA64(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + y*bounds.Dy()/height
+		y1 := max(bounds.Min.Y+(y+1)*bounds.Dy()/height, y0+1)
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*bounds.Dx()/width
+			x1 := max(bounds.Min.X+(x+1)*bounds.Dx()/width, x0+1)
+
+			// Average every source pixel covered by the destination pixel
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			dst.SetRGBA64(x, y, color.RGBA64{
+				R: uint16(r / n), G: uint16(g / n), B: uint16(b / n), A: uint16(a / n),
+			})
+		}
+	}
+
+	var buf bytes.Buffer
+	contentType := "image/jpeg"
+	if format == "png" {
+		contentType = "image/png"
+		err = png.Encode(&buf, dst)
+	} else {
+		err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode cover: %w", err)
+	}
+
+	return &cover.Image{
+		Data:        buf.Bytes(),
+		ContentType: contentType,
+		ModTime:     time.Now(),
+	}, nil
+}
+
+// placeholder generates an SVG cover showing the book's title and authors
+func placeholder(b *book.Book) *cover.Image {
+	var svg strings.Builder
+	svg.WriteString(`<svg xmlns="http://www.w3.org/2000/svg" width="256" height="384" viewBox="0 0 256 384">`)
+	svg.WriteString(`<rect width="256" height="384" fill="#4f46e5"/>`)
+	svg.WriteString(`<rect x="12" y="12" width="232" height="360" fill="none" stroke="#c7d2fe" stroke-width="2"/>`)
+	svg.WriteString(`<g font-family="sans-serif" fill="#ffffff" text-anchor="middle">`)
+
+	y := 120
+	for _, line := range wrapText(b.Title, 18, 5) {
+		fmt.Fprintf(&svg, `<text x="128" y="%d" font-size="22" font-weight="bold">%s</text>`, y, escapeXML(line))
+		y += 28
+	}
+	y += 20
+	for _, line := range wrapText(strings.Join(b.Authors, ", "), 24, 3) {
+		fmt.Fprintf(&svg, `<text x="128" y="%d" font-size="16" fill-opacity="0.85">%s</text>`, y, escapeXML(line))
+		y += 22
+	}
+
+	svg.WriteString(`</g></svg>`)
+
+	return &cover.Image{
+		Data:        []byte(svg.String()),
+		ContentType: "image/svg+xml",
+		ModTime:     b.UpdatedAt,
+		Placeholder: true,
+	}
+}
+
+// wrapText splits text into lines of at most width runes, truncating after maxLines
+func wrapText(text string, width, maxLines int) []string {
+	var lines []string
+	var current string
+	for _, word := range strings.Fields(text) {
+		switch {
+		case current == "":
+			current = word
+		case len([]rune(current))+1+len([]rune(word)) <= width:
+			current += " " + word
+		default:
+			lines = append(lines, current)
+			current = word
+		}
+	}
+	if current != "" {
+		lines = append(lines, current)
+	}
+
+	for i, line := range lines {
+		if r := []rune(line); len(r) > width {
+			lines[i] = string(r[:width-1]) + "…"
+		}
+	}
+	if len(lines) > maxLines {
+		lines = lines[:maxLines]
+		lines[maxLines-1] += "…"
+	}
+	return lines
+}
+
+func escapeXML(s string) string {
+	var buf bytes.Buffer
+	_ = xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
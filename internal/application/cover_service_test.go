@@ -0,0 +1,204 @@
+package application
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/guisithos/save-my-read/internal/domain/book"
+	"github.com/guisithos/save-my-read/internal/domain/cover"
+	"github.com/guisithos/save-my-read/internal/domain/event"
+	"github.com/guisithos/save-my-read/internal/infrastructure/filestore"
+	"github.com/guisithos/save-my-read/internal/logging"
+)
+
+type stubBookRepo struct {
+	book.Repository
+	books map[string]*book.Book
+}
+
+func (r *stubBookRepo) FindByID(_ context.Context, id string) (*book.Book, error) {
+	if b, ok := r.books[id]; ok {
+		return b, nil
+	}
+	return nil, book.ErrNotFound
+}
+
+func testPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: 200, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestCoverService_FetchAndResize(t *testing.T) {
+	original := testPNG(t, 600, 900)
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(original)
+	}))
+	defer srv.Close()
+	host := mustHost(t, srv.URL)
+
+	store, err := filestore.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := &stubBookRepo{books: map[string]*book.Book{
+		"b1": {ID: "b1", Title: "Dune", ImageURL: srv.URL + "/cover.png"},
+	}}
+	svc := NewCoverService(store, repo, srv.Client(), []string{host}, logging.Discard())
+
+	img, err := svc.GetCover(context.Background(), "b1", cover.SizeSmall)
+	if err != nil {
+		t.Fatalf("GetCover() error = %v", err)
+	}
+	if img.Placeholder {
+		t.Fatal("expected downloaded cover, got placeholder")
+	}
+
+	decoded, _, err := image.Decode(bytes.NewReader(img.Data))
+	if err != nil {
+		t.Fatalf("failed to decode variant: %v", err)
+	}
+	if w := decoded.Bounds().Dx(); w != cover.SizeSmall.Width() {
+		t.Errorf("expected width %d, got %d", cover.SizeSmall.Width(), w)
+	}
+
+	if _, err := store.Get(cover.Key("b1", cover.SizeSmall)); err != nil {
+		t.Errorf("expected small variant to be cached: %v", err)
+	}
+}
+
+func TestCoverService_RefusesRedirectOffAllowList(t *testing.T) {
+	var reached bool
+	elsewhere := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+		w.Write(testPNG(t, 10, 10))
+	}))
+	defer elsewhere.Close()
+	// Same address, another host name, so only the allow-list stops it
+	target := strings.Replace(elsewhere.URL, "127.0.0.1", "localhost", 1) + "/x.png"
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target, http.StatusFound)
+	}))
+	defer srv.Close()
+
+	store, err := filestore.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := NewCoverService(store, &stubBookRepo{}, srv.Client(), []string{mustHost(t, srv.URL)}, logging.Discard())
+
+	err = svc.FetchCover(context.Background(), "b1", srv.URL+"/cover.png")
+	if err == nil || !strings.Contains(err.Error(), `"localhost" is not allowed`) {
+		t.Fatalf("expected the redirect to be refused, got %v", err)
+	}
+	if reached {
+		t.Error("redirect target was requested")
+	}
+}
+
+func TestCoverService_CacheCover(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(testPNG(t, 60, 90))
+	}))
+	defer srv.Close()
+
+	store, err := filestore.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := &stubBookRepo{books: map[string]*book.Book{
+		"b1": {ID: "b1", Title: "Dune", ImageURL: srv.URL + "/cover.png"},
+		"b2": {ID: "b2", Title: "Emma", ImageURL: "https://evil.example/x.png"},
+	}}
+	svc := NewCoverService(store, repo, srv.Client(), []string{mustHost(t, srv.URL)}, logging.Discard())
+
+	for _, id := range []string{"b1", "b2", "gone"} {
+		m, err := event.NewMessage(book.Added{BookID: id})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := svc.CacheCover(context.Background(), m); err != nil {
+			t.Errorf("CacheCover(%s) error = %v", id, err)
+		}
+	}
+
+	if _, err := store.Get(cover.Key("b1", cover.SizeOriginal)); err != nil {
+		t.Errorf("expected the cover to be cached: %v", err)
+	}
+	if _, err := store.Get(cover.Key("b2", cover.SizeOriginal)); err == nil {
+		t.Error("expected the cover from a host not allowed to be skipped")
+	}
+}
+
+func TestResizeImage_RejectsHugeDimensions(t *testing.T) {
+	// Rewrite the PNG header to declare 10000x10000 pixels, as a
+	// decompression bomb would, without the pixel data to back it
+	data := testPNG(t, 4, 4)
+	binary.BigEndian.PutUint32(data[16:], 10000)
+	binary.BigEndian.PutUint32(data[20:], 10000)
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+
+	_, err := resizeImage(&cover.Image{Data: data, ContentType: "image/png"}, 200)
+	if err == nil || !strings.Contains(err.Error(), "too large") {
+		t.Fatalf("expected the image to be rejected before decoding, got %v", err)
+	}
+
+	if _, err := resizeImage(&cover.Image{Data: testPNG(t, 400, 600), ContentType: "image/png"}, 200); err != nil {
+		t.Errorf("expected an ordinary cover to resize, got %v", err)
+	}
+}
+
+func TestCoverService_PlaceholderAndHostAllowList(t *testing.T) {
+	store, err := filestore.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := &stubBookRepo{books: map[string]*book.Book{
+		"b1": {ID: "b1", Title: "Pride & Prejudice", Authors: []string{"Jane Austen"}, ImageURL: "https://evil.example/x.png"},
+	}}
+	svc := NewCoverService(store, repo, http.DefaultClient, []string{"books.google.com"}, logging.Discard())
+
+	img, err := svc.GetCover(context.Background(), "b1", cover.SizeMedium)
+	if err != nil {
+		t.Fatalf("GetCover() error = %v", err)
+	}
+	if !img.Placeholder || img.ContentType != "image/svg+xml" {
+		t.Fatalf("expected SVG placeholder, got %q", img.ContentType)
+	}
+	if !strings.Contains(string(img.Data), "Pride &amp; Prejudice") || !strings.Contains(string(img.Data), "Jane Austen") {
+		t.Errorf("placeholder missing escaped title or author: %s", img.Data)
+	}
+
+	if _, err := svc.GetCover(context.Background(), "missing", cover.SizeMedium); err != book.ErrNotFound {
+		t.Errorf("expected ErrNotFound for unknown book, got %v", err)
+	}
+}
+
+func mustHost(t *testing.T, raw string) string {
+	t.Helper()
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.Hostname()
+}
@@ -0,0 +1,208 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/guisithos/save-my-read/internal/domain/event"
+)
+
+const (
+	defaultHubHistory = 1000
+	// hubBuffer is how many events a subscriber may fall behind by before
+	// it is dropped
+	hubBuffer = 64
+)
+
+// LiveEvent is an event as streamed to a user's open clients
+type LiveEvent struct {
+	// ID orders the events and lets a client resume after it
+	ID   string
+	Name string
+	// Data is the event encoded as JSON
+	Data []byte
+}
+
+// EventHub fans the domain events published on the bus out to the
+// clients of the user they concern, such as browser tabs following the
+// library. It keeps the latest events so a client that reconnects can
+// catch up on what it missed. The hub lives in the process, so clients
+// only see changes made through the same instance.
+type EventHub struct {
+	// epoch tells event IDs of this process from those of an earlier one
+	epoch   string
+	history int
+
+	mu     sync.Mutex
+	seq    uint64
+	recent []hubEntry
+	subs   map[*Subscription]struct{}
+	closed bool
+}
+
+type hubEntry struct {
+	seq    uint64
+	userID string
+	event  LiveEvent
+}
+
+// NewEventHub creates a hub keeping the latest history events for
+// resuming, 1000 when history is not positive
+func NewEventHub(history int) *EventHub {
+	if history <= 0 {
+		history = defaultHubHistory
+	}
+	return &EventHub{
+		epoch:   strconv.FormatInt(time.Now().UnixNano(), 36),
+		history: history,
+		subs:    make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish hands e to the subscribers of the user it belongs to. It never
+// blocks: a subscriber that has fallen too far behind is dropped and can
+// resume from the history. Subscribe it to the event bus.
+func (h *EventHub) Publish(_ context.Context, e event.Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("error encoding %s event: %w", e.EventName(), err)
+	}
+	var owner struct {
+		UserID string `json:"user_id"`
+	}
+	if err := json.Unmarshal(data, &owner); err != nil || owner.UserID == "" {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return nil
+	}
+	h.seq++
+	entry := hubEntry{
+		seq:    h.seq,
+		userID: owner.UserID,
+		event:  LiveEvent{ID: h.eventID(h.seq), Name: e.EventName(), Data: data},
+	}
+	if len(h.recent) == h.history {
+		h.recent = append(h.recent[:0], h.recent[1:]...)
+	}
+	h.recent = append(h.recent, entry)
+
+	for sub := range h.subs {
+		if sub.userID != owner.UserID {
+			continue
+		}
+		select {
+		case sub.events <- entry.event:
+		default:
+			h.drop(sub)
+		}
+	}
+	return nil
+}
+
+// Subscribe follows the user's events. With the ID of the last event a
+// client received, the events it missed since are queued first; if they
+// are no longer known, as after a restart, the subscription is marked
+// Stale and the client should reload instead. Close the subscription when
+// done.
+func (h *EventHub) Subscribe(userID, lastEventID string) *Subscription {
+	sub := &Subscription{hub: h, userID: userID}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var missed []LiveEvent
+	if lastEventID != "" {
+		after, ok := h.parseEventID(lastEventID)
+		sub.Stale = !ok
+		if ok {
+			for _, entry := range h.recent {
+				if entry.seq > after && entry.userID == userID {
+					missed = append(missed, entry.event)
+				}
+			}
+		}
+	}
+
+	sub.events = make(chan LiveEvent, len(missed)+hubBuffer)
+	for _, e := range missed {
+		sub.events <- e
+	}
+	if h.closed {
+		close(sub.events)
+		return sub
+	}
+	h.subs[sub] = struct{}{}
+	return sub
+}
+
+// Close ends every subscription, as on shutdown
+func (h *EventHub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for sub := range h.subs {
+		h.drop(sub)
+	}
+}
+
+// drop ends a subscription; the caller holds h.mu
+func (h *EventHub) drop(sub *Subscription) {
+	if _, ok := h.subs[sub]; ok {
+		delete(h.subs, sub)
+		close(sub.events)
+	}
+}
+
+func (h *EventHub) eventID(seq uint64) string {
+	return h.epoch + "-" + strconv.FormatUint(seq, 10)
+}
+
+// parseEventID returns the sequence number of an event ID if the events
+// after it are all still in the history; the caller holds h.mu
+func (h *EventHub) parseEventID(id string) (uint64, bool) {
+	epoch, n, ok := strings.Cut(id, "-")
+	if !ok || epoch != h.epoch {
+		return 0, false
+	}
+	seq, err := strconv.ParseUint(n, 10, 64)
+	if err != nil || seq > h.seq {
+		return 0, false
+	}
+	if len(h.recent) > 0 && h.recent[0].seq > seq+1 {
+		return 0, false
+	}
+	return seq, true
+}
+
+// Subscription receives one user's events from an EventHub
+type Subscription struct {
+	hub    *EventHub
+	userID string
+	events chan LiveEvent
+	// Stale is set when the events since the given ID could not be
+	// replayed, so the client's view may be out of date
+	Stale bool
+}
+
+// Events delivers the events in order. It is closed when the subscriber
+// fell too far behind or the hub closed; the client should reconnect with
+// the last ID it received.
+func (s *Subscription) Events() <-chan LiveEvent {
+	return s.events
+}
+
+// Close stops the subscription
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.drop(s)
+}
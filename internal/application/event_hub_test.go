@@ -0,0 +1,135 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/guisithos/save-my-read/internal/domain/book"
+)
+
+// received drains the events already queued on sub
+func received(sub *Subscription) []LiveEvent {
+	var events []LiveEvent
+	for {
+		select {
+		case e, ok := <-sub.Events():
+			if !ok {
+				return events
+			}
+			events = append(events, e)
+		default:
+			return events
+		}
+	}
+}
+
+func TestEventHub_DeliversToTheOwner(t *testing.T) {
+	hub := NewEventHub(0)
+	ctx := context.Background()
+	alice, bob := hub.Subscribe("alice", ""), hub.Subscribe("bob", "")
+	defer alice.Close()
+	defer bob.Close()
+
+	hub.Publish(ctx, book.Added{BookID: "b1", UserID: "alice", Title: "Dune"})
+	hub.Publish(ctx, book.Deleted{BookID: "b2", UserID: "bob"})
+
+	got := received(alice)
+	if len(got) != 1 || got[0].Name != book.EventAdded {
+		t.Fatalf("expected alice's event only, got %+v", got)
+	}
+	var added book.Added
+	if err := json.Unmarshal(got[0].Data, &added); err != nil || added.BookID != "b1" || added.Title != "Dune" {
+		t.Errorf("unexpected data %s", got[0].Data)
+	}
+	if got := received(bob); len(got) != 1 || got[0].Name != book.EventDeleted {
+		t.Errorf("expected bob's event only, got %+v", got)
+	}
+}
+
+func TestEventHub_ResumesAfterLastEventID(t *testing.T) {
+	hub := NewEventHub(0)
+	ctx := context.Background()
+	first := hub.Subscribe("alice", "")
+	hub.Publish(ctx, book.Added{BookID: "b1", UserID: "alice"})
+	last := received(first)[0].ID
+	first.Close()
+
+	hub.Publish(ctx, book.StatusChanged{BookID: "b1", UserID: "alice", From: book.StatusToRead, To: book.StatusReading})
+	hub.Publish(ctx, book.Added{BookID: "b9", UserID: "bob"})
+	hub.Publish(ctx, book.Deleted{BookID: "b1", UserID: "alice"})
+
+	sub := hub.Subscribe("alice", last)
+	defer sub.Close()
+	if sub.Stale {
+		t.Fatal("expected the subscription to resume")
+	}
+	got := received(sub)
+	if len(got) != 2 || got[0].Name != book.EventStatusChanged || got[1].Name != book.EventDeleted {
+		t.Fatalf("expected the two missed events in order, got %+v", got)
+	}
+	if got[0].ID == last || got[1].ID == got[0].ID {
+		t.Errorf("expected distinct event IDs, got %s, %s after %s", got[0].ID, got[1].ID, last)
+	}
+
+	hub.Publish(ctx, book.Restored{BookID: "b1", UserID: "alice"})
+	if got := received(sub); len(got) != 1 || got[0].Name != book.EventRestored {
+		t.Errorf("expected live events after the replay, got %+v", got)
+	}
+}
+
+func TestEventHub_StaleWhenHistoryIsGone(t *testing.T) {
+	hub := NewEventHub(2)
+	ctx := context.Background()
+	sub := hub.Subscribe("alice", "")
+	hub.Publish(ctx, book.Added{BookID: "b1", UserID: "alice"})
+	last := received(sub)[0].ID
+	sub.Close()
+
+	for _, id := range []string{"b2", "b3", "b4"} {
+		hub.Publish(ctx, book.Added{BookID: id, UserID: "alice"})
+	}
+
+	for _, id := range []string{last, "other-1", "garbage"} {
+		sub := hub.Subscribe("alice", id)
+		if !sub.Stale || len(received(sub)) != 0 {
+			t.Errorf("expected a stale subscription without replay for %q", id)
+		}
+		sub.Close()
+	}
+	if other := NewEventHub(0).Subscribe("alice", last); !other.Stale {
+		t.Error("expected IDs from another hub to be stale")
+	}
+}
+
+func TestEventHub_DropsSlowSubscribers(t *testing.T) {
+	hub := NewEventHub(0)
+	ctx := context.Background()
+	sub := hub.Subscribe("alice", "")
+
+	for i := 0; i <= hubBuffer; i++ {
+		hub.Publish(ctx, book.Added{BookID: "b", UserID: "alice"})
+	}
+
+	got := received(sub)
+	if len(got) != hubBuffer {
+		t.Errorf("expected the buffered events, got %d", len(got))
+	}
+	if _, ok := <-sub.Events(); ok {
+		t.Error("expected the subscription to be closed")
+	}
+	sub.Close()
+}
+
+func TestEventHub_Close(t *testing.T) {
+	hub := NewEventHub(0)
+	sub := hub.Subscribe("alice", "")
+	hub.Close()
+
+	if _, ok := <-sub.Events(); ok {
+		t.Error("expected Close to end subscriptions")
+	}
+	if _, ok := <-hub.Subscribe("alice", "").Events(); ok {
+		t.Error("expected subscriptions after Close to end at once")
+	}
+}
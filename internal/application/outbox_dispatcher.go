@@ -0,0 +1,197 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/guisithos/save-my-read/internal/domain/event"
+	"github.com/guisithos/save-my-read/internal/logging"
+	"github.com/guisithos/save-my-read/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	defaultOutboxBatchSize   = 100
+	defaultOutboxLease       = time.Minute
+	defaultOutboxMaxAttempts = 10
+	maxOutboxRetryDelay      = time.Hour
+)
+
+// MessageHandler delivers an outbox message. A message may be delivered
+// more than once, so handlers must tolerate repeats.
+type MessageHandler func(ctx context.Context, m *event.Message) error
+
+// OutboxOptions tunes an OutboxDispatcher; zero values take the defaults
+type OutboxOptions struct {
+	// BatchSize is how many messages are claimed at a time (100)
+	BatchSize int
+	// Lease is how long a claimed message is hidden from other dispatchers
+	// while it is delivered (1m)
+	Lease time.Duration
+	// MaxAttempts is how many deliveries are tried before a message is
+	// given up on (10)
+	MaxAttempts int
+}
+
+// OutboxDispatcher delivers the messages in the outbox to the handlers
+// subscribed to them. A failed delivery is retried with exponential
+// backoff; a message whose handler keeps failing is eventually marked dead
+// and left in the outbox for inspection.
+type OutboxDispatcher struct {
+	store  event.OutboxStore
+	opts   OutboxOptions
+	logger *slog.Logger
+
+	mu       sync.RWMutex
+	handlers []messageSubscription
+}
+
+type messageSubscription struct {
+	names   map[string]bool
+	handler MessageHandler
+}
+
+// NewOutboxDispatcher creates a dispatcher over store; a nil logger uses
+// slog.Default()
+func NewOutboxDispatcher(store event.OutboxStore, opts OutboxOptions, logger *slog.Logger) *OutboxDispatcher {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultOutboxBatchSize
+	}
+	if opts.Lease <= 0 {
+		opts.Lease = defaultOutboxLease
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = defaultOutboxMaxAttempts
+	}
+	return &OutboxDispatcher{store: store, opts: opts, logger: logging.OrDefault(logger)}
+}
+
+// Subscribe registers h for the named events, or for every event when no
+// names are given
+func (d *OutboxDispatcher) Subscribe(h MessageHandler, names ...string) {
+	sub := messageSubscription{handler: h}
+	if len(names) > 0 {
+		sub.names = make(map[string]bool, len(names))
+		for _, name := range names {
+			sub.names[name] = true
+		}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.handlers = append(d.handlers, sub)
+}
+
+// Dispatch delivers the messages that are due, batch by batch until none
+// are left, and returns how many were delivered. Messages nobody subscribes
+// to count as delivered.
+func (d *OutboxDispatcher) Dispatch(ctx context.Context) (_ int, err error) {
+	ctx, span := tracer.Start(ctx, "OutboxDispatcher.Dispatch")
+	defer func() { tracing.End(span, err) }()
+
+	var delivered int
+	for ctx.Err() == nil {
+		msgs, err := d.store.Claim(ctx, time.Now(), d.opts.Lease, d.opts.BatchSize)
+		if err != nil {
+			return delivered, err
+		}
+		for _, m := range msgs {
+			ok, err := d.deliver(ctx, m)
+			if err != nil {
+				return delivered, err
+			}
+			if ok {
+				delivered++
+			}
+		}
+		if len(msgs) < d.opts.BatchSize {
+			break
+		}
+	}
+	span.SetAttributes(attribute.Int("outbox.delivered", delivered))
+	return delivered, nil
+}
+
+// deliver hands m to its handlers and records the outcome. It reports
+// whether every handler succeeded; the error is for failing to record it.
+func (d *OutboxDispatcher) deliver(ctx context.Context, m *event.Message) (bool, error) {
+	ctx, span := tracer.Start(ctx, "OutboxDispatcher.deliver", trace.WithAttributes(
+		attribute.String("event.name", m.Name),
+		attribute.String("event.id", m.ID),
+		attribute.Int("outbox.attempt", m.Attempts),
+	))
+	deliveryErr := d.handle(ctx, m)
+	defer func() { tracing.End(span, deliveryErr) }()
+
+	if deliveryErr == nil {
+		if err := d.store.MarkDispatched(ctx, m.ID, time.Now()); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+	if m.Attempts >= d.opts.MaxAttempts {
+		d.logger.ErrorContext(ctx, "giving up on outbox message",
+			"event", m.Name, "id", m.ID, "attempts", m.Attempts, "error", deliveryErr)
+		return false, d.store.Bury(ctx, m.ID, deliveryErr.Error())
+	}
+
+	delay := retryDelay(m.Attempts)
+	d.logger.WarnContext(ctx, "outbox delivery failed",
+		"event", m.Name, "id", m.ID, "attempts", m.Attempts, "retry_in", delay, "error", deliveryErr)
+	return false, d.store.Retry(ctx, m.ID, deliveryErr.Error(), time.Now().Add(delay))
+}
+
+// handle calls every handler subscribed to m, stopping at the first failure
+func (d *OutboxDispatcher) handle(ctx context.Context, m *event.Message) (err error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	// A panicking handler fails the delivery rather than the dispatcher
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("handler panicked: %v", p)
+		}
+	}()
+
+	for _, sub := range d.handlers {
+		if sub.names != nil && !sub.names[m.Name] {
+			continue
+		}
+		if err := sub.handler(ctx, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Cleanup deletes messages dispatched more than retention ago and returns
+// how many were deleted
+func (d *OutboxDispatcher) Cleanup(ctx context.Context, retention time.Duration) (_ int64, err error) {
+	ctx, span := tracer.Start(ctx, "OutboxDispatcher.Cleanup")
+	defer func() { tracing.End(span, err) }()
+
+	n, err := d.store.DeleteDispatched(ctx, time.Now().Add(-retention))
+	if err != nil {
+		return 0, err
+	}
+	if n > 0 {
+		d.logger.InfoContext(ctx, "deleted dispatched outbox messages", "messages", n)
+	}
+	return n, nil
+}
+
+// retryDelay doubles the wait after each failed attempt, from a second up
+// to an hour
+func retryDelay(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+	if attempts > 12 {
+		return maxOutboxRetryDelay
+	}
+	return min(time.Second<<(attempts-1), maxOutboxRetryDelay)
+}
@@ -0,0 +1,179 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guisithos/save-my-read/internal/domain/event"
+	"github.com/guisithos/save-my-read/internal/logging"
+)
+
+// mockOutboxStore claims messages in the order they were added
+type mockOutboxStore struct {
+	msgs   []*event.Message
+	claims int
+}
+
+func (m *mockOutboxStore) Add(_ context.Context, msgs ...*event.Message) error {
+	m.msgs = append(m.msgs, msgs...)
+	return nil
+}
+
+func (m *mockOutboxStore) Claim(_ context.Context, now time.Time, lease time.Duration, limit int) ([]*event.Message, error) {
+	m.claims++
+	var claimed []*event.Message
+	for _, msg := range m.msgs {
+		if len(claimed) == limit {
+			break
+		}
+		if msg.Status == event.StatusPending && !msg.NextAttemptAt.After(now) {
+			msg.Attempts++
+			msg.NextAttemptAt = now.Add(lease)
+			c := *msg
+			claimed = append(claimed, &c)
+		}
+	}
+	return claimed, nil
+}
+
+func (m *mockOutboxStore) MarkDispatched(_ context.Context, id string, at time.Time) error {
+	msg := m.find(id)
+	msg.Status = event.StatusDispatched
+	msg.DispatchedAt = &at
+	return nil
+}
+
+func (m *mockOutboxStore) Retry(_ context.Context, id, lastError string, retryAt time.Time) error {
+	msg := m.find(id)
+	msg.LastError = lastError
+	msg.NextAttemptAt = retryAt
+	return nil
+}
+
+func (m *mockOutboxStore) Bury(_ context.Context, id, lastError string) error {
+	msg := m.find(id)
+	msg.Status = event.StatusDead
+	msg.LastError = lastError
+	return nil
+}
+
+func (m *mockOutboxStore) DeleteDispatched(context.Context, time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (m *mockOutboxStore) find(id string) *event.Message {
+	for _, msg := range m.msgs {
+		if msg.ID == id {
+			return msg
+		}
+	}
+	return nil
+}
+
+func newTestMessage(name string) *event.Message {
+	return &event.Message{
+		ID:            uuid.NewString(),
+		Name:          name,
+		Payload:       []byte(`{}`),
+		Status:        event.StatusPending,
+		NextAttemptAt: time.Now().Add(-time.Second),
+	}
+}
+
+func TestOutboxDispatcher_Dispatch(t *testing.T) {
+	store := &mockOutboxStore{}
+	for i := 0; i < 5; i++ {
+		store.Add(context.Background(), newTestMessage("book.added"))
+	}
+	store.Add(context.Background(), newTestMessage("book.deleted"))
+
+	d := NewOutboxDispatcher(store, OutboxOptions{BatchSize: 2}, logging.Discard())
+	var added int
+	d.Subscribe(func(_ context.Context, m *event.Message) error {
+		added++
+		return nil
+	}, "book.added")
+
+	n, err := d.Dispatch(context.Background())
+	if err != nil {
+		t.Fatalf("Dispatch() error = %v", err)
+	}
+	if n != 6 || added != 5 {
+		t.Errorf("expected 6 messages delivered and 5 handled, got %d and %d", n, added)
+	}
+	if store.claims != 4 {
+		t.Errorf("expected batches to be claimed until one came back short, got %d claims", store.claims)
+	}
+	for _, m := range store.msgs {
+		if m.Status != event.StatusDispatched {
+			t.Errorf("expected %s to be dispatched, got %s", m.ID, m.Status)
+		}
+	}
+}
+
+func TestOutboxDispatcher_RetriesThenBuries(t *testing.T) {
+	store := &mockOutboxStore{}
+	m := newTestMessage("book.added")
+	store.Add(context.Background(), m)
+
+	d := NewOutboxDispatcher(store, OutboxOptions{MaxAttempts: 2}, logging.Discard())
+	d.Subscribe(func(context.Context, *event.Message) error {
+		return errors.New("webhook down")
+	})
+
+	before := time.Now()
+	if n, err := d.Dispatch(context.Background()); err != nil || n != 0 {
+		t.Fatalf("Dispatch() = %d, %v; want 0, nil", n, err)
+	}
+	if m.Status != event.StatusPending || m.LastError != "webhook down" || m.NextAttemptAt.Before(before.Add(time.Second)) {
+		t.Fatalf("expected a retry in a second, got %+v", m)
+	}
+
+	m.NextAttemptAt = time.Now()
+	if _, err := d.Dispatch(context.Background()); err != nil {
+		t.Fatalf("Dispatch() error = %v", err)
+	}
+	if m.Status != event.StatusDead || m.Attempts != 2 {
+		t.Errorf("expected the message buried after 2 attempts, got %+v", m)
+	}
+}
+
+func TestOutboxDispatcher_HandlerPanic(t *testing.T) {
+	store := &mockOutboxStore{}
+	m := newTestMessage("book.added")
+	store.Add(context.Background(), m)
+
+	d := NewOutboxDispatcher(store, OutboxOptions{}, logging.Discard())
+	d.Subscribe(func(context.Context, *event.Message) error {
+		panic("boom")
+	})
+
+	if _, err := d.Dispatch(context.Background()); err != nil {
+		t.Fatalf("Dispatch() error = %v", err)
+	}
+	if m.Status != event.StatusPending || m.LastError != "handler panicked: boom" {
+		t.Errorf("expected the panic to fail the delivery, got %+v", m)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{5, 16 * time.Second},
+		{12, 2048 * time.Second},
+		{13, time.Hour},
+		{100, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := retryDelay(tt.attempts); got != tt.want {
+			t.Errorf("retryDelay(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
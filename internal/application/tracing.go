@@ -0,0 +1,5 @@
+package application
+
+import "github.com/guisithos/save-my-read/internal/tracing"
+
+var tracer = tracing.Tracer("github.com/guisithos/save-my-read/internal/application")
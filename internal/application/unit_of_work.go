@@ -0,0 +1,25 @@
+package application
+
+import (
+	"context"
+
+	"github.com/guisithos/save-my-read/internal/domain/book"
+	"github.com/guisithos/save-my-read/internal/domain/event"
+	"github.com/guisithos/save-my-read/internal/domain/user"
+)
+
+// Repositories are the repositories bound to one unit of work
+type Repositories struct {
+	Books book.Repository
+	Users user.Repository
+	// Outbox takes the events raised by the changes, delivered once they commit
+	Outbox event.Outbox
+}
+
+// UnitOfWork runs a function atomically: everything it does through the
+// repositories it is handed is committed together or not at all. The
+// function may be run again when the store asks for a retry, such as after
+// a serialization failure, so it must not have effects outside repos.
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(ctx context.Context, repos Repositories) error) error
+}
@@ -0,0 +1,333 @@
+package application
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guisithos/save-my-read/internal/domain/audit"
+	"github.com/guisithos/save-my-read/internal/domain/event"
+	"github.com/guisithos/save-my-read/internal/domain/webhook"
+	"github.com/guisithos/save-my-read/internal/logging"
+	"github.com/guisithos/save-my-read/internal/netguard"
+	"github.com/guisithos/save-my-read/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	defaultWebhookTimeout = 10 * time.Second
+	webhookUserAgent      = "save-my-read-webhooks"
+	// maxWebhookResponseBytes bounds how much of a response is read before
+	// the connection is given up for reuse
+	maxWebhookResponseBytes = 64 << 10
+
+	defaultDeliveryLimit = 20
+	maxDeliveryLimit     = 100
+)
+
+// WebhookUpdate holds the fields of a webhook to change; nil fields are
+// left as they are
+type WebhookUpdate struct {
+	URL    *string
+	Events []string
+	Active *bool
+}
+
+// WebhookService manages users' webhooks and delivers events to them.
+// Deliver is meant to be subscribed to an OutboxDispatcher, which retries
+// failed deliveries with backoff.
+type WebhookService struct {
+	repo   webhook.Repository
+	client *http.Client
+	audit  audit.Recorder
+	logger *slog.Logger
+}
+
+// NewWebhookService creates a WebhookService sending requests with client.
+// A nil client uses one with a 10s timeout that only connects to public
+// addresses and does not follow redirects, a nil recorder discards audit
+// events and a nil logger uses slog.Default().
+func NewWebhookService(repo webhook.Repository, client *http.Client, recorder audit.Recorder, logger *slog.Logger) *WebhookService {
+	if client == nil {
+		client = &http.Client{
+			Transport: netguard.Transport(),
+			Timeout:   defaultWebhookTimeout,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+	}
+	if recorder == nil {
+		recorder = audit.Discard
+	}
+	return &WebhookService{repo: repo, client: client, audit: recorder, logger: logging.OrDefault(logger)}
+}
+
+// Create registers a webhook for the user. The returned webhook carries
+// its secret, which is not shown again.
+func (s *WebhookService) Create(ctx context.Context, userID, url string, events []string) (_ *webhook.Webhook, err error) {
+	ctx, span := s.startSpan(ctx, "WebhookService.Create", userID)
+	defer func() { tracing.End(span, err) }()
+
+	existing, err := s.repo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) >= webhook.MaxPerUser {
+		return nil, webhook.ErrLimit
+	}
+
+	w, err := webhook.New(userID, url, normalizeEvents(events))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Save(ctx, w); err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, webhookEvent(audit.ActionWebhookCreate, userID, w.ID, nil, w))
+	return w, nil
+}
+
+// List returns the user's webhooks, oldest first
+func (s *WebhookService) List(ctx context.Context, userID string) (_ []*webhook.Webhook, err error) {
+	ctx, span := s.startSpan(ctx, "WebhookService.List", userID)
+	defer func() { tracing.End(span, err) }()
+
+	return s.repo.FindByUserID(ctx, userID)
+}
+
+// Get returns one of the user's webhooks
+func (s *WebhookService) Get(ctx context.Context, userID, id string) (_ *webhook.Webhook, err error) {
+	ctx, span := s.startSpan(ctx, "WebhookService.Get", userID)
+	defer func() { tracing.End(span, err) }()
+
+	return s.repo.FindByIDAndUserID(ctx, id, userID)
+}
+
+// Update changes the URL, events or active flag of one of the user's
+// webhooks
+func (s *WebhookService) Update(ctx context.Context, userID, id string, u WebhookUpdate) (_ *webhook.Webhook, err error) {
+	ctx, span := s.startSpan(ctx, "WebhookService.Update", userID)
+	defer func() { tracing.End(span, err) }()
+
+	w, err := s.repo.FindByIDAndUserID(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	before := *w
+	if u.URL != nil {
+		w.URL = *u.URL
+	}
+	if u.Events != nil {
+		w.Events = normalizeEvents(u.Events)
+	}
+	if u.Active != nil {
+		w.Active = *u.Active
+	}
+	w.UpdatedAt = time.Now()
+	if err := s.repo.Update(ctx, w); err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, webhookEvent(audit.ActionWebhookUpdate, userID, id, &before, w))
+	return w, nil
+}
+
+// Delete removes one of the user's webhooks along with its delivery log
+func (s *WebhookService) Delete(ctx context.Context, userID, id string) (err error) {
+	ctx, span := s.startSpan(ctx, "WebhookService.Delete", userID)
+	defer func() { tracing.End(span, err) }()
+
+	w, err := s.repo.FindByIDAndUserID(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, id, userID); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, webhookEvent(audit.ActionWebhookDelete, userID, id, w, nil))
+	return nil
+}
+
+// Deliveries returns the latest delivery attempts of one of the user's
+// webhooks, newest first. The limit defaults to 20 and is capped at 100.
+func (s *WebhookService) Deliveries(ctx context.Context, userID, id string, limit int) (_ []*webhook.Delivery, err error) {
+	ctx, span := s.startSpan(ctx, "WebhookService.Deliveries", userID)
+	defer func() { tracing.End(span, err) }()
+
+	if _, err := s.repo.FindByIDAndUserID(ctx, id, userID); err != nil {
+		return nil, err
+	}
+	switch {
+	case limit <= 0:
+		limit = defaultDeliveryLimit
+	case limit > maxDeliveryLimit:
+		limit = maxDeliveryLimit
+	}
+	return s.repo.FindDeliveries(ctx, id, limit)
+}
+
+// SendTest delivers a webhook.test event to one of the user's webhooks
+// right away, whether or not it is active, and returns the attempt. A
+// receiver failing is reported in the delivery, not as an error.
+func (s *WebhookService) SendTest(ctx context.Context, userID, id string) (_ *webhook.Delivery, err error) {
+	ctx, span := s.startSpan(ctx, "WebhookService.SendTest", userID)
+	defer func() { tracing.End(span, err) }()
+
+	w, err := s.repo.FindByIDAndUserID(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	p := webhook.Payload{
+		ID:         uuid.NewString(),
+		Event:      webhook.EventTest,
+		OccurredAt: time.Now(),
+		Data:       map[string]string{"webhook_id": w.ID},
+	}
+	return s.send(ctx, w, p, 1)
+}
+
+// Deliver sends an outbox message to the webhooks of the user it concerns
+// that subscribe to it. Webhooks that already received the event are
+// skipped, so a retry only goes to those that failed; the error joins
+// their failures.
+func (s *WebhookService) Deliver(ctx context.Context, m *event.Message) error {
+	var owner struct {
+		UserID string `json:"user_id"`
+	}
+	if err := json.Unmarshal(m.Payload, &owner); err != nil {
+		return fmt.Errorf("error decoding %s event: %w", m.Name, err)
+	}
+	if owner.UserID == "" {
+		return nil
+	}
+
+	webhooks, err := s.repo.FindByUserID(ctx, owner.UserID)
+	if err != nil {
+		return err
+	}
+	p := webhook.Payload{
+		ID:         m.ID,
+		Event:      m.Name,
+		OccurredAt: m.OccurredAt,
+		Data:       json.RawMessage(m.Payload),
+	}
+	var errs []error
+	for _, w := range webhooks {
+		if !w.Subscribed(m.Name) {
+			continue
+		}
+		delivered, err := s.repo.Delivered(ctx, w.ID, m.ID)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if delivered {
+			continue
+		}
+		d, err := s.send(ctx, w, p, m.Attempts)
+		switch {
+		case err != nil:
+			errs = append(errs, err)
+		case !d.Success:
+			errs = append(errs, fmt.Errorf("webhook %s: %s", w.ID, d.Error))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// PruneDeliveries removes delivery attempts older than retention
+func (s *WebhookService) PruneDeliveries(ctx context.Context, retention time.Duration) (_ int64, err error) {
+	ctx, span := tracer.Start(ctx, "WebhookService.PruneDeliveries")
+	defer func() { tracing.End(span, err) }()
+
+	return s.repo.DeleteDeliveriesBefore(ctx, time.Now().Add(-retention))
+}
+
+// send POSTs the signed payload to the webhook and logs the attempt. The
+// error is only set when the attempt could not be made or logged.
+func (s *WebhookService) send(ctx context.Context, w *webhook.Webhook, p webhook.Payload, attempt int) (*webhook.Delivery, error) {
+	ctx, span := tracer.Start(ctx, "WebhookService.send", trace.WithAttributes(
+		attribute.String("webhook.id", w.ID),
+		attribute.String("webhook.event", p.Event),
+	))
+	defer span.End()
+
+	body, err := json.Marshal(p)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding webhook payload: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("error creating webhook request: %w", err)
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", webhookUserAgent)
+	req.Header.Set(webhook.HeaderID, p.ID)
+	req.Header.Set(webhook.HeaderEvent, p.Event)
+	req.Header.Set(webhook.HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(webhook.HeaderSignature, webhook.Sign(w.Secret, timestamp, body))
+
+	d := &webhook.Delivery{
+		ID:        uuid.NewString(),
+		WebhookID: w.ID,
+		EventID:   p.ID,
+		Event:     p.Event,
+		Attempt:   attempt,
+		CreatedAt: time.Now(),
+	}
+	start := time.Now()
+	resp, err := s.client.Do(req)
+	if err == nil {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, maxWebhookResponseBytes))
+		resp.Body.Close()
+		d.StatusCode = resp.StatusCode
+		d.Success = resp.StatusCode >= 200 && resp.StatusCode < 300
+		if !d.Success {
+			d.Error = "receiver responded " + resp.Status
+		}
+	} else {
+		d.Error = err.Error()
+	}
+	d.DurationMS = time.Since(start).Milliseconds()
+	span.SetAttributes(attribute.Int("http.response.status_code", d.StatusCode))
+
+	// The delivery is logged even if the caller has gone away
+	if err := s.repo.SaveDelivery(context.WithoutCancel(ctx), d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (s *WebhookService) startSpan(ctx context.Context, name, userID string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithAttributes(attribute.String("user.id", userID)))
+}
+
+// normalizeEvents sorts the event names and drops repeats
+func normalizeEvents(events []string) []string {
+	events = slices.Clone(events)
+	slices.Sort(events)
+	return slices.Compact(events)
+}
+
+// webhookEvent describes a change a user made to one of their webhooks
+func webhookEvent(action audit.Action, userID, id string, before, after *webhook.Webhook) *audit.Event {
+	return &audit.Event{
+		Action:     action,
+		UserID:     userID,
+		ActorID:    userID,
+		TargetType: audit.TargetWebhook,
+		TargetID:   id,
+		Changes:    audit.Diff(before, after),
+	}
+}
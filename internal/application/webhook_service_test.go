@@ -0,0 +1,303 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/guisithos/save-my-read/internal/domain/book"
+	"github.com/guisithos/save-my-read/internal/domain/event"
+	"github.com/guisithos/save-my-read/internal/domain/webhook"
+	"github.com/guisithos/save-my-read/internal/logging"
+)
+
+type mockWebhookRepo struct {
+	webhooks   []*webhook.Webhook
+	deliveries []*webhook.Delivery
+}
+
+func (m *mockWebhookRepo) Save(_ context.Context, w *webhook.Webhook) error {
+	m.webhooks = append(m.webhooks, w)
+	return nil
+}
+
+func (m *mockWebhookRepo) FindByIDAndUserID(_ context.Context, id, userID string) (*webhook.Webhook, error) {
+	for _, w := range m.webhooks {
+		if w.ID == id && w.UserID == userID {
+			c := *w
+			return &c, nil
+		}
+	}
+	return nil, webhook.ErrNotFound
+}
+
+func (m *mockWebhookRepo) FindByUserID(_ context.Context, userID string) ([]*webhook.Webhook, error) {
+	var found []*webhook.Webhook
+	for _, w := range m.webhooks {
+		if w.UserID == userID {
+			found = append(found, w)
+		}
+	}
+	return found, nil
+}
+
+func (m *mockWebhookRepo) Update(_ context.Context, w *webhook.Webhook) error {
+	for i, existing := range m.webhooks {
+		if existing.ID == w.ID && existing.UserID == w.UserID {
+			m.webhooks[i] = w
+			return nil
+		}
+	}
+	return webhook.ErrNotFound
+}
+
+func (m *mockWebhookRepo) Delete(_ context.Context, id, userID string) error {
+	for i, w := range m.webhooks {
+		if w.ID == id && w.UserID == userID {
+			m.webhooks = append(m.webhooks[:i], m.webhooks[i+1:]...)
+			return nil
+		}
+	}
+	return webhook.ErrNotFound
+}
+
+func (m *mockWebhookRepo) SaveDelivery(_ context.Context, d *webhook.Delivery) error {
+	m.deliveries = append(m.deliveries, d)
+	return nil
+}
+
+func (m *mockWebhookRepo) FindDeliveries(_ context.Context, webhookID string, limit int) ([]*webhook.Delivery, error) {
+	var found []*webhook.Delivery
+	for i := len(m.deliveries) - 1; i >= 0 && len(found) < limit; i-- {
+		if m.deliveries[i].WebhookID == webhookID {
+			found = append(found, m.deliveries[i])
+		}
+	}
+	return found, nil
+}
+
+func (m *mockWebhookRepo) Delivered(_ context.Context, webhookID, eventID string) (bool, error) {
+	for _, d := range m.deliveries {
+		if d.WebhookID == webhookID && d.EventID == eventID && d.Success {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *mockWebhookRepo) DeleteDeliveriesBefore(context.Context, time.Time) (int64, error) {
+	return 0, nil
+}
+
+// receiver records the requests it gets and answers with the next status
+type receiver struct {
+	mu       sync.Mutex
+	requests []*http.Request
+	bodies   [][]byte
+	statuses []int
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.requests = append(rc.requests, r)
+	rc.bodies = append(rc.bodies, body)
+	status := http.StatusNoContent
+	if len(rc.statuses) > 0 {
+		status, rc.statuses = rc.statuses[0], rc.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func newWebhookService(t *testing.T, rc *receiver) (*WebhookService, *mockWebhookRepo, string) {
+	t.Helper()
+	srv := httptest.NewServer(rc)
+	t.Cleanup(srv.Close)
+	repo := &mockWebhookRepo{}
+	return NewWebhookService(repo, srv.Client(), nil, logging.Discard()), repo, srv.URL
+}
+
+func TestWebhookService_DeliverSignsPayload(t *testing.T) {
+	rc := &receiver{}
+	svc, repo, url := newWebhookService(t, rc)
+	ctx := context.Background()
+
+	w, err := svc.Create(ctx, "user-1", url, []string{book.EventAdded})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if _, err := svc.Create(ctx, "user-2", url, []string{book.EventAdded}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	m, err := event.NewMessage(book.Added{BookID: "book-1", UserID: "user-1", Title: "Dune", Status: book.StatusToRead})
+	if err != nil {
+		t.Fatalf("NewMessage() error = %v", err)
+	}
+	m.Attempts = 1
+	if err := svc.Deliver(ctx, m); err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+
+	if len(rc.requests) != 1 {
+		t.Fatalf("expected only the owner's webhook to be called, got %d requests", len(rc.requests))
+	}
+	r, body := rc.requests[0], rc.bodies[0]
+	if r.Header.Get(webhook.HeaderID) != m.ID || r.Header.Get(webhook.HeaderEvent) != book.EventAdded {
+		t.Errorf("unexpected headers %v", r.Header)
+	}
+	ts, err := strconv.ParseInt(r.Header.Get(webhook.HeaderTimestamp), 10, 64)
+	if err != nil {
+		t.Fatalf("invalid timestamp header: %v", err)
+	}
+	if !webhook.Verify(w.Secret, ts, body, r.Header.Get(webhook.HeaderSignature)) {
+		t.Error("expected the signature to verify with the webhook's secret")
+	}
+	if webhook.Verify("whsec_other", ts, body, r.Header.Get(webhook.HeaderSignature)) {
+		t.Error("expected the signature to fail with another secret")
+	}
+
+	var p struct {
+		ID    string     `json:"id"`
+		Event string     `json:"event"`
+		Data  book.Added `json:"data"`
+	}
+	if err := json.Unmarshal(body, &p); err != nil {
+		t.Fatalf("invalid payload: %v", err)
+	}
+	if p.ID != m.ID || p.Event != book.EventAdded || p.Data.BookID != "book-1" || p.Data.Title != "Dune" {
+		t.Errorf("unexpected payload %s", body)
+	}
+
+	if len(repo.deliveries) != 1 || !repo.deliveries[0].Success || repo.deliveries[0].StatusCode != http.StatusNoContent {
+		t.Errorf("expected a successful delivery logged, got %+v", repo.deliveries)
+	}
+}
+
+func TestWebhookService_DeliverRetriesOnlyFailures(t *testing.T) {
+	rc := &receiver{}
+	svc, repo, url := newWebhookService(t, rc)
+	ctx := context.Background()
+
+	ok, _ := svc.Create(ctx, "user-1", url+"/ok", []string{book.EventStatusChanged})
+	failing, _ := svc.Create(ctx, "user-1", url+"/failing", []string{book.EventStatusChanged})
+	svc.Create(ctx, "user-1", url+"/other", []string{book.EventAdded})
+	inactive, _ := svc.Create(ctx, "user-1", url+"/inactive", []string{book.EventStatusChanged})
+	off := false
+	if _, err := svc.Update(ctx, "user-1", inactive.ID, WebhookUpdate{Active: &off}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	m, _ := event.NewMessage(book.StatusChanged{BookID: "book-1", UserID: "user-1", From: book.StatusToRead, To: book.StatusReading})
+	m.Attempts = 1
+	rc.statuses = []int{http.StatusOK, http.StatusInternalServerError}
+	if err := svc.Deliver(ctx, m); err == nil {
+		t.Fatal("expected the failed delivery to be reported for a retry")
+	}
+
+	m.Attempts = 2
+	if err := svc.Deliver(ctx, m); err != nil {
+		t.Fatalf("Deliver() retry error = %v", err)
+	}
+
+	var paths []string
+	for _, r := range rc.requests {
+		paths = append(paths, r.URL.Path)
+	}
+	if len(paths) != 3 || paths[0] != "/ok" || paths[1] != "/failing" || paths[2] != "/failing" {
+		t.Errorf("expected the retry to go to the failing webhook only, got %v", paths)
+	}
+
+	log, _ := svc.Deliveries(ctx, "user-1", failing.ID, 0)
+	if len(log) != 2 || !log[0].Success || log[0].Attempt != 2 ||
+		log[1].Success || log[1].StatusCode != http.StatusInternalServerError || log[1].Error == "" {
+		t.Errorf("unexpected delivery log %+v", log)
+	}
+	if log, _ := svc.Deliveries(ctx, "user-1", ok.ID, 0); len(log) != 1 {
+		t.Errorf("expected one delivery to the working webhook, got %d", len(log))
+	}
+	if len(repo.deliveries) != 3 {
+		t.Errorf("expected 3 deliveries logged, got %d", len(repo.deliveries))
+	}
+}
+
+func TestWebhookService_SendTest(t *testing.T) {
+	rc := &receiver{statuses: []int{http.StatusBadRequest}}
+	svc, _, url := newWebhookService(t, rc)
+	ctx := context.Background()
+
+	w, _ := svc.Create(ctx, "user-1", url, []string{book.EventAdded})
+	d, err := svc.SendTest(ctx, "user-1", w.ID)
+	if err != nil {
+		t.Fatalf("SendTest() error = %v", err)
+	}
+	if d.Event != webhook.EventTest || d.Success || d.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected the receiver's rejection in the delivery, got %+v", d)
+	}
+	if len(rc.requests) != 1 || rc.requests[0].Header.Get(webhook.HeaderEvent) != webhook.EventTest {
+		t.Errorf("expected a test event to be sent, got %d requests", len(rc.requests))
+	}
+
+	if _, err := svc.SendTest(ctx, "user-2", w.ID); !errors.Is(err, webhook.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for another user's webhook, got %v", err)
+	}
+}
+
+func TestWebhookService_DoesNotFollowRedirects(t *testing.T) {
+	target := &receiver{}
+	targetSrv := httptest.NewServer(target)
+	defer targetSrv.Close()
+	redirect := httptest.NewServer(http.RedirectHandler(targetSrv.URL, http.StatusTemporaryRedirect))
+	defer redirect.Close()
+
+	svc := NewWebhookService(&mockWebhookRepo{}, nil, nil, logging.Discard())
+	// The default client refuses the loopback test servers
+	svc.client.Transport = http.DefaultTransport
+	ctx := context.Background()
+	w, _ := svc.Create(ctx, "user-1", redirect.URL, []string{book.EventAdded})
+	d, err := svc.SendTest(ctx, "user-1", w.ID)
+	if err != nil {
+		t.Fatalf("SendTest() error = %v", err)
+	}
+	if d.Success || d.StatusCode != http.StatusTemporaryRedirect || len(target.requests) != 0 {
+		t.Errorf("expected the redirect not to be followed, got %+v", d)
+	}
+}
+
+func TestWebhookService_DefaultClientRefusesPrivateAddresses(t *testing.T) {
+	rc := &receiver{}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	svc := NewWebhookService(&mockWebhookRepo{}, nil, nil, logging.Discard())
+	ctx := context.Background()
+	w, _ := svc.Create(ctx, "user-1", srv.URL, []string{book.EventAdded})
+	d, err := svc.SendTest(ctx, "user-1", w.ID)
+	if err != nil {
+		t.Fatalf("SendTest() error = %v", err)
+	}
+	if d.Success || d.Error == "" || len(rc.requests) != 0 {
+		t.Errorf("expected the loopback receiver to be refused, got %+v", d)
+	}
+}
+
+func TestWebhookService_CreateLimit(t *testing.T) {
+	svc, _, url := newWebhookService(t, &receiver{})
+	ctx := context.Background()
+	for i := 0; i < webhook.MaxPerUser; i++ {
+		if _, err := svc.Create(ctx, "user-1", url, []string{book.EventAdded}); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+	if _, err := svc.Create(ctx, "user-1", url, []string{book.EventAdded}); !errors.Is(err, webhook.ErrLimit) {
+		t.Errorf("expected ErrLimit, got %v", err)
+	}
+}
@@ -0,0 +1,616 @@
+// Package config loads the runtime configuration shared by the binaries.
+//
+// Values are resolved in the following order, later sources overriding
+// earlier ones: built-in defaults, an optional JSON config file, a .env file
+// in the working directory and finally the process environment.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/netip"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/guisithos/save-my-read/internal/logging"
+	"github.com/joho/godotenv"
+)
+
+// Config holds every setting needed to run the API server and the CLI
+type Config struct {
+	DatabaseURL  string            `json:"database_url"`
+	QueryTimeout Duration          `json:"query_timeout"`
+	AutoMigrate  bool              `json:"auto_migrate"`
+	Server       ServerConfig      `json:"server"`
+	JWT          JWTConfig         `json:"jwt"`
+	GoogleBooks  GoogleBooksConfig `json:"google_books"`
+	Covers       CoversConfig      `json:"covers"`
+	Trash        TrashConfig       `json:"trash"`
+	Idempotency  IdempotencyConfig `json:"idempotency"`
+	Outbox       OutboxConfig      `json:"outbox"`
+	Webhooks     WebhooksConfig    `json:"webhooks"`
+	CORS         CORSConfig        `json:"cors"`
+	Log          LogConfig         `json:"log"`
+	Metrics      MetricsConfig     `json:"metrics"`
+	Tracing      TracingConfig     `json:"tracing"`
+	// Demo runs the server on an in-memory store with sample data, so no
+	// database or Google Books key is needed
+	Demo bool `json:"demo"`
+}
+
+// LogValue describes the configuration for logs with every secret redacted
+func (c *Config) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("database_url", logging.RedactURL(c.DatabaseURL)),
+		slog.Duration("query_timeout", time.Duration(c.QueryTimeout)),
+		slog.Bool("auto_migrate", c.AutoMigrate),
+		slog.String("listen_addr", c.Server.ListenAddr()),
+		slog.Bool("tls", c.Server.TLSCertFile != ""),
+		slog.Any("trusted_proxies", c.Server.TrustedProxies),
+		slog.Duration("jwt_duration", time.Duration(c.JWT.Duration)),
+		slog.Bool("jwt_secret_set", c.JWT.Secret != ""),
+		slog.String("google_books_base_url", c.GoogleBooks.BaseURL),
+		slog.Bool("google_books_api_key_set", c.GoogleBooks.APIKey != ""),
+		slog.String("covers_dir", c.Covers.Dir),
+		slog.Duration("trash_retention", time.Duration(c.Trash.Retention)),
+		slog.Duration("trash_purge_interval", time.Duration(c.Trash.PurgeInterval)),
+		slog.Duration("idempotency_ttl", time.Duration(c.Idempotency.TTL)),
+		slog.Duration("idempotency_cleanup_interval", time.Duration(c.Idempotency.CleanupInterval)),
+		slog.Duration("outbox_poll_interval", time.Duration(c.Outbox.PollInterval)),
+		slog.Int("outbox_max_attempts", c.Outbox.MaxAttempts),
+		slog.Duration("outbox_retention", time.Duration(c.Outbox.Retention)),
+		slog.Duration("outbox_cleanup_interval", time.Duration(c.Outbox.CleanupInterval)),
+		slog.Duration("webhook_timeout", time.Duration(c.Webhooks.Timeout)),
+		slog.Bool("webhook_allow_private_addresses", c.Webhooks.AllowPrivateAddresses),
+		slog.Duration("webhook_delivery_retention", time.Duration(c.Webhooks.DeliveryRetention)),
+		slog.Any("cors_allowed_origins", c.CORS.AllowedOrigins),
+		slog.String("log_level", c.Log.Level),
+		slog.String("log_format", c.Log.Format),
+		slog.Bool("metrics_enabled", c.Metrics.Enabled),
+		slog.String("admin_addr", c.Metrics.AdminAddr),
+		slog.String("trace_exporter", c.Tracing.Exporter),
+		slog.String("trace_endpoint", logging.RedactURL(c.Tracing.Endpoint)),
+		slog.Bool("demo", c.Demo),
+	)
+}
+
+// ServerConfig holds the HTTP server settings
+type ServerConfig struct {
+	// Addr is the listen address, either host:port or unix:/path/to.sock.
+	// When empty the server listens on Port on all interfaces.
+	Addr              string   `json:"addr"`
+	Port              string   `json:"port"`
+	ReadTimeout       Duration `json:"read_timeout"`
+	ReadHeaderTimeout Duration `json:"read_header_timeout"`
+	WriteTimeout      Duration `json:"write_timeout"`
+	IdleTimeout       Duration `json:"idle_timeout"`
+	ShutdownTimeout   Duration `json:"shutdown_timeout"`
+	// TLSCertFile and TLSKeyFile enable HTTPS; both files are reloaded when they change
+	TLSCertFile string `json:"tls_cert_file"`
+	TLSKeyFile  string `json:"tls_key_file"`
+	// TrustedProxies lists the addresses or CIDR ranges of the proxies in
+	// front of the server, whose X-Forwarded-For and Forwarded headers give
+	// the client address recorded in the audit log
+	TrustedProxies []string `json:"trusted_proxies"`
+}
+
+// ListenAddr returns the address the server should listen on
+func (c ServerConfig) ListenAddr() string {
+	if c.Addr != "" {
+		return c.Addr
+	}
+	return ":" + c.Port
+}
+
+// TrustedProxyPrefixes parses TrustedProxies; a single address becomes a
+// prefix that holds only it. Entries that do not parse are skipped, as
+// Validate reports them.
+func (c ServerConfig) TrustedProxyPrefixes() []netip.Prefix {
+	var prefixes []netip.Prefix
+	for _, entry := range c.TrustedProxies {
+		if p, ok := parseProxy(entry); ok {
+			prefixes = append(prefixes, p)
+		}
+	}
+	return prefixes
+}
+
+func parseProxy(entry string) (netip.Prefix, bool) {
+	if addr, err := netip.ParseAddr(entry); err == nil {
+		addr = addr.Unmap()
+		return netip.PrefixFrom(addr, addr.BitLen()), true
+	}
+	p, err := netip.ParsePrefix(entry)
+	return p.Masked(), err == nil
+}
+
+// JWTConfig holds the token signing settings
+type JWTConfig struct {
+	Secret   string   `json:"secret"`
+	Duration Duration `json:"duration"`
+}
+
+// GoogleBooksConfig holds the Google Books API client settings
+type GoogleBooksConfig struct {
+	APIKey    string   `json:"api_key"`
+	BaseURL   string   `json:"base_url"`
+	UserAgent string   `json:"user_agent"`
+	Country   string   `json:"country"`
+	Timeout   Duration `json:"timeout"`
+}
+
+// CoversConfig holds the cover cache settings
+type CoversConfig struct {
+	Dir          string   `json:"dir"`
+	AllowedHosts []string `json:"allowed_hosts"`
+	FetchTimeout Duration `json:"fetch_timeout"`
+}
+
+// TrashConfig holds how long deleted books are kept before being purged
+type TrashConfig struct {
+	Retention     Duration `json:"retention"`
+	PurgeInterval Duration `json:"purge_interval"`
+}
+
+// IdempotencyConfig holds how long Idempotency-Key responses are kept for retries
+type IdempotencyConfig struct {
+	TTL             Duration `json:"ttl"`
+	CleanupInterval Duration `json:"cleanup_interval"`
+}
+
+// OutboxConfig holds how events in the outbox are delivered and kept
+type OutboxConfig struct {
+	PollInterval Duration `json:"poll_interval"`
+	MaxAttempts  int      `json:"max_attempts"`
+	// Retention is how long delivered events are kept
+	Retention       Duration `json:"retention"`
+	CleanupInterval Duration `json:"cleanup_interval"`
+}
+
+// WebhooksConfig holds how webhook deliveries are sent and logged
+type WebhooksConfig struct {
+	Timeout Duration `json:"timeout"`
+	// AllowPrivateAddresses lets webhooks reach loopback and private
+	// networks; only for development, since any user can register a URL
+	AllowPrivateAddresses bool `json:"allow_private_addresses"`
+	// DeliveryRetention is how long the delivery log is kept
+	DeliveryRetention Duration `json:"delivery_retention"`
+}
+
+// CORSConfig holds the cross-origin settings for browser clients on other origins
+type CORSConfig struct {
+	AllowedOrigins []string `json:"allowed_origins"`
+	MaxAge         Duration `json:"max_age"`
+}
+
+// MetricsConfig holds the Prometheus and admin listener settings
+type MetricsConfig struct {
+	Enabled bool `json:"enabled"`
+	// AdminAddr moves /metrics and the health probes to a separate
+	// listener, host:port or unix:/path, that can stay off the internet
+	AdminAddr string `json:"admin_addr"`
+}
+
+// TracingConfig holds the OpenTelemetry settings
+type TracingConfig struct {
+	// Exporter is none, otlp or stdout
+	Exporter string `json:"exporter"`
+	// Endpoint is the OTLP/HTTP collector URL, e.g. http://localhost:4318
+	Endpoint    string  `json:"endpoint"`
+	ServiceName string  `json:"service_name"`
+	SampleRatio float64 `json:"sample_ratio"`
+}
+
+// LogConfig holds the logging settings
+type LogConfig struct {
+	// Level is debug, info, warn or error
+	Level string `json:"level"`
+	// Format is text for local development or json for log collectors
+	Format string `json:"format"`
+}
+
+// Duration is a time.Duration that reads as a string such as "24h" in JSON
+type Duration time.Duration
+
+// UnmarshalJSON parses a Go duration string
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"30s\": %w", err)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// ValidationError lists every problem found in a configuration
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Default returns the configuration used when nothing else is set
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Port:              "8080",
+			ReadTimeout:       Duration(15 * time.Second),
+			ReadHeaderTimeout: Duration(5 * time.Second),
+			WriteTimeout:      Duration(30 * time.Second),
+			IdleTimeout:       Duration(2 * time.Minute),
+			ShutdownTimeout:   Duration(20 * time.Second),
+		},
+		JWT: JWTConfig{
+			Duration: Duration(24 * time.Hour),
+		},
+		GoogleBooks: GoogleBooksConfig{
+			BaseURL:   "https://www.googleapis.com/books/v1",
+			UserAgent: "save-my-read",
+			Timeout:   Duration(10 * time.Second),
+		},
+		Covers: CoversConfig{
+			Dir:          "data/covers",
+			AllowedHosts: []string{"books.google.com", "books.googleusercontent.com"},
+			FetchTimeout: Duration(5 * time.Second),
+		},
+		Trash: TrashConfig{
+			Retention:     Duration(30 * 24 * time.Hour),
+			PurgeInterval: Duration(time.Hour),
+		},
+		Idempotency: IdempotencyConfig{
+			TTL:             Duration(24 * time.Hour),
+			CleanupInterval: Duration(time.Hour),
+		},
+		Outbox: OutboxConfig{
+			PollInterval:    Duration(time.Second),
+			MaxAttempts:     10,
+			Retention:       Duration(7 * 24 * time.Hour),
+			CleanupInterval: Duration(time.Hour),
+		},
+		Webhooks: WebhooksConfig{
+			Timeout:           Duration(10 * time.Second),
+			DeliveryRetention: Duration(30 * 24 * time.Hour),
+		},
+		CORS: CORSConfig{
+			MaxAge: Duration(time.Hour),
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: logging.FormatText,
+		},
+		Metrics: MetricsConfig{
+			Enabled: true,
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			ServiceName: "save-my-read",
+			SampleRatio: 1,
+		},
+		QueryTimeout: Duration(5 * time.Second),
+	}
+}
+
+// Load builds the configuration from defaults, the JSON file at path (if
+// path is not empty), the .env file and the environment. It does not
+// validate the result; call Validate once the caller knows what it needs.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading config file: %w", err)
+		}
+		if err := json.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("error parsing config file %s: %w", path, err)
+		}
+	}
+
+	// A missing .env file is normal outside local development
+	if err := godotenv.Load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("error loading .env file: %w", err)
+	}
+
+	var problems []string
+	setString(&cfg.DatabaseURL, "DATABASE_URL")
+	setDuration(&cfg.QueryTimeout, "DB_QUERY_TIMEOUT", &problems)
+	setBool(&cfg.AutoMigrate, "AUTO_MIGRATE", &problems)
+	setString(&cfg.Server.Port, "PORT")
+	setString(&cfg.Server.Addr, "LISTEN_ADDR")
+	setDuration(&cfg.Server.ReadTimeout, "SERVER_READ_TIMEOUT", &problems)
+	setDuration(&cfg.Server.ReadHeaderTimeout, "SERVER_READ_HEADER_TIMEOUT", &problems)
+	setDuration(&cfg.Server.WriteTimeout, "SERVER_WRITE_TIMEOUT", &problems)
+	setDuration(&cfg.Server.IdleTimeout, "SERVER_IDLE_TIMEOUT", &problems)
+	setDuration(&cfg.Server.ShutdownTimeout, "SERVER_SHUTDOWN_TIMEOUT", &problems)
+	setString(&cfg.Server.TLSCertFile, "TLS_CERT_FILE")
+	setString(&cfg.Server.TLSKeyFile, "TLS_KEY_FILE")
+	setList(&cfg.Server.TrustedProxies, "TRUSTED_PROXIES")
+	setString(&cfg.JWT.Secret, "JWT_SECRET")
+	setDuration(&cfg.JWT.Duration, "JWT_DURATION", &problems)
+	setString(&cfg.GoogleBooks.APIKey, "GOOGLE_BOOKS_API_KEY")
+	setString(&cfg.GoogleBooks.BaseURL, "GOOGLE_BOOKS_BASE_URL")
+	setString(&cfg.GoogleBooks.UserAgent, "GOOGLE_BOOKS_USER_AGENT")
+	setString(&cfg.GoogleBooks.Country, "GOOGLE_BOOKS_COUNTRY")
+	setDuration(&cfg.GoogleBooks.Timeout, "GOOGLE_BOOKS_TIMEOUT", &problems)
+	setString(&cfg.Covers.Dir, "COVERS_DIR")
+	setList(&cfg.Covers.AllowedHosts, "COVER_ALLOWED_HOSTS")
+	setDuration(&cfg.Covers.FetchTimeout, "COVER_FETCH_TIMEOUT", &problems)
+	setDuration(&cfg.Trash.Retention, "TRASH_RETENTION", &problems)
+	setDuration(&cfg.Trash.PurgeInterval, "TRASH_PURGE_INTERVAL", &problems)
+	setDuration(&cfg.Idempotency.TTL, "IDEMPOTENCY_TTL", &problems)
+	setDuration(&cfg.Idempotency.CleanupInterval, "IDEMPOTENCY_CLEANUP_INTERVAL", &problems)
+	setDuration(&cfg.Outbox.PollInterval, "OUTBOX_POLL_INTERVAL", &problems)
+	setInt(&cfg.Outbox.MaxAttempts, "OUTBOX_MAX_ATTEMPTS", &problems)
+	setDuration(&cfg.Outbox.Retention, "OUTBOX_RETENTION", &problems)
+	setDuration(&cfg.Outbox.CleanupInterval, "OUTBOX_CLEANUP_INTERVAL", &problems)
+	setDuration(&cfg.Webhooks.Timeout, "WEBHOOK_TIMEOUT", &problems)
+	setBool(&cfg.Webhooks.AllowPrivateAddresses, "WEBHOOK_ALLOW_PRIVATE_ADDRESSES", &problems)
+	setDuration(&cfg.Webhooks.DeliveryRetention, "WEBHOOK_DELIVERY_RETENTION", &problems)
+	setList(&cfg.CORS.AllowedOrigins, "CORS_ALLOWED_ORIGINS")
+	setDuration(&cfg.CORS.MaxAge, "CORS_MAX_AGE", &problems)
+	setString(&cfg.Log.Level, "LOG_LEVEL")
+	setString(&cfg.Log.Format, "LOG_FORMAT")
+	setBool(&cfg.Metrics.Enabled, "METRICS_ENABLED", &problems)
+	setString(&cfg.Metrics.AdminAddr, "ADMIN_ADDR")
+	setString(&cfg.Tracing.Exporter, "OTEL_TRACES_EXPORTER")
+	setString(&cfg.Tracing.Endpoint, "OTEL_EXPORTER_OTLP_ENDPOINT")
+	setString(&cfg.Tracing.ServiceName, "OTEL_SERVICE_NAME")
+	setFloat(&cfg.Tracing.SampleRatio, "OTEL_TRACES_SAMPLER_ARG", &problems)
+
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+	return cfg, nil
+}
+
+// Validate checks everything the API server needs and reports all problems at once
+func (c *Config) Validate() error {
+	var problems []string
+
+	if c.DatabaseURL == "" && !c.Demo {
+		problems = append(problems, "DATABASE_URL is required")
+	}
+	if c.QueryTimeout <= 0 {
+		problems = append(problems, "DB_QUERY_TIMEOUT must be positive")
+	}
+	problems = append(problems, c.Server.problems()...)
+	if c.JWT.Secret == "" {
+		problems = append(problems, "JWT_SECRET is required")
+	}
+	if c.JWT.Duration <= 0 {
+		problems = append(problems, "JWT_DURATION must be positive")
+	}
+	if c.GoogleBooks.APIKey == "" && !c.Demo {
+		problems = append(problems, "GOOGLE_BOOKS_API_KEY is required")
+	}
+	problems = append(problems, c.GoogleBooks.problems()...)
+	if c.Covers.Dir == "" {
+		problems = append(problems, "COVERS_DIR must not be empty")
+	}
+	if c.Covers.FetchTimeout <= 0 {
+		problems = append(problems, "COVER_FETCH_TIMEOUT must be positive")
+	}
+	if c.Trash.Retention <= 0 {
+		problems = append(problems, "TRASH_RETENTION must be positive")
+	}
+	if c.Trash.PurgeInterval <= 0 {
+		problems = append(problems, "TRASH_PURGE_INTERVAL must be positive")
+	}
+	if c.Idempotency.TTL <= 0 {
+		problems = append(problems, "IDEMPOTENCY_TTL must be positive")
+	}
+	if c.Idempotency.CleanupInterval <= 0 {
+		problems = append(problems, "IDEMPOTENCY_CLEANUP_INTERVAL must be positive")
+	}
+	if c.Outbox.PollInterval <= 0 {
+		problems = append(problems, "OUTBOX_POLL_INTERVAL must be positive")
+	}
+	if c.Outbox.MaxAttempts <= 0 {
+		problems = append(problems, "OUTBOX_MAX_ATTEMPTS must be positive")
+	}
+	if c.Outbox.Retention <= 0 {
+		problems = append(problems, "OUTBOX_RETENTION must be positive")
+	}
+	if c.Outbox.CleanupInterval <= 0 {
+		problems = append(problems, "OUTBOX_CLEANUP_INTERVAL must be positive")
+	}
+	if c.Webhooks.Timeout <= 0 {
+		problems = append(problems, "WEBHOOK_TIMEOUT must be positive")
+	}
+	if c.Webhooks.DeliveryRetention <= 0 {
+		problems = append(problems, "WEBHOOK_DELIVERY_RETENTION must be positive")
+	}
+	for _, origin := range c.CORS.AllowedOrigins {
+		if origin == "*" {
+			continue
+		}
+		if u, err := url.Parse(origin); err != nil || u.Scheme == "" || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			problems = append(problems, fmt.Sprintf("CORS_ALLOWED_ORIGINS entry %q must be * or an origin such as https://app.example.com", origin))
+		}
+	}
+	if c.CORS.MaxAge < 0 {
+		problems = append(problems, "CORS_MAX_AGE must not be negative")
+	}
+	if c.Metrics.AdminAddr != "" && c.Metrics.AdminAddr == c.Server.ListenAddr() {
+		problems = append(problems, "ADMIN_ADDR must differ from the main listen address")
+	}
+	problems = append(problems, c.Tracing.problems()...)
+	if _, err := logging.New(io.Discard, c.Log.Level, c.Log.Format); err != nil {
+		problems = append(problems, "LOG_LEVEL and LOG_FORMAT: "+err.Error())
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+func (c ServerConfig) problems() []string {
+	var problems []string
+
+	if path, ok := strings.CutPrefix(c.Addr, "unix:"); ok {
+		if path == "" {
+			problems = append(problems, "LISTEN_ADDR must name a socket path after unix:")
+		}
+	} else if c.Addr == "" && c.Port == "" {
+		problems = append(problems, "PORT or LISTEN_ADDR must be set")
+	}
+	for _, t := range []struct {
+		key   string
+		value Duration
+	}{
+		{"SERVER_READ_TIMEOUT", c.ReadTimeout},
+		{"SERVER_READ_HEADER_TIMEOUT", c.ReadHeaderTimeout},
+		{"SERVER_WRITE_TIMEOUT", c.WriteTimeout},
+		{"SERVER_IDLE_TIMEOUT", c.IdleTimeout},
+		{"SERVER_SHUTDOWN_TIMEOUT", c.ShutdownTimeout},
+	} {
+		if t.value <= 0 {
+			problems = append(problems, t.key+" must be positive")
+		}
+	}
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		problems = append(problems, "TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	for _, entry := range c.TrustedProxies {
+		if _, ok := parseProxy(entry); !ok {
+			problems = append(problems, fmt.Sprintf("TRUSTED_PROXIES entry %q must be an IP address or CIDR range", entry))
+		}
+	}
+
+	return problems
+}
+
+func (c TracingConfig) problems() []string {
+	var problems []string
+
+	switch c.Exporter {
+	case "", "none", "stdout":
+	case "otlp":
+		if c.Endpoint != "" {
+			if u, err := url.Parse(c.Endpoint); err != nil || u.Scheme == "" || u.Host == "" {
+				problems = append(problems, "OTEL_EXPORTER_OTLP_ENDPOINT must be an absolute URL")
+			}
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("OTEL_TRACES_EXPORTER %q must be none, otlp or stdout", c.Exporter))
+	}
+	if c.SampleRatio < 0 || c.SampleRatio > 1 {
+		problems = append(problems, "OTEL_TRACES_SAMPLER_ARG must be between 0 and 1")
+	}
+
+	return problems
+}
+
+// Validate checks the Google Books settings on their own, for tools that
+// only talk to the Google Books API
+func (c GoogleBooksConfig) Validate() error {
+	var problems []string
+	if c.APIKey == "" {
+		problems = append(problems, "GOOGLE_BOOKS_API_KEY is required")
+	}
+	if problems = append(problems, c.problems()...); len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+func (c GoogleBooksConfig) problems() []string {
+	var problems []string
+
+	if u, err := url.Parse(c.BaseURL); err != nil || u.Scheme == "" || u.Host == "" {
+		problems = append(problems, "GOOGLE_BOOKS_BASE_URL must be an absolute URL")
+	}
+	if c.Country != "" && len(c.Country) != 2 {
+		problems = append(problems, "GOOGLE_BOOKS_COUNTRY must be a two-letter country code")
+	}
+	if c.Timeout <= 0 {
+		problems = append(problems, "GOOGLE_BOOKS_TIMEOUT must be positive")
+	}
+
+	return problems
+}
+
+func setString(dst *string, key string) {
+	if v, ok := os.LookupEnv(key); ok {
+		*dst = v
+	}
+}
+
+func setBool(dst *bool, key string, problems *[]string) {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		*problems = append(*problems, fmt.Sprintf("%s must be true or false", key))
+		return
+	}
+	*dst = b
+}
+
+func setList(dst *[]string, key string) {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return
+	}
+	var list []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	*dst = list
+}
+
+func setInt(dst *int, key string, problems *[]string) {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		*problems = append(*problems, fmt.Sprintf("%s must be a whole number", key))
+		return
+	}
+	*dst = n
+}
+
+func setFloat(dst *float64, key string, problems *[]string) {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		*problems = append(*problems, fmt.Sprintf("%s must be a number", key))
+		return
+	}
+	*dst = f
+}
+
+func setDuration(dst *Duration, key string, problems *[]string) {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		*problems = append(*problems, fmt.Sprintf("%s must be a duration such as \"30s\": %v", key, err))
+		return
+	}
+	*dst = Duration(d)
+}
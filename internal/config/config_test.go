@@ -0,0 +1,151 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoad_FileThenEnvironment(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	data := `{"server":{"port":"9000"},"jwt":{"secret":"from-file","duration":"1h"},"google_books":{"country":"PT"}}`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("JWT_SECRET", "from-env")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if cfg.Server.Port != "9000" {
+		t.Errorf("expected port from file, got %q", cfg.Server.Port)
+	}
+	if cfg.JWT.Secret != "from-env" {
+		t.Errorf("expected environment to override file, got %q", cfg.JWT.Secret)
+	}
+	if time.Duration(cfg.JWT.Duration) != time.Hour {
+		t.Errorf("expected 1h duration, got %v", time.Duration(cfg.JWT.Duration))
+	}
+	if cfg.GoogleBooks.BaseURL != Default().GoogleBooks.BaseURL {
+		t.Errorf("expected default base URL, got %q", cfg.GoogleBooks.BaseURL)
+	}
+}
+
+func TestValidate_ReportsAllProblems(t *testing.T) {
+	cfg := Default()
+	cfg.GoogleBooks.Country = "Brazil"
+
+	err := cfg.Validate()
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+
+	// DATABASE_URL, JWT_SECRET, GOOGLE_BOOKS_API_KEY and GOOGLE_BOOKS_COUNTRY
+	if len(verr.Problems) != 4 {
+		t.Errorf("expected 4 problems, got %d: %v", len(verr.Problems), verr.Problems)
+	}
+}
+
+func TestLoad_InvalidDuration(t *testing.T) {
+	t.Setenv("GOOGLE_BOOKS_TIMEOUT", "soon")
+
+	if _, err := Load(""); err == nil {
+		t.Error("expected error for invalid duration")
+	}
+}
+
+func TestServerConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      ServerConfig
+		wantAddr string
+		problems int
+	}{
+		{"port only", ServerConfig{Port: "8080"}, ":8080", 0},
+		{"addr wins over port", ServerConfig{Addr: "127.0.0.1:9000", Port: "8080"}, "127.0.0.1:9000", 0},
+		{"unix socket", ServerConfig{Addr: "unix:/run/app.sock"}, "unix:/run/app.sock", 0},
+		{"unix without path", ServerConfig{Addr: "unix:"}, "unix:", 1},
+		{"cert without key", ServerConfig{Port: "8080", TLSCertFile: "cert.pem"}, ":8080", 1},
+		{"trusted proxies", ServerConfig{Port: "8080", TrustedProxies: []string{"10.0.0.0/8", "::1"}}, ":8080", 0},
+		{"bad trusted proxy", ServerConfig{Port: "8080", TrustedProxies: []string{"proxy.internal"}}, ":8080", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defaults := Default().Server
+			tt.cfg.ReadTimeout = defaults.ReadTimeout
+			tt.cfg.ReadHeaderTimeout = defaults.ReadHeaderTimeout
+			tt.cfg.WriteTimeout = defaults.WriteTimeout
+			tt.cfg.IdleTimeout = defaults.IdleTimeout
+			tt.cfg.ShutdownTimeout = defaults.ShutdownTimeout
+
+			if got := tt.cfg.ListenAddr(); got != tt.wantAddr {
+				t.Errorf("expected address %q, got %q", tt.wantAddr, got)
+			}
+			if got := tt.cfg.problems(); len(got) != tt.problems {
+				t.Errorf("expected %d problems, got %v", tt.problems, got)
+			}
+		})
+	}
+}
+
+func TestConfig_LogValueRedactsSecrets(t *testing.T) {
+	cfg := Default()
+	cfg.DatabaseURL = "postgres://app:hunter2@db:5432/books"
+	cfg.JWT.Secret = "jwt-signing-secret"
+	cfg.GoogleBooks.APIKey = "google-api-key"
+
+	var buf bytes.Buffer
+	slog.New(slog.NewJSONHandler(&buf, nil)).Info("config", "config", cfg)
+
+	for _, secret := range []string{"hunter2", "jwt-signing-secret", "google-api-key"} {
+		if strings.Contains(buf.String(), secret) {
+			t.Errorf("secret %q leaked into log output: %s", secret, buf.String())
+		}
+	}
+	if !strings.Contains(buf.String(), "db:5432") {
+		t.Errorf("expected the database host to be logged, got %s", buf.String())
+	}
+}
+
+func TestTracingConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      TracingConfig
+		problems int
+	}{
+		{"disabled", TracingConfig{Exporter: "none", SampleRatio: 1}, 0},
+		{"stdout", TracingConfig{Exporter: "stdout", SampleRatio: 0.5}, 0},
+		{"otlp with default endpoint", TracingConfig{Exporter: "otlp", SampleRatio: 1}, 0},
+		{"otlp with endpoint", TracingConfig{Exporter: "otlp", Endpoint: "http://collector:4318", SampleRatio: 1}, 0},
+		{"relative endpoint", TracingConfig{Exporter: "otlp", Endpoint: "collector:4318", SampleRatio: 1}, 1},
+		{"unknown exporter", TracingConfig{Exporter: "jaeger", SampleRatio: 1}, 1},
+		{"ratio out of range", TracingConfig{Exporter: "stdout", SampleRatio: 2}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cfg.problems(); len(got) != tt.problems {
+				t.Errorf("expected %d problems, got %v", tt.problems, got)
+			}
+		})
+	}
+}
+
+func TestValidate_DemoNeedsNoDatabaseOrAPIKey(t *testing.T) {
+	cfg := Default()
+	cfg.Demo = true
+	cfg.JWT.Secret = "secret"
+
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected demo config to be valid, got %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+// Package audit records who did what: security events such as logins and
+// every change to a user's data, kept in an append-only log.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"time"
+)
+
+// Action names what happened
+type Action string
+
+const (
+	ActionRegister      Action = "auth.register"
+	ActionLogin         Action = "auth.login"
+	ActionLoginFailed   Action = "auth.login_failed"
+	ActionTokenIssued   Action = "auth.token_issued"
+	ActionBookCreate    Action = "book.create"
+	ActionBookUpdate    Action = "book.update"
+	ActionBookDelete    Action = "book.delete"
+	ActionBookRestore   Action = "book.restore"
+	ActionBookMerge     Action = "book.merge"
+	ActionWebhookCreate Action = "webhook.create"
+	ActionWebhookUpdate Action = "webhook.update"
+	ActionWebhookDelete Action = "webhook.delete"
+)
+
+// Target types
+const (
+	TargetUser    = "user"
+	TargetBook    = "book"
+	TargetWebhook = "webhook"
+)
+
+// Event is one entry of the audit log
+type Event struct {
+	ID         string    `json:"id"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Action     Action    `json:"action"`
+	// UserID is the account the event belongs to, empty when unknown
+	UserID string `json:"user_id,omitempty"`
+	// ActorID is who acted, empty for anonymous requests such as a failed login
+	ActorID    string `json:"actor_id,omitempty"`
+	TargetType string `json:"target_type,omitempty"`
+	TargetID   string `json:"target_id,omitempty"`
+	IP         string `json:"ip,omitempty"`
+	UserAgent  string `json:"user_agent,omitempty"`
+	RequestID  string `json:"request_id,omitempty"`
+	// Changes maps each changed field to its value before and after
+	Changes map[string]Change `json:"changes,omitempty"`
+	Details map[string]string `json:"details,omitempty"`
+}
+
+// Change is the value of a field before and after an event; From is nil
+// for created data and To is nil for deleted data
+type Change struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// Diff compares the JSON fields of before and after, either of which may
+// be nil, and returns those that differ
+func Diff(before, after interface{}) map[string]Change {
+	from, to := fields(before), fields(after)
+	changes := make(map[string]Change)
+	for name, v := range from {
+		if w, ok := to[name]; !ok || !reflect.DeepEqual(v, w) {
+			changes[name] = Change{From: v, To: to[name]}
+		}
+	}
+	for name, w := range to {
+		if _, ok := from[name]; !ok {
+			changes[name] = Change{To: w}
+		}
+	}
+	return changes
+}
+
+func fields(v interface{}) map[string]interface{} {
+	m := map[string]interface{}{}
+	if v == nil || reflect.ValueOf(v).Kind() == reflect.Pointer && reflect.ValueOf(v).IsNil() {
+		return m
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return m
+	}
+	json.Unmarshal(data, &m)
+	return m
+}
+
+// Recorder takes events to be written to the audit log
+type Recorder interface {
+	Record(ctx context.Context, e *Event)
+}
+
+// Discard is a Recorder that drops every event
+var Discard Recorder = discard{}
+
+type discard struct{}
+
+func (discard) Record(context.Context, *Event) {}
+
+// Source describes where a request came from
+type Source struct {
+	IP        string
+	UserAgent string
+	RequestID string
+}
+
+type sourceKey struct{}
+
+// WithSource returns a copy of ctx carrying the request's source
+func WithSource(ctx context.Context, src Source) context.Context {
+	return context.WithValue(ctx, sourceKey{}, src)
+}
+
+// SourceFrom returns the source stored in ctx, if any
+func SourceFrom(ctx context.Context) Source {
+	src, _ := ctx.Value(sourceKey{}).(Source)
+	return src
+}
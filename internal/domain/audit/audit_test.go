@@ -0,0 +1,55 @@
+package audit
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	type item struct {
+		Name  string   `json:"name"`
+		Tags  []string `json:"tags"`
+		Count int      `json:"count,omitempty"`
+	}
+
+	tests := []struct {
+		name          string
+		before, after interface{}
+		want          map[string]Change
+	}{
+		{
+			name:   "changed fields only",
+			before: &item{Name: "a", Tags: []string{"x"}, Count: 1},
+			after:  &item{Name: "a", Tags: []string{"x", "y"}, Count: 2},
+			want: map[string]Change{
+				"tags":  {From: []interface{}{"x"}, To: []interface{}{"x", "y"}},
+				"count": {From: 1.0, To: 2.0},
+			},
+		},
+		{
+			name:   "created",
+			before: (*item)(nil),
+			after:  &item{Name: "a"},
+			want:   map[string]Change{"name": {To: "a"}, "tags": {}},
+		},
+		{
+			name:   "deleted",
+			before: &item{Name: "a", Count: 3},
+			after:  nil,
+			want:   map[string]Change{"name": {From: "a"}, "tags": {}, "count": {From: 3.0}},
+		},
+		{
+			name:   "field dropped",
+			before: &item{Name: "a", Count: 3},
+			after:  &item{Name: "a"},
+			want:   map[string]Change{"count": {From: 3.0}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Diff(tt.before, tt.after); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package audit
+
+import (
+	"context"
+	"time"
+)
+
+// Filter selects events from the log. Zero fields match everything.
+type Filter struct {
+	UserID string
+	Action Action
+	// Since and Until bound OccurredAt; Until is exclusive so the oldest
+	// event of a page can be passed as the next page's Until
+	Since time.Time
+	Until time.Time
+	// Limit caps how many events are returned, newest first
+	Limit int
+}
+
+// Store defines the interface for audit log persistence. Events can only
+// be appended; the log is never updated or deleted from.
+type Store interface {
+	Append(ctx context.Context, events ...*Event) error
+	List(ctx context.Context, f Filter) ([]*Event, error)
+}
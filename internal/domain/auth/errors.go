@@ -1,8 +1,9 @@
 package auth
 
-import "errors"
+import "github.com/guisithos/save-my-read/internal/domain/domainerr"
 
 var (
-	ErrEmailAlreadyExists = errors.New("email already exists")
-	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrEmailAlreadyExists = domainerr.Conflict("email_already_exists", "email already exists")
+	ErrInvalidCredentials = domainerr.Unauthorized("invalid_credentials", "invalid email or password")
+	ErrInvalidToken       = domainerr.Unauthorized("invalid_token", "invalid or expired token")
 )
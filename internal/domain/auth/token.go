@@ -3,6 +3,7 @@ package auth
 
 import (
 	"errors"
+	"log/slog"
 	"time"
 
 	"github.com/golang-jwt/jwt"
@@ -63,7 +64,7 @@ func (s *JWTService) ValidateToken(tokenString string) (*Claims, error) {
 		return claims, nil
 	}
 
-	return nil, errors.New("invalid token")
+	return nil, ErrInvalidToken
 }
 
 type UserResponse struct {
@@ -76,3 +77,11 @@ type LoginResponse struct {
 	Token string       `json:"token"`
 	User  UserResponse `json:"user"`
 }
+
+// LogValue keeps the bearer token out of structured logs
+func (r LoginResponse) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("token", "[REDACTED]"),
+		slog.String("user_id", r.User.ID),
+	)
+}
@@ -1,10 +1,11 @@
 package book
 
 import (
-	"errors"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/guisithos/save-my-read/internal/domain/domainerr"
+	"github.com/guisithos/save-my-read/internal/domain/event"
 )
 
 // Status represents the reading status of a book
@@ -36,34 +37,46 @@ type Book struct {
 	Description string    `json:"description"`
 	Categories  []string  `json:"categories"`
 	ImageURL    string    `json:"image_url"`
+	ISBN        string    `json:"isbn,omitempty"`
 	Status      Status    `json:"status"`
 	UserID      string    `json:"user_id"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+	// Version starts at 1 and goes up with every saved change
+	Version int `json:"version"`
+	// DeletedAt is set while the book is in the trash
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+
+	// events are the changes recorded since PullEvents was last called
+	events []event.Event
 }
 
 // NewBook creates a new book with validated fields
 func NewBook(googleID, title string, authors []string, description string,
 	categories []string, imageURL string, status Status, userID string) (*Book, error) {
 
+	verr := &domainerr.ValidationError{}
 	if googleID == "" {
-		return nil, errors.New("google_id is required")
+		verr.Add("google_id", "is required")
 	}
 	if title == "" {
-		return nil, errors.New("title is required")
+		verr.Add("title", "is required")
 	}
 	if len(authors) == 0 {
-		return nil, errors.New("at least one author is required")
+		verr.Add("authors", "at least one author is required")
 	}
 	if userID == "" {
-		return nil, errors.New("user_id is required")
+		verr.Add("user_id", "is required")
 	}
 	if !status.IsValid() {
-		return nil, errors.New("invalid status")
+		verr.Add("status", "invalid status")
+	}
+	if err := verr.Err(); err != nil {
+		return nil, err
 	}
 
 	now := time.Now()
-	return &Book{
+	b := &Book{
 		ID:          uuid.New().String(),
 		GoogleID:    googleID,
 		Title:       title,
@@ -75,13 +88,19 @@ func NewBook(googleID, title string, authors []string, description string,
 		UserID:      userID,
 		CreatedAt:   now,
 		UpdatedAt:   now,
-	}, nil
+		Version:     1,
+	}
+	b.record(Added{BookID: b.ID, UserID: userID, GoogleID: googleID, Title: title, Status: status})
+	return b, nil
 }
 
 // UpdateStatus changes the book's reading status
 func (b *Book) UpdateStatus(status Status) error {
 	if !status.IsValid() {
-		return errors.New("invalid status")
+		return domainerr.Validation("status", "invalid status")
+	}
+	if status != b.Status {
+		b.record(StatusChanged{BookID: b.ID, UserID: b.UserID, From: b.Status, To: status})
 	}
 	b.Status = status
 	b.UpdatedAt = time.Now()
@@ -0,0 +1,207 @@
+package book
+
+import (
+	"strings"
+	"time"
+	"unicode"
+)
+
+// DuplicateReason describes why two entries are considered the same book
+type DuplicateReason string
+
+const (
+	DuplicateGoogleID    DuplicateReason = "google_id"
+	DuplicateISBN        DuplicateReason = "isbn"
+	DuplicateTitleAuthor DuplicateReason = "title_author"
+)
+
+// titleSimilarity is the minimum similarity between normalized titles for
+// two entries with a common author to be reported as duplicates
+const titleSimilarity = 0.85
+
+// FindDuplicate returns the first entry in library that is likely the same
+// book as b, together with the reason for the match
+func FindDuplicate(b *Book, library []*Book) (*Book, DuplicateReason, bool) {
+	for _, other := range library {
+		if other.ID == b.ID {
+			continue
+		}
+		if reason, ok := IsLikelyDuplicate(b, other); ok {
+			return other, reason, true
+		}
+	}
+	return nil, "", false
+}
+
+// IsLikelyDuplicate reports whether two entries describe the same book,
+// even when they were added from different providers
+func IsLikelyDuplicate(a, b *Book) (DuplicateReason, bool) {
+	if a.GoogleID != "" && a.GoogleID == b.GoogleID {
+		return DuplicateGoogleID, true
+	}
+
+	if isbnA, isbnB := NormalizeISBN(a.ISBN), NormalizeISBN(b.ISBN); isbnA != "" && isbnA == isbnB {
+		return DuplicateISBN, true
+	}
+
+	if shareAuthor(a.Authors, b.Authors) &&
+		similarity(normalizeTitle(a.Title), normalizeTitle(b.Title)) >= titleSimilarity {
+		return DuplicateTitleAuthor, true
+	}
+
+	return "", false
+}
+
+// Merge folds source into b. Fields b lacks are taken from source, authors
+// and categories are combined, the more advanced reading status wins and
+// the entry keeps the earliest date it was added.
+func (b *Book) Merge(source *Book) {
+	if b.Description == "" {
+		b.Description = source.Description
+	}
+	if b.ImageURL == "" {
+		b.ImageURL = source.ImageURL
+	}
+	if b.ISBN == "" {
+		b.ISBN = source.ISBN
+	}
+	b.Authors = union(b.Authors, source.Authors)
+	b.Categories = union(b.Categories, source.Categories)
+
+	if statusRank(source.Status) > statusRank(b.Status) {
+		b.record(StatusChanged{BookID: b.ID, UserID: b.UserID, From: b.Status, To: source.Status})
+		b.Status = source.Status
+	}
+	if source.CreatedAt.Before(b.CreatedAt) {
+		b.CreatedAt = source.CreatedAt
+	}
+	b.UpdatedAt = time.Now()
+	b.record(Merged{BookID: b.ID, SourceID: source.ID, UserID: b.UserID})
+}
+
+// NormalizeISBN strips separators from an ISBN, returning an empty string
+// when the result is not a plausible ISBN-10 or ISBN-13
+func NormalizeISBN(isbn string) string {
+	var sb strings.Builder
+	for _, r := range isbn {
+		switch {
+		case unicode.IsDigit(r):
+			sb.WriteRune(r)
+		case r == 'x' || r == 'X':
+			sb.WriteRune('X')
+		}
+	}
+	if n := sb.Len(); n != 10 && n != 13 {
+		return ""
+	}
+	return sb.String()
+}
+
+// statusRank orders statuses by how far the reader got with the book
+func statusRank(s Status) int {
+	switch s {
+	case StatusCompleted:
+		return 3
+	case StatusDNF:
+		return 2
+	case StatusReading:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// normalizeTitle lowercases a title, drops the subtitle, punctuation and
+// leading articles so editions from different providers compare equal
+func normalizeTitle(title string) string {
+	if i := strings.IndexAny(title, ":("); i > 0 {
+		title = title[:i]
+	}
+	words := strings.Fields(normalizeText(title))
+	if len(words) > 1 {
+		switch words[0] {
+		case "the", "a", "an":
+			words = words[1:]
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// normalizeText lowercases s and replaces everything but letters and digits with spaces
+func normalizeText(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return ' '
+	}, s)
+}
+
+// shareAuthor reports whether the lists have an author in common, comparing
+// surnames so "J. R. R. Tolkien" matches "John Ronald Reuel Tolkien"
+func shareAuthor(a, b []string) bool {
+	surnames := make(map[string]bool, len(a))
+	for _, name := range a {
+		if s := surname(name); s != "" {
+			surnames[s] = true
+		}
+	}
+	for _, name := range b {
+		if surnames[surname(name)] {
+			return true
+		}
+	}
+	return false
+}
+
+func surname(name string) string {
+	words := strings.Fields(normalizeText(name))
+	if len(words) == 0 {
+		return ""
+	}
+	return words[len(words)-1]
+}
+
+// similarity returns 1 minus the normalized Levenshtein distance between a and b
+func similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 && len(rb) == 0 {
+		return 1
+	}
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return 1 - float64(prev[len(rb)])/float64(max(len(ra), len(rb)))
+}
+
+// union appends the items of b missing from a, ignoring case
+func union(a, b []string) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+	result := make([]string, 0, len(a)+len(b))
+	for _, list := range [][]string{a, b} {
+		for _, item := range list {
+			key := strings.ToLower(strings.TrimSpace(item))
+			if key == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			result = append(result, item)
+		}
+	}
+	return result
+}
@@ -0,0 +1,105 @@
+package book
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/guisithos/save-my-read/internal/domain/event"
+)
+
+func TestIsLikelyDuplicate(t *testing.T) {
+	tests := []struct {
+		name   string
+		a, b   *Book
+		want   bool
+		reason DuplicateReason
+	}{
+		{
+			name:   "same google id",
+			a:      &Book{GoogleID: "g1", Title: "Dune"},
+			b:      &Book{GoogleID: "g1", Title: "Something else"},
+			want:   true,
+			reason: DuplicateGoogleID,
+		},
+		{
+			name:   "same isbn with different formatting",
+			a:      &Book{GoogleID: "g1", ISBN: "978-0-441-17271-9"},
+			b:      &Book{GoogleID: "ol1", ISBN: "9780441172719"},
+			want:   true,
+			reason: DuplicateISBN,
+		},
+		{
+			name:   "title and author from another provider",
+			a:      &Book{GoogleID: "g1", Title: "The Lord of the Rings", Authors: []string{"J. R. R. Tolkien"}},
+			b:      &Book{GoogleID: "ol1", Title: "Lord of the Rings: 50th Anniversary Edition", Authors: []string{"John Ronald Reuel Tolkien"}},
+			want:   true,
+			reason: DuplicateTitleAuthor,
+		},
+		{
+			name: "same title different author",
+			a:    &Book{GoogleID: "g1", Title: "Emma", Authors: []string{"Jane Austen"}},
+			b:    &Book{GoogleID: "g2", Title: "Emma", Authors: []string{"Alexander McCall Smith"}},
+			want: false,
+		},
+		{
+			name: "same author different title",
+			a:    &Book{GoogleID: "g1", Title: "Dune", Authors: []string{"Frank Herbert"}},
+			b:    &Book{GoogleID: "g2", Title: "Dune Messiah", Authors: []string{"Frank Herbert"}},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, ok := IsLikelyDuplicate(tt.a, tt.b)
+			if ok != tt.want || reason != tt.reason {
+				t.Errorf("IsLikelyDuplicate() = (%q, %v), want (%q, %v)", reason, ok, tt.reason, tt.want)
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	older := time.Now().Add(-48 * time.Hour)
+	target := &Book{
+		ID:         "b1",
+		UserID:     "u1",
+		Authors:    []string{"Frank Herbert"},
+		Categories: []string{"Fiction"},
+		Status:     StatusToRead,
+		CreatedAt:  time.Now(),
+	}
+	source := &Book{
+		ID:          "b2",
+		Authors:     []string{"frank herbert"},
+		Categories:  []string{"Science Fiction"},
+		Description: "Spice",
+		ISBN:        "9780441172719",
+		Status:      StatusCompleted,
+		CreatedAt:   older,
+	}
+
+	target.Merge(source)
+
+	if target.Status != StatusCompleted {
+		t.Errorf("expected most advanced status, got %s", target.Status)
+	}
+	if !target.CreatedAt.Equal(older) {
+		t.Errorf("expected earliest created_at to be kept")
+	}
+	if len(target.Authors) != 1 || len(target.Categories) != 2 {
+		t.Errorf("unexpected authors %v or categories %v", target.Authors, target.Categories)
+	}
+	if target.Description != "Spice" || target.ISBN != "9780441172719" {
+		t.Errorf("expected missing fields to be filled from source")
+	}
+
+	want := []event.Event{
+		StatusChanged{BookID: "b1", UserID: "u1", From: StatusToRead, To: StatusCompleted},
+		Merged{BookID: "b1", SourceID: "b2", UserID: "u1"},
+	}
+	if got := target.PullEvents(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected the status change and the merge recorded, got %+v", got)
+	}
+}
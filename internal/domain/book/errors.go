@@ -0,0 +1,36 @@
+package book
+
+import (
+	"fmt"
+
+	"github.com/guisithos/save-my-read/internal/domain/domainerr"
+)
+
+var (
+	ErrNotFound  = domainerr.NotFound("book_not_found", "book not found")
+	ErrDuplicate = domainerr.Conflict("book_duplicate", "book already in library")
+	// ErrModified reports an update based on an outdated version of a book
+	ErrModified = domainerr.PreconditionFailed("book_modified", "book was modified since it was read")
+)
+
+// DuplicateError reports that a book is already in the user's library
+type DuplicateError struct {
+	ExistingID string
+	Reason     DuplicateReason
+}
+
+func (e *DuplicateError) Error() string {
+	return fmt.Sprintf("book already in library as %s (matched by %s)", e.ExistingID, e.Reason)
+}
+
+func (e *DuplicateError) Unwrap() error {
+	return ErrDuplicate
+}
+
+// Details exposes the existing entry so clients can link to it
+func (e *DuplicateError) Details() map[string]interface{} {
+	return map[string]interface{}{
+		"existing_book_id": e.ExistingID,
+		"reason":           e.Reason,
+	}
+}
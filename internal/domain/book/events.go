@@ -0,0 +1,72 @@
+package book
+
+import "github.com/guisithos/save-my-read/internal/domain/event"
+
+// Names of the events about books
+const (
+	EventAdded         = "book.added"
+	EventStatusChanged = "book.status_changed"
+	EventDeleted       = "book.deleted"
+	EventRestored      = "book.restored"
+	EventMerged        = "book.merged"
+)
+
+// Added is emitted when a book is added to a user's list
+type Added struct {
+	BookID   string `json:"book_id"`
+	UserID   string `json:"user_id"`
+	GoogleID string `json:"google_id"`
+	Title    string `json:"title"`
+	Status   Status `json:"status"`
+}
+
+func (Added) EventName() string { return EventAdded }
+
+// StatusChanged is emitted when a book's reading status changes
+type StatusChanged struct {
+	BookID string `json:"book_id"`
+	UserID string `json:"user_id"`
+	From   Status `json:"from"`
+	To     Status `json:"to"`
+}
+
+func (StatusChanged) EventName() string { return EventStatusChanged }
+
+// Deleted is emitted when a book is moved to the trash
+type Deleted struct {
+	BookID string `json:"book_id"`
+	UserID string `json:"user_id"`
+}
+
+func (Deleted) EventName() string { return EventDeleted }
+
+// Restored is emitted when a book is taken out of the trash
+type Restored struct {
+	BookID string `json:"book_id"`
+	UserID string `json:"user_id"`
+}
+
+func (Restored) EventName() string { return EventRestored }
+
+// Merged is emitted when a duplicate is folded into another book; the
+// source is moved to the trash without a separate Deleted event
+type Merged struct {
+	BookID   string `json:"book_id"`
+	SourceID string `json:"source_id"`
+	UserID   string `json:"user_id"`
+}
+
+func (Merged) EventName() string { return EventMerged }
+
+// record keeps e until the book's events are pulled
+func (b *Book) record(e event.Event) {
+	b.events = append(b.events, e)
+}
+
+// PullEvents returns the events recorded by the book since they were last
+// pulled and forgets them, so they are handed on exactly once
+func (b *Book) PullEvents() []event.Event {
+	events := b.events
+	b.events = nil
+	return events
+}
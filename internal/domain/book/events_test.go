@@ -0,0 +1,35 @@
+package book
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/guisithos/save-my-read/internal/domain/event"
+)
+
+func TestBook_Events(t *testing.T) {
+	b, err := NewBook("g1", "Dune", []string{"Frank Herbert"}, "", nil, "", StatusToRead, "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []event.Event{Added{BookID: b.ID, UserID: "u1", GoogleID: "g1", Title: "Dune", Status: StatusToRead}}
+	if got := b.PullEvents(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+	if got := b.PullEvents(); len(got) != 0 {
+		t.Errorf("expected events to be pulled once, got %+v", got)
+	}
+
+	b.UpdateStatus(StatusToRead)
+	b.UpdateStatus(StatusReading)
+	b.UpdateStatus("SHELVED")
+	b.Merge(&Book{ID: "b2", ISBN: "9780441172719"})
+
+	want = []event.Event{
+		StatusChanged{BookID: b.ID, UserID: "u1", From: StatusToRead, To: StatusReading},
+		Merged{BookID: b.ID, SourceID: "b2", UserID: "u1"},
+	}
+	if got := b.PullEvents(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected only real changes, got %+v", got)
+	}
+}
@@ -1,11 +1,36 @@
 package book
 
-// Repository defines the interface for book persistence
+import (
+	"context"
+	"time"
+)
+
+// Repository defines the interface for book persistence. Every method
+// except FindByID and PurgeDeleted is scoped to a user and reports
+// ErrNotFound for books owned by someone else. Books in the trash are
+// invisible to every method but FindDeletedByUserID, Restore and
+// PurgeDeleted.
 type Repository interface {
-	Save(book *Book) error
-	FindByID(id string) (*Book, error)
-	FindByUserID(userID string) ([]*Book, error)
-	FindByUserIDAndStatus(userID string, status Status) ([]*Book, error)
-	Update(book *Book) error
-	Delete(id string) error
+	Save(ctx context.Context, book *Book) error
+	// FindByID looks a book up regardless of owner. It is only meant for
+	// resources that are public by book ID, such as covers.
+	FindByID(ctx context.Context, id string) (*Book, error)
+	FindByIDAndUserID(ctx context.Context, id, userID string) (*Book, error)
+	FindByUserID(ctx context.Context, userID string) ([]*Book, error)
+	FindByUserIDAndStatus(ctx context.Context, userID string, status Status) ([]*Book, error)
+	FindByUserIDAndGoogleID(ctx context.Context, userID, googleID string) (*Book, error)
+	// Update saves changes to a book owned by book.UserID if the stored
+	// version is still book.Version, and then increments book.Version. It
+	// fails with ErrModified when the book was changed in the meantime.
+	Update(ctx context.Context, book *Book) error
+	// Delete moves a user's book to the trash
+	Delete(ctx context.Context, id, userID string) error
+	// FindDeletedByUserID lists the user's trash, most recently deleted first
+	FindDeletedByUserID(ctx context.Context, userID string) ([]*Book, error)
+	// Restore takes a book out of the trash as a new version. It fails with
+	// ErrDuplicate when the same volume was added again in the meantime.
+	Restore(ctx context.Context, id, userID string) error
+	// PurgeDeleted permanently removes every user's books trashed before
+	// cutoff and returns their IDs
+	PurgeDeleted(ctx context.Context, cutoff time.Time) ([]string, error)
 }
@@ -0,0 +1,59 @@
+package cover
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrNotFound is returned by a Store when no blob exists for a key
+var ErrNotFound = errors.New("cover not found")
+
+// Size identifies a stored variant of a cover image
+type Size string
+
+const (
+	SizeOriginal Size = "original"
+	SizeSmall    Size = "small"
+	SizeMedium   Size = "medium"
+	SizeLarge    Size = "large"
+)
+
+// IsValid checks if the size is one of the supported variants
+func (s Size) IsValid() bool {
+	switch s {
+	case SizeOriginal, SizeSmall, SizeMedium, SizeLarge:
+		return true
+	default:
+		return false
+	}
+}
+
+// Width returns the target width in pixels of a resized variant, or 0 for
+// the original image
+func (s Size) Width() int {
+	switch s {
+	case SizeSmall:
+		return 128
+	case SizeMedium:
+		return 256
+	case SizeLarge:
+		return 512
+	default:
+		return 0
+	}
+}
+
+// Image is a cover ready to be served
+type Image struct {
+	Data        []byte
+	ContentType string
+	ModTime     time.Time
+	// Placeholder is true when the image was generated because no real
+	// cover could be found
+	Placeholder bool
+}
+
+// Key returns the blob store key for a book's cover variant
+func Key(bookID string, size Size) string {
+	return bookID + "/" + string(size)
+}
@@ -0,0 +1,8 @@
+package cover
+
+// Store defines the interface for cover blob persistence
+type Store interface {
+	Put(key string, data []byte, contentType string) error
+	Get(key string) (*Image, error)
+	Delete(key string) error
+}
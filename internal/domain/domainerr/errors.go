@@ -0,0 +1,132 @@
+// Package domainerr defines the error categories shared by the domain
+// packages. Each package declares its own errors with one of the
+// constructors below, so callers can branch on the category with
+// errors.Is (for example errors.Is(err, domainerr.ErrNotFound)) while the
+// HTTP layer reports the specific machine-readable code.
+package domainerr
+
+import (
+	"errors"
+	"strings"
+)
+
+// Error categories
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrValidation   = errors.New("validation failed")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrTooLarge     = errors.New("too large")
+	ErrUpstream     = errors.New("upstream service failed")
+	// ErrUnprocessable reports a well-formed request that can't be applied
+	ErrUnprocessable = errors.New("unprocessable")
+	// ErrPrecondition reports a conditional request whose condition no
+	// longer holds, such as an edit based on an outdated version
+	ErrPrecondition = errors.New("precondition failed")
+	// ErrPreconditionRequired reports a request that must be conditional
+	ErrPreconditionRequired = errors.New("precondition required")
+)
+
+// Error is a domain error with a category and a stable code
+type Error struct {
+	Kind    error
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Unwrap returns the category so errors.Is matches it
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+// NotFound creates an error for a missing resource
+func NotFound(code, message string) *Error {
+	return &Error{Kind: ErrNotFound, Code: code, Message: message}
+}
+
+// Conflict creates an error for a request that clashes with existing state
+func Conflict(code, message string) *Error {
+	return &Error{Kind: ErrConflict, Code: code, Message: message}
+}
+
+// Unauthorized creates an error for a caller that could not be authenticated
+func Unauthorized(code, message string) *Error {
+	return &Error{Kind: ErrUnauthorized, Code: code, Message: message}
+}
+
+// Forbidden creates an error for an authenticated caller lacking permission
+func Forbidden(code, message string) *Error {
+	return &Error{Kind: ErrForbidden, Code: code, Message: message}
+}
+
+// TooLarge creates an error for input exceeding a size limit
+func TooLarge(code, message string) *Error {
+	return &Error{Kind: ErrTooLarge, Code: code, Message: message}
+}
+
+// Unprocessable creates an error for a well-formed request that can't be applied
+func Unprocessable(code, message string) *Error {
+	return &Error{Kind: ErrUnprocessable, Code: code, Message: message}
+}
+
+// PreconditionFailed creates an error for a conditional request whose condition no longer holds
+func PreconditionFailed(code, message string) *Error {
+	return &Error{Kind: ErrPrecondition, Code: code, Message: message}
+}
+
+// PreconditionRequired creates an error for a request that must be conditional
+func PreconditionRequired(code, message string) *Error {
+	return &Error{Kind: ErrPreconditionRequired, Code: code, Message: message}
+}
+
+// Upstream creates an error for a failure in a service we depend on
+func Upstream(code, message string) *Error {
+	return &Error{Kind: ErrUpstream, Code: code, Message: message}
+}
+
+// FieldError describes a problem with a single input field
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError reports every invalid field of an input at once
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// Validation creates a ValidationError for a single field
+func Validation(field, message string) *ValidationError {
+	return &ValidationError{Fields: []FieldError{{Field: field, Message: message}}}
+}
+
+// Add records another invalid field
+func (e *ValidationError) Add(field, message string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: message})
+}
+
+// Err returns e if any field was recorded and nil otherwise
+func (e *ValidationError) Err() error {
+	if e == nil || len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Field + ": " + f.Message
+	}
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+// Unwrap returns ErrValidation so errors.Is matches the category
+func (e *ValidationError) Unwrap() error {
+	return ErrValidation
+}
@@ -0,0 +1,75 @@
+// Package event lets features react to what happens in the domain, such as
+// a book being added, without the code making the change knowing about
+// them. Events reach in-process subscribers through a Bus right after the
+// change commits, and are also written to a transactional outbox in the
+// same transaction so they can be delivered asynchronously and reliably.
+package event
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Event is something that happened in the domain
+type Event interface {
+	// EventName identifies the kind of event, such as "book.added"
+	EventName() string
+}
+
+// Handler reacts to an event
+type Handler func(ctx context.Context, e Event) error
+
+// Bus delivers events to in-process subscribers synchronously. It is safe
+// for concurrent use.
+type Bus struct {
+	mu       sync.RWMutex
+	handlers []subscription
+}
+
+type subscription struct {
+	names   map[string]bool
+	handler Handler
+}
+
+// NewBus creates a Bus without subscribers
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe registers h for the named events, or for every event when no
+// names are given
+func (b *Bus) Subscribe(h Handler, names ...string) {
+	sub := subscription{handler: h}
+	if len(names) > 0 {
+		sub.names = make(map[string]bool, len(names))
+		for _, name := range names {
+			sub.names[name] = true
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, sub)
+}
+
+// Publish calls the subscribers of each event in the order they subscribed.
+// Every subscriber runs even if an earlier one fails; their errors are
+// joined.
+func (b *Bus) Publish(ctx context.Context, events ...Event) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	var errs []error
+	for _, e := range events {
+		for _, sub := range b.handlers {
+			if sub.names != nil && !sub.names[e.EventName()] {
+				continue
+			}
+			if err := sub.handler(ctx, e); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,41 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+type testEvent string
+
+func (e testEvent) EventName() string { return string(e) }
+
+func TestBus(t *testing.T) {
+	bus := NewBus()
+	var all, added []string
+	errFailed := errors.New("failed")
+	bus.Subscribe(func(_ context.Context, e Event) error {
+		all = append(all, e.EventName())
+		return errFailed
+	})
+	bus.Subscribe(func(_ context.Context, e Event) error {
+		added = append(added, e.EventName())
+		return nil
+	}, "book.added")
+
+	err := bus.Publish(context.Background(), testEvent("book.added"), testEvent("book.deleted"))
+	if !errors.Is(err, errFailed) {
+		t.Errorf("expected the subscriber's error, got %v", err)
+	}
+	if !slices.Equal(all, []string{"book.added", "book.deleted"}) {
+		t.Errorf("expected every event for the catch-all subscriber, got %v", all)
+	}
+	if !slices.Equal(added, []string{"book.added"}) {
+		t.Errorf("expected only book.added after a failing subscriber, got %v", added)
+	}
+
+	if err := NewBus().Publish(context.Background(), testEvent("book.added")); err != nil {
+		t.Errorf("expected no error without subscribers, got %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Message statuses
+const (
+	StatusPending    = "pending"
+	StatusDispatched = "dispatched"
+	// StatusDead marks a message that failed too often to be retried
+	StatusDead = "dead"
+)
+
+// Message is an event as stored in the outbox
+type Message struct {
+	ID   string
+	Name string
+	// Payload is the event encoded as JSON
+	Payload    []byte
+	OccurredAt time.Time
+	Status     string
+	// Attempts counts the deliveries started, including the current one
+	Attempts      int
+	NextAttemptAt time.Time
+	LastError     string
+	DispatchedAt  *time.Time
+}
+
+// NewMessage encodes e as a pending message due right away
+func NewMessage(e Event) (*Message, error) {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding %s event: %w", e.EventName(), err)
+	}
+	now := time.Now()
+	return &Message{
+		ID:            uuid.NewString(),
+		Name:          e.EventName(),
+		Payload:       payload,
+		OccurredAt:    now,
+		Status:        StatusPending,
+		NextAttemptAt: now,
+	}, nil
+}
+
+// Outbox takes events to deliver once the transaction writing them commits
+type Outbox interface {
+	Add(ctx context.Context, msgs ...*Message) error
+}
+
+// OutboxStore is the outbox as seen by the dispatcher
+type OutboxStore interface {
+	Outbox
+	// Claim returns up to limit pending messages due at now, oldest first,
+	// and hides them from other claims until now+lease so a dispatcher that
+	// dies mid-delivery doesn't lose them. Each claim counts as an attempt.
+	Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*Message, error)
+	// MarkDispatched records that the message was delivered
+	MarkDispatched(ctx context.Context, id string, at time.Time) error
+	// Retry records a failed delivery to be tried again at retryAt
+	Retry(ctx context.Context, id, lastError string, retryAt time.Time) error
+	// Bury records a failed delivery that will not be retried
+	Bury(ctx context.Context, id, lastError string) error
+	// DeleteDispatched removes messages dispatched before cutoff and returns how many
+	DeleteDispatched(ctx context.Context, cutoff time.Time) (int64, error)
+}
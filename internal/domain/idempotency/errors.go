@@ -0,0 +1,10 @@
+package idempotency
+
+import "github.com/guisithos/save-my-read/internal/domain/domainerr"
+
+var (
+	ErrNotFound   = domainerr.NotFound("idempotency_key_not_found", "idempotency key not found")
+	ErrExists     = domainerr.Conflict("idempotency_key_exists", "idempotency key already used")
+	ErrInProgress = domainerr.Conflict("idempotency_key_in_progress", "a request with this idempotency key is still in progress")
+	ErrKeyReused  = domainerr.Unprocessable("idempotency_key_reused", "idempotency key was already used for a different request")
+)
@@ -0,0 +1,25 @@
+// Package idempotency keeps the outcome of requests sent with an
+// Idempotency-Key so that a client retrying one gets the original response
+// instead of repeating its effect.
+package idempotency
+
+import "time"
+
+// Record is what is kept for one key of one user
+type Record struct {
+	UserID string
+	Key    string
+	// Fingerprint identifies the request the key was first used with
+	Fingerprint string
+	// Status is 0 while the first request is still being handled
+	Status    int
+	Header    map[string][]string
+	Body      []byte
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// Completed reports whether the response has been stored
+func (r *Record) Completed() bool {
+	return r.Status != 0
+}
@@ -0,0 +1,21 @@
+package idempotency
+
+import (
+	"context"
+	"time"
+)
+
+// Store defines the interface for idempotency record persistence. Records
+// past their ExpiresAt are treated as if they did not exist.
+type Store interface {
+	// Reserve stores rec as in progress. It fails with ErrExists when a
+	// live record already holds the key, whatever its state.
+	Reserve(ctx context.Context, rec *Record) error
+	FindByKey(ctx context.Context, userID, key string) (*Record, error)
+	// Complete stores the response held by rec for a reserved key
+	Complete(ctx context.Context, rec *Record) error
+	// Release drops a reserved key so the request can be tried again
+	Release(ctx context.Context, userID, key string) error
+	// DeleteExpired removes records that expired before now and returns how many
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
@@ -0,0 +1,7 @@
+package user
+
+import "github.com/guisithos/save-my-read/internal/domain/domainerr"
+
+var (
+	ErrNotFound = domainerr.NotFound("user_not_found", "user not found")
+)
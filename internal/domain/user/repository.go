@@ -1,9 +1,11 @@
 package user
 
+import "context"
+
 // Repository defines the interface for user persistence
 type Repository interface {
-	Save(user *User) error
-	FindByEmail(email string) (*User, error)
-	FindByID(id string) (*User, error)
-	Update(user *User) error
+	Save(ctx context.Context, user *User) error
+	FindByEmail(ctx context.Context, email string) (*User, error)
+	FindByID(ctx context.Context, id string) (*User, error)
+	Update(ctx context.Context, user *User) error
 }
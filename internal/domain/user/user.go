@@ -1,10 +1,12 @@
 package user
 
 import (
-	"errors"
+	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/guisithos/save-my-read/internal/domain/domainerr"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -22,16 +24,34 @@ type User struct {
 	UpdatedAt time.Time
 }
 
+// LogValue keeps the password hash out of structured logs
+func (u User) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("id", u.ID),
+		slog.String("email", u.Email),
+		slog.String("name", u.Name),
+	)
+}
+
+// String keeps the password hash out of fmt output
+func (u User) String() string {
+	return fmt.Sprintf("User{ID:%s Email:%s Name:%s}", u.ID, u.Email, u.Name)
+}
+
 // NewUser creates a new user with validated fields
 func NewUser(email, password, name string, genres []string) (*User, error) {
+	verr := &domainerr.ValidationError{}
 	if email == "" {
-		return nil, errors.New("email cannot be empty")
+		verr.Add("email", "cannot be empty")
 	}
 	if password == "" {
-		return nil, errors.New("password cannot be empty")
+		verr.Add("password", "cannot be empty")
 	}
 	if name == "" {
-		return nil, errors.New("name cannot be empty")
+		verr.Add("name", "cannot be empty")
+	}
+	if err := verr.Err(); err != nil {
+		return nil, err
 	}
 
 	// Hash password
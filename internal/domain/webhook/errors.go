@@ -0,0 +1,12 @@
+package webhook
+
+import (
+	"fmt"
+
+	"github.com/guisithos/save-my-read/internal/domain/domainerr"
+)
+
+var (
+	ErrNotFound = domainerr.NotFound("webhook_not_found", "webhook not found")
+	ErrLimit    = domainerr.Conflict("webhook_limit", fmt.Sprintf("at most %d webhooks per user", MaxPerUser))
+)
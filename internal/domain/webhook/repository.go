@@ -0,0 +1,28 @@
+package webhook
+
+import (
+	"context"
+	"time"
+)
+
+// Repository stores webhooks and the log of their deliveries
+type Repository interface {
+	Save(ctx context.Context, w *Webhook) error
+	// FindByIDAndUserID retrieves a webhook if it belongs to the user
+	FindByIDAndUserID(ctx context.Context, id, userID string) (*Webhook, error)
+	// FindByUserID retrieves the user's webhooks, oldest first
+	FindByUserID(ctx context.Context, userID string) ([]*Webhook, error)
+	// Update saves the URL, events and active flag of a webhook owned by w.UserID
+	Update(ctx context.Context, w *Webhook) error
+	// Delete removes a webhook owned by the user along with its deliveries
+	Delete(ctx context.Context, id, userID string) error
+
+	SaveDelivery(ctx context.Context, d *Delivery) error
+	// FindDeliveries retrieves up to limit deliveries of a webhook, newest first
+	FindDeliveries(ctx context.Context, webhookID string, limit int) ([]*Delivery, error)
+	// Delivered reports whether an event has been delivered to a webhook
+	// successfully
+	Delivered(ctx context.Context, webhookID, eventID string) (bool, error)
+	// DeleteDeliveriesBefore removes deliveries older than cutoff and returns how many
+	DeleteDeliveriesBefore(ctx context.Context, cutoff time.Time) (int64, error)
+}
@@ -0,0 +1,120 @@
+// Package health runs the dependency checks behind the readiness endpoint.
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Status summarises the outcome of one check or a whole report
+type Status string
+
+const (
+	StatusOK Status = "ok"
+	// StatusDegraded means a non-critical dependency is failing
+	StatusDegraded Status = "degraded"
+	// StatusFailing means a critical dependency is failing
+	StatusFailing Status = "failing"
+)
+
+// CheckFunc returns an error when the dependency is not usable
+type CheckFunc func(ctx context.Context) error
+
+type check struct {
+	name     string
+	critical bool
+	fn       CheckFunc
+}
+
+// Result is the outcome of a single check
+type Result struct {
+	Name     string `json:"name"`
+	Status   Status `json:"status"`
+	Critical bool   `json:"critical"`
+	// Error is why the check failed; see Report.Redacted
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// Report is the outcome of every check
+type Report struct {
+	Status Status   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+// Redacted returns the report without the error of each check, for
+// callers that must not learn how the dependencies behind it fail
+func (r Report) Redacted() Report {
+	checks := make([]Result, len(r.Checks))
+	for i, c := range r.Checks {
+		c.Error = ""
+		checks[i] = c
+	}
+	return Report{Status: r.Status, Checks: checks}
+}
+
+// Checker runs registered checks concurrently, each under a timeout
+type Checker struct {
+	timeout time.Duration
+	checks  []check
+}
+
+// NewChecker creates a Checker that gives each check at most timeout
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// Add registers a check. A failing critical check makes the report
+// failing; a failing non-critical check only degrades it.
+func (c *Checker) Add(name string, critical bool, fn CheckFunc) {
+	c.checks = append(c.checks, check{name: name, critical: critical, fn: fn})
+}
+
+// Run executes every check and summarises the results
+func (c *Checker) Run(ctx context.Context) Report {
+	report := Report{Status: StatusOK, Checks: make([]Result, len(c.checks))}
+
+	var wg sync.WaitGroup
+	for i, chk := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report.Checks[i] = c.run(ctx, chk)
+		}()
+	}
+	wg.Wait()
+
+	for _, r := range report.Checks {
+		switch {
+		case r.Status == StatusOK:
+		case r.Critical:
+			report.Status = StatusFailing
+		case report.Status == StatusOK:
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, chk check) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := chk.fn(ctx)
+	result := Result{
+		Name:     chk.name,
+		Status:   StatusOK,
+		Critical: chk.critical,
+		Duration: time.Since(start).Round(time.Microsecond).String(),
+	}
+	if err != nil {
+		result.Error = err.Error()
+		result.Status = StatusDegraded
+		if chk.critical {
+			result.Status = StatusFailing
+		}
+	}
+	return result
+}
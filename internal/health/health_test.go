@@ -0,0 +1,52 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestChecker_Run(t *testing.T) {
+	ok := func(context.Context) error { return nil }
+	fail := func(context.Context) error { return errors.New("down") }
+	slow := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	tests := []struct {
+		name  string
+		setup func(c *Checker)
+		want  Status
+	}{
+		{"no checks", func(c *Checker) {}, StatusOK},
+		{"all passing", func(c *Checker) {
+			c.Add("db", true, ok)
+			c.Add("google", false, ok)
+		}, StatusOK},
+		{"non-critical failing", func(c *Checker) {
+			c.Add("db", true, ok)
+			c.Add("google", false, fail)
+		}, StatusDegraded},
+		{"critical failing", func(c *Checker) {
+			c.Add("db", true, fail)
+			c.Add("google", false, fail)
+		}, StatusFailing},
+		{"critical timing out", func(c *Checker) {
+			c.Add("db", true, slow)
+		}, StatusFailing},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewChecker(20 * time.Millisecond)
+			tt.setup(c)
+
+			report := c.Run(context.Background())
+			if report.Status != tt.want {
+				t.Errorf("expected %s, got %s: %+v", tt.want, report.Status, report.Checks)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package filestore
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/guisithos/save-my-read/internal/domain/cover"
+)
+
+// contentTypeSuffix names the sidecar file holding a blob's content type
+const contentTypeSuffix = ".content-type"
+
+// Store implements the cover.Store interface on the local filesystem
+type Store struct {
+	root string
+}
+
+// NewStore creates a filesystem store rooted at dir, creating it if needed
+func NewStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating store directory: %w", err)
+	}
+	return &Store{root: dir}, nil
+}
+
+// Put writes a blob, replacing any previous content for the key
+func (s *Store) Put(key string, data []byte, contentType string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("error creating blob directory: %w", err)
+	}
+	if err := writeAtomic(path+contentTypeSuffix, []byte(contentType)); err != nil {
+		return fmt.Errorf("error writing blob metadata: %w", err)
+	}
+	if err := writeAtomic(path, data); err != nil {
+		return fmt.Errorf("error writing blob: %w", err)
+	}
+
+	return nil
+}
+
+// Get reads a blob and its content type
+func (s *Store) Get(key string) (*cover.Image, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, cover.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading blob: %w", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading blob: %w", err)
+	}
+
+	contentType, err := os.ReadFile(path + contentTypeSuffix)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("error reading blob metadata: %w", err)
+	}
+
+	return &cover.Image{
+		Data:        data,
+		ContentType: string(contentType),
+		ModTime:     info.ModTime(),
+	}, nil
+}
+
+// Delete removes a blob; deleting a missing key is not an error
+func (s *Store) Delete(key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range []string{path, path + contentTypeSuffix} {
+		if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("error deleting blob: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// path maps a key to a file below the root, rejecting keys that would escape it
+func (s *Store) path(key string) (string, error) {
+	local := filepath.FromSlash(key)
+	if !filepath.IsLocal(local) {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	return filepath.Join(s.root, local), nil
+}
+
+// writeAtomic writes data to a temporary file and renames it into place so
+// readers never observe a partially written blob
+func writeAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,109 @@
+package googlebooks
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/guisithos/save-my-read/internal/logging"
+)
+
+func TestBreaker(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := newBreaker(2, time.Minute)
+	b.now = func() time.Time { return now }
+
+	b.record(false)
+	if b.current() != CircuitClosed || !b.allow() {
+		t.Fatal("expected breaker to stay closed below the threshold")
+	}
+	b.record(false)
+	if b.current() != CircuitOpen || b.allow() {
+		t.Fatal("expected breaker to open at the threshold")
+	}
+
+	now = now.Add(time.Minute)
+	if b.current() != CircuitHalfOpen {
+		t.Fatalf("expected half-open after the cooldown, got %s", b.current())
+	}
+	if !b.allow() || b.allow() {
+		t.Fatal("expected exactly one trial request while half-open")
+	}
+	b.record(false)
+	if b.current() != CircuitOpen {
+		t.Fatalf("expected a failed trial to reopen, got %s", b.current())
+	}
+
+	now = now.Add(time.Minute)
+	b.allow()
+	b.record(true)
+	if b.current() != CircuitClosed {
+		t.Fatalf("expected a successful trial to close, got %s", b.current())
+	}
+}
+
+func TestSearchBooks_CircuitBreaker(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	client, err := NewClient(WithBaseURL(srv.URL), WithCircuitBreaker(2, time.Hour))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.SearchBooks(context.Background(), "golang"); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("call %d: expected upstream error, got %v", i, err)
+		}
+	}
+	if _, err := client.SearchBooks(context.Background(), "golang"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected ErrCircuitOpen, got %v", err)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("expected 2 calls to reach the server, got %d", got)
+	}
+	if client.CircuitState() != CircuitOpen {
+		t.Errorf("expected open circuit, got %s", client.CircuitState())
+	}
+}
+
+func TestSearchBooks_TimeoutsTripButCancellationsDont(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	client, err := NewClient(
+		WithBaseURL(srv.URL),
+		WithTimeout(20*time.Millisecond),
+		WithCircuitBreaker(1, time.Hour),
+		WithLogger(logging.Discard()),
+	)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := client.SearchBooks(ctx, "golang"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if client.CircuitState() != CircuitClosed {
+		t.Fatalf("expected a cancelled call to leave the circuit closed, got %s", client.CircuitState())
+	}
+
+	if _, err := client.SearchBooks(context.Background(), "golang"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if client.CircuitState() != CircuitOpen {
+		t.Errorf("expected a timeout to open the circuit, got %s", client.CircuitState())
+	}
+}
@@ -1,22 +1,112 @@
 package googlebooks
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io/ioutil"
-	"log"
+	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
-	"os"
+	"strings"
+	"time"
 
-	"github.com/joho/godotenv"
+	"github.com/guisithos/save-my-read/internal/logging"
+	"github.com/guisithos/save-my-read/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// Client handles communication with Google Books AP
+const (
+	defaultBaseURL   = "https://www.googleapis.com/books/v1"
+	defaultUserAgent = "save-my-read"
+	defaultTimeout   = 10 * time.Second
+
+	// maxErrorBody bounds how much of an error response is logged
+	maxErrorBody = 2048
+)
+
+var tracer = tracing.Tracer("github.com/guisithos/save-my-read/internal/infrastructure/googlebooks")
+
+// Client handles communication with Google Books API
 type Client struct {
 	apiKey     string
 	baseURL    string
+	userAgent  string
+	country    string
 	httpClient *http.Client
+	timeout    time.Duration
+	logger     *slog.Logger
+	breaker    *breaker
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithBaseURL points the client at a different API root, such as a test server
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		c.baseURL = baseURL
+	}
+}
+
+// WithAPIKey sets the API key sent with every request
+func WithAPIKey(apiKey string) Option {
+	return func(c *Client) {
+		c.apiKey = apiKey
+	}
+}
+
+// WithHTTPClient replaces the HTTP client used for requests
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithTimeout bounds each API call, on top of any deadline the caller's
+// context carries; a timeout of 0 leaves calls bounded only by the context
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithCountry restricts results to those available in the given ISO 3166-1 country
+func WithCountry(country string) Option {
+	return func(c *Client) {
+		c.country = country
+	}
+}
+
+// WithLogger sets the logger for request diagnostics
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// WithCircuitBreaker stops calling the API for cooldown after threshold
+// consecutive failures; a threshold of 0 disables the breaker
+func WithCircuitBreaker(threshold int, cooldown time.Duration) Option {
+	return func(c *Client) {
+		if threshold <= 0 {
+			c.breaker = nil
+			return
+		}
+		c.breaker = newBreaker(threshold, cooldown)
+	}
 }
 
 // BookResponse represents the Google Books API response structure
@@ -36,48 +126,137 @@ type BookResponse struct {
 }
 
 // NewClient creates a new Google Books API client
-func NewClient() (*Client, error) {
-	// Try to load from .env file if godotenv is available
-	if err := godotenv.Load(); err != nil {
-		log.Printf("Warning: .env file not found or error loading it")
+func NewClient(opts ...Option) (*Client, error) {
+	c := &Client{
+		baseURL:    defaultBaseURL,
+		userAgent:  defaultUserAgent,
+		httpClient: &http.Client{},
+		timeout:    defaultTimeout,
+		breaker:    newBreaker(defaultFailureThreshold, defaultCooldown),
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.logger = logging.OrDefault(c.logger)
 
-	apiKey := os.Getenv("GOOGLE_BOOKS_API_KEY")
-	if apiKey == "" {
-		return nil, fmt.Errorf("GOOGLE_BOOKS_API_KEY environment variable is not set")
+	u, err := url.Parse(c.baseURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid base URL %q", c.baseURL)
 	}
+	c.baseURL = strings.TrimSuffix(c.baseURL, "/")
 
-	return &Client{
-		apiKey:     apiKey,
-		baseURL:    "https://www.googleapis.com/books/v1",
-		httpClient: &http.Client{},
-	}, nil
+	if c.httpClient == nil {
+		return nil, errors.New("http client must not be nil")
+	}
+
+	return c, nil
+}
+
+// CircuitState reports whether the circuit breaker is letting requests through
+func (c *Client) CircuitState() CircuitState {
+	if c.breaker == nil {
+		return CircuitClosed
+	}
+	return c.breaker.current()
 }
 
 // SearchBooks searches for books using the Google Books API
-func (c *Client) SearchBooks(query string) (*BookResponse, error) {
-	log.Printf("Making request to Google Books API with query: %s", query)
-	url := fmt.Sprintf("%s/volumes?q=%s&key=%s", c.baseURL, url.QueryEscape(query), c.apiKey)
+func (c *Client) SearchBooks(ctx context.Context, query string) (*BookResponse, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, errors.New("query is required")
+	}
 
-	resp, err := c.httpClient.Get(url)
+	params := url.Values{}
+	params.Set("q", query)
+	if c.apiKey != "" {
+		params.Set("key", c.apiKey)
+	}
+	if c.country != "" {
+		params.Set("country", c.country)
+	}
+
+	// The caller's context is kept to tell its cancellation from our timeout
+	callerCtx := ctx
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/volumes?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+
+	if c.breaker != nil && !c.breaker.allow() {
+		return nil, ErrCircuitOpen
+	}
+
+	// The URL carries the API key, so the span only records a redacted copy
+	ctx, span := tracer.Start(ctx, "GET /volumes",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", http.MethodGet),
+			attribute.String("url.full", logging.RedactURL(req.URL.String())),
+			attribute.String("server.address", req.URL.Hostname()),
+		),
+	)
+	defer span.End()
+	req = req.WithContext(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	start := time.Now()
+	resp, err := c.httpClient.Do(req)
+	// Rate limiting, server errors and timeouts mean Google is struggling;
+	// other statuses and callers that gave up don't count against it
+	if c.breaker != nil {
+		if err != nil && callerCtx.Err() != nil {
+			c.breaker.release()
+		} else {
+			c.breaker.record(err == nil && resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < http.StatusInternalServerError)
+		}
+	}
 	if err != nil {
-		log.Printf("Google Books API request failed: %v", err)
+		// url.Error includes the request URL, and with it the API key
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			uerr.URL = logging.RedactURL(uerr.URL)
+		}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "request failed")
+		c.logger.WarnContext(ctx, "google books request failed", "error", err, "latency", time.Since(start))
 		return nil, fmt.Errorf("failed to make request: %w", err)
 	}
 	defer resp.Body.Close()
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := ioutil.ReadAll(resp.Body)
-		log.Printf("Google Books API error response: %s", string(body))
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+		c.logger.WarnContext(ctx, "google books returned an error",
+			"status", resp.StatusCode,
+			"body", string(body),
+			"latency", time.Since(start),
+		)
 		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
 
 	var result BookResponse
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		log.Printf("Failed to decode response: %v", err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "invalid response body")
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
+	span.SetAttributes(attribute.Int("googlebooks.results", len(result.Items)))
 
-	log.Printf("Successfully retrieved %d books", len(result.Items))
+	c.logger.DebugContext(ctx, "google books search",
+		"query", query,
+		"results", len(result.Items),
+		"latency", time.Since(start),
+	)
 	return &result, nil
 }
@@ -1,8 +1,14 @@
 package googlebooks
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
+
+	"github.com/guisithos/save-my-read/internal/logging"
 )
 
 func TestSearchBooks(t *testing.T) {
@@ -11,7 +17,7 @@ func TestSearchBooks(t *testing.T) {
 		t.Skip("GOOGLE_BOOKS_API_KEY not set")
 	}
 
-	client, err := NewClient()
+	client, err := NewClient(WithAPIKey(os.Getenv("GOOGLE_BOOKS_API_KEY")))
 	if err != nil {
 		t.Fatalf("Failed to create client: %v", err)
 	}
@@ -35,7 +41,7 @@ func TestSearchBooks(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			response, err := client.SearchBooks(tt.query)
+			response, err := client.SearchBooks(context.Background(), tt.query)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("SearchBooks() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -46,3 +52,83 @@ func TestSearchBooks(t *testing.T) {
 		})
 	}
 }
+
+func TestSearchBooks_FakeServer(t *testing.T) {
+	var got *http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"items":[{"id":"abc","volumeInfo":{"title":"Dune","authors":["Frank Herbert"]}}]}`))
+	}))
+	defer srv.Close()
+
+	client, err := NewClient(
+		WithBaseURL(srv.URL),
+		WithAPIKey("test-key"),
+		WithUserAgent("test-agent"),
+		WithCountry("BR"),
+		WithHTTPClient(srv.Client()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	response, err := client.SearchBooks(context.Background(), "dune")
+	if err != nil {
+		t.Fatalf("SearchBooks() error = %v", err)
+	}
+	if len(response.Items) != 1 || response.Items[0].VolumeInfo.Title != "Dune" {
+		t.Errorf("unexpected response: %+v", response)
+	}
+
+	if got.URL.Path != "/volumes" {
+		t.Errorf("expected path /volumes, got %s", got.URL.Path)
+	}
+	q := got.URL.Query()
+	if q.Get("q") != "dune" || q.Get("key") != "test-key" || q.Get("country") != "BR" {
+		t.Errorf("unexpected query parameters: %s", got.URL.RawQuery)
+	}
+	if ua := got.Header.Get("User-Agent"); ua != "test-agent" {
+		t.Errorf("expected User-Agent test-agent, got %q", ua)
+	}
+}
+
+func TestSearchBooks_ErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "quota exceeded", http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	client, err := NewClient(WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	if _, err := client.SearchBooks(context.Background(), "dune"); err == nil {
+		t.Error("expected error for non-200 response")
+	}
+}
+
+func TestNewClient_InvalidBaseURL(t *testing.T) {
+	if _, err := NewClient(WithBaseURL("not a url")); err == nil {
+		t.Error("expected error for invalid base URL")
+	}
+}
+
+func TestSearchBooks_TransportErrorHidesAPIKey(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	client, err := NewClient(WithBaseURL(srv.URL), WithAPIKey("super-secret-key"), WithLogger(logging.Discard()))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	_, err = client.SearchBooks(context.Background(), "golang")
+	if err == nil {
+		t.Fatal("expected error from closed server")
+	}
+	if strings.Contains(err.Error(), "super-secret-key") {
+		t.Errorf("API key leaked into error: %v", err)
+	}
+}
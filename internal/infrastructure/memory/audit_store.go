@@ -0,0 +1,69 @@
+package memory
+
+import (
+	"context"
+	"maps"
+	"sort"
+	"sync"
+
+	"github.com/guisithos/save-my-read/internal/domain/audit"
+)
+
+// AuditStore implements audit.Store in memory. It is safe for concurrent use.
+type AuditStore struct {
+	mu     sync.Mutex
+	events []*audit.Event
+}
+
+// NewAuditStore creates an empty AuditStore
+func NewAuditStore() *AuditStore {
+	return &AuditStore{}
+}
+
+// Append adds copies of events to the log
+func (s *AuditStore) Append(_ context.Context, events ...*audit.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, e := range events {
+		s.events = append(s.events, copyEvent(e))
+	}
+	return nil
+}
+
+// List returns the events matching f, newest first
+func (s *AuditStore) List(_ context.Context, f audit.Filter) ([]*audit.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var events []*audit.Event
+	for _, e := range s.events {
+		switch {
+		case f.UserID != "" && e.UserID != f.UserID,
+			f.Action != "" && e.Action != f.Action,
+			!f.Since.IsZero() && e.OccurredAt.Before(f.Since),
+			!f.Until.IsZero() && !e.OccurredAt.Before(f.Until):
+			continue
+		}
+		events = append(events, copyEvent(e))
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		if !events[i].OccurredAt.Equal(events[j].OccurredAt) {
+			return events[i].OccurredAt.After(events[j].OccurredAt)
+		}
+		return events[i].ID < events[j].ID
+	})
+	if f.Limit > 0 && len(events) > f.Limit {
+		events = events[:f.Limit]
+	}
+	return events, nil
+}
+
+// copyEvent returns a copy so callers never share state with the store.
+// Change values are JSON values and treated as immutable.
+func copyEvent(e *audit.Event) *audit.Event {
+	c := *e
+	c.Changes = maps.Clone(e.Changes)
+	c.Details = maps.Clone(e.Details)
+	return &c
+}
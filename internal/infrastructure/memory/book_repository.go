@@ -0,0 +1,189 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/guisithos/save-my-read/internal/domain/book"
+)
+
+// BookRepository implements the book.Repository interface over a Store
+type BookRepository struct {
+	store *Store
+	lock  locker
+}
+
+// NewBookRepository creates a book repository backed by store
+func NewBookRepository(store *Store) *BookRepository {
+	return &BookRepository{store: store, lock: &store.mu}
+}
+
+// Save stores a new book, refusing a second entry for the same volume
+// outside the trash
+func (r *BookRepository) Save(_ context.Context, b *book.Book) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if _, ok := r.store.data.books[b.ID]; ok || r.holdsVolume(b.UserID, b.GoogleID) {
+		return book.ErrDuplicate
+	}
+	r.store.data.books[b.ID] = copyBook(b)
+	return nil
+}
+
+// FindByID retrieves a book by its ID
+func (r *BookRepository) FindByID(_ context.Context, id string) (*book.Book, error) {
+	return r.findOne(func(b *book.Book) bool { return b.ID == id })
+}
+
+// FindByIDAndUserID retrieves a book by its ID if it belongs to the user
+func (r *BookRepository) FindByIDAndUserID(_ context.Context, id, userID string) (*book.Book, error) {
+	return r.findOne(func(b *book.Book) bool { return b.ID == id && b.UserID == userID })
+}
+
+// FindByUserIDAndGoogleID retrieves the user's entry for a Google Books volume
+func (r *BookRepository) FindByUserIDAndGoogleID(_ context.Context, userID, googleID string) (*book.Book, error) {
+	return r.findOne(func(b *book.Book) bool { return b.UserID == userID && b.GoogleID == googleID })
+}
+
+// FindByUserID retrieves all books for a user, oldest first
+func (r *BookRepository) FindByUserID(_ context.Context, userID string) ([]*book.Book, error) {
+	return r.findAll(func(b *book.Book) bool { return b.UserID == userID }), nil
+}
+
+// FindByUserIDAndStatus retrieves books for a user with specific status, oldest first
+func (r *BookRepository) FindByUserIDAndStatus(_ context.Context, userID string, status book.Status) ([]*book.Book, error) {
+	return r.findAll(func(b *book.Book) bool { return b.UserID == userID && b.Status == status }), nil
+}
+
+// Update updates an existing book owned by b.UserID
+func (r *BookRepository) Update(_ context.Context, b *book.Book) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	existing, ok := r.store.data.books[b.ID]
+	if !ok || existing.UserID != b.UserID || existing.DeletedAt != nil {
+		return book.ErrNotFound
+	}
+	if existing.Version != b.Version {
+		return book.ErrModified
+	}
+
+	updated := copyBook(b)
+	updated.GoogleID = existing.GoogleID
+	updated.DeletedAt = nil
+	updated.UpdatedAt = time.Now()
+	updated.Version++
+	r.store.data.books[b.ID] = updated
+	b.Version = updated.Version
+	return nil
+}
+
+// Delete moves a user's book to the trash
+func (r *BookRepository) Delete(_ context.Context, id, userID string) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	existing, ok := r.store.data.books[id]
+	if !ok || existing.UserID != userID || existing.DeletedAt != nil {
+		return book.ErrNotFound
+	}
+	now := time.Now()
+	existing.DeletedAt = &now
+	return nil
+}
+
+// FindDeletedByUserID lists the user's trash, most recently deleted first
+func (r *BookRepository) FindDeletedByUserID(_ context.Context, userID string) ([]*book.Book, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	var books []*book.Book
+	for _, b := range r.store.data.books {
+		if b.UserID == userID && b.DeletedAt != nil {
+			books = append(books, copyBook(b))
+		}
+	}
+	sort.Slice(books, func(i, j int) bool {
+		return books[i].DeletedAt.After(*books[j].DeletedAt)
+	})
+	return books, nil
+}
+
+// Restore takes a user's book out of the trash
+func (r *BookRepository) Restore(_ context.Context, id, userID string) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	existing, ok := r.store.data.books[id]
+	if !ok || existing.UserID != userID || existing.DeletedAt == nil {
+		return book.ErrNotFound
+	}
+	if r.holdsVolume(userID, existing.GoogleID) {
+		return book.ErrDuplicate
+	}
+	existing.DeletedAt = nil
+	existing.UpdatedAt = time.Now()
+	existing.Version++
+	return nil
+}
+
+// PurgeDeleted permanently removes books trashed before cutoff
+func (r *BookRepository) PurgeDeleted(_ context.Context, cutoff time.Time) ([]string, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var ids []string
+	for id, b := range r.store.data.books {
+		if b.DeletedAt != nil && b.DeletedAt.Before(cutoff) {
+			delete(r.store.data.books, id)
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// holdsVolume reports whether the user has the volume outside the trash;
+// the caller holds the lock
+func (r *BookRepository) holdsVolume(userID, googleID string) bool {
+	for _, b := range r.store.data.books {
+		if b.UserID == userID && b.GoogleID == googleID && b.DeletedAt == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// findOne returns a copy of the first book outside the trash that matches
+func (r *BookRepository) findOne(match func(*book.Book) bool) (*book.Book, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	for _, b := range r.store.data.books {
+		if b.DeletedAt == nil && match(b) {
+			return copyBook(b), nil
+		}
+	}
+	return nil, book.ErrNotFound
+}
+
+// findAll returns copies of the books outside the trash that match, oldest first
+func (r *BookRepository) findAll(match func(*book.Book) bool) []*book.Book {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	var books []*book.Book
+	for _, b := range r.store.data.books {
+		if b.DeletedAt == nil && match(b) {
+			books = append(books, copyBook(b))
+		}
+	}
+	sort.Slice(books, func(i, j int) bool {
+		if !books[i].CreatedAt.Equal(books[j].CreatedAt) {
+			return books[i].CreatedAt.Before(books[j].CreatedAt)
+		}
+		return books[i].ID < books[j].ID
+	})
+	return books
+}
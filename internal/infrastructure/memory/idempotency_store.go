@@ -0,0 +1,114 @@
+package memory
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/guisithos/save-my-read/internal/domain/idempotency"
+)
+
+// IdempotencyStore implements idempotency.Store in memory. It is safe for
+// concurrent use.
+type IdempotencyStore struct {
+	mu      sync.Mutex
+	records map[idempotencyKey]*idempotency.Record
+}
+
+type idempotencyKey struct {
+	userID string
+	key    string
+}
+
+// NewIdempotencyStore creates an empty IdempotencyStore
+func NewIdempotencyStore() *IdempotencyStore {
+	return &IdempotencyStore{records: make(map[idempotencyKey]*idempotency.Record)}
+}
+
+// Reserve stores rec as in progress unless a live record holds its key
+func (s *IdempotencyStore) Reserve(_ context.Context, rec *idempotency.Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := idempotencyKey{rec.UserID, rec.Key}
+	if existing, ok := s.records[k]; ok && existing.ExpiresAt.After(rec.CreatedAt) {
+		return idempotency.ErrExists
+	}
+
+	reserved := copyRecord(rec)
+	reserved.Status = 0
+	reserved.Header = nil
+	reserved.Body = nil
+	s.records[k] = reserved
+	return nil
+}
+
+// FindByKey retrieves the user's live record for key
+func (s *IdempotencyStore) FindByKey(_ context.Context, userID, key string) (*idempotency.Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, ok := s.records[idempotencyKey{userID, key}]
+	if !ok || !rec.ExpiresAt.After(time.Now()) {
+		return nil, idempotency.ErrNotFound
+	}
+	return copyRecord(rec), nil
+}
+
+// Complete stores the response held by rec for a reserved key
+func (s *IdempotencyStore) Complete(_ context.Context, rec *idempotency.Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.records[idempotencyKey{rec.UserID, rec.Key}]
+	if !ok || existing.Completed() {
+		return idempotency.ErrNotFound
+	}
+	completed := copyRecord(rec)
+	existing.Status = completed.Status
+	existing.Header = completed.Header
+	existing.Body = completed.Body
+	return nil
+}
+
+// Release drops a reserved key that has no response yet
+func (s *IdempotencyStore) Release(_ context.Context, userID, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := idempotencyKey{userID, key}
+	if rec, ok := s.records[k]; ok && !rec.Completed() {
+		delete(s.records, k)
+	}
+	return nil
+}
+
+// DeleteExpired removes records that expired before now
+func (s *IdempotencyStore) DeleteExpired(_ context.Context, now time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var n int64
+	for k, rec := range s.records {
+		if !rec.ExpiresAt.After(now) {
+			delete(s.records, k)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// copyRecord returns a deep copy so callers never share state with the store
+func copyRecord(rec *idempotency.Record) *idempotency.Record {
+	c := *rec
+	c.Body = slices.Clone(rec.Body)
+	if rec.Header != nil {
+		c.Header = maps.Clone(rec.Header)
+		for name, values := range c.Header {
+			c.Header[name] = slices.Clone(values)
+		}
+	}
+	return &c
+}
@@ -0,0 +1,73 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/guisithos/save-my-read/internal/domain/book"
+	"github.com/guisithos/save-my-read/internal/infrastructure/repotest"
+)
+
+func TestRepositories(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repotest.Setup {
+		store := NewStore()
+		return repotest.Setup{
+			Books:       NewBookRepository(store),
+			Users:       NewUserRepository(store),
+			UnitOfWork:  NewUnitOfWork(store),
+			Idempotency: NewIdempotencyStore(),
+			Audit:       NewAuditStore(),
+			Outbox:      NewOutboxStore(store),
+			Webhooks:    NewWebhookRepository(),
+		}
+	})
+}
+
+func TestBookRepository_ConcurrentSaves(t *testing.T) {
+	repo := NewBookRepository(NewStore())
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 50)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Every other goroutine races for the same volume
+			googleID := fmt.Sprintf("g%d", i%25)
+			b, _ := book.NewBook(googleID, "Dune", []string{"Frank Herbert"}, "", nil, "", book.StatusToRead, "u1")
+			errs <- repo.Save(context.Background(), b)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	var duplicates int
+	for err := range errs {
+		if err != nil {
+			duplicates++
+		}
+	}
+	books, _ := repo.FindByUserID(context.Background(), "u1")
+	if len(books) != 25 || duplicates != 25 {
+		t.Errorf("expected 25 books and 25 duplicates, got %d and %d", len(books), duplicates)
+	}
+}
+
+func TestBookRepository_ReturnsCopies(t *testing.T) {
+	repo := NewBookRepository(NewStore())
+	b, _ := book.NewBook("g1", "Dune", []string{"Frank Herbert"}, "", nil, "", book.StatusToRead, "u1")
+	if err := repo.Save(context.Background(), b); err != nil {
+		t.Fatal(err)
+	}
+
+	b.Authors[0] = "Changed"
+	got, _ := repo.FindByID(context.Background(), b.ID)
+	got.Title = "Changed"
+
+	again, _ := repo.FindByID(context.Background(), b.ID)
+	if again.Title != "Dune" || again.Authors[0] != "Frank Herbert" {
+		t.Errorf("store shares state with callers: %+v", again)
+	}
+}
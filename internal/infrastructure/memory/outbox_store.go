@@ -0,0 +1,133 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/guisithos/save-my-read/internal/domain/event"
+)
+
+// OutboxStore implements the event.OutboxStore interface over a Store, so
+// messages added in a unit of work are rolled back with it
+type OutboxStore struct {
+	store *Store
+	lock  locker
+}
+
+// NewOutboxStore creates an outbox backed by store
+func NewOutboxStore(store *Store) *OutboxStore {
+	return &OutboxStore{store: store, lock: &store.mu}
+}
+
+// Add stores pending messages
+func (s *OutboxStore) Add(_ context.Context, msgs ...*event.Message) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, m := range msgs {
+		added := copyMessage(m)
+		added.Status = event.StatusPending
+		added.Attempts = 0
+		s.store.data.outbox = append(s.store.data.outbox, added)
+	}
+	return nil
+}
+
+// Claim leases up to limit pending messages due at now, oldest first
+func (s *OutboxStore) Claim(_ context.Context, now time.Time, lease time.Duration, limit int) ([]*event.Message, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var due []*event.Message
+	for _, m := range s.store.data.outbox {
+		if m.Status == event.StatusPending && !m.NextAttemptAt.After(now) {
+			due = append(due, m)
+		}
+	}
+	sort.SliceStable(due, func(i, j int) bool {
+		if !due[i].OccurredAt.Equal(due[j].OccurredAt) {
+			return due[i].OccurredAt.Before(due[j].OccurredAt)
+		}
+		return due[i].ID < due[j].ID
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	claimed := make([]*event.Message, 0, len(due))
+	for _, m := range due {
+		m.Attempts++
+		m.NextAttemptAt = now.Add(lease)
+		claimed = append(claimed, copyMessage(m))
+	}
+	return claimed, nil
+}
+
+// MarkDispatched records that the message was delivered
+func (s *OutboxStore) MarkDispatched(_ context.Context, id string, at time.Time) error {
+	s.update(id, func(m *event.Message) {
+		m.Status = event.StatusDispatched
+		m.DispatchedAt = &at
+		m.LastError = ""
+	})
+	return nil
+}
+
+// Retry records a failed delivery to be tried again at retryAt
+func (s *OutboxStore) Retry(_ context.Context, id, lastError string, retryAt time.Time) error {
+	s.update(id, func(m *event.Message) {
+		if m.Status == event.StatusPending {
+			m.NextAttemptAt = retryAt
+			m.LastError = lastError
+		}
+	})
+	return nil
+}
+
+// Bury records a failed delivery that will not be retried
+func (s *OutboxStore) Bury(_ context.Context, id, lastError string) error {
+	s.update(id, func(m *event.Message) {
+		if m.Status == event.StatusPending {
+			m.Status = event.StatusDead
+			m.LastError = lastError
+		}
+	})
+	return nil
+}
+
+// DeleteDispatched removes messages dispatched before cutoff
+func (s *OutboxStore) DeleteDispatched(_ context.Context, cutoff time.Time) (int64, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	before := len(s.store.data.outbox)
+	s.store.data.outbox = slices.DeleteFunc(s.store.data.outbox, func(m *event.Message) bool {
+		return m.Status == event.StatusDispatched && m.DispatchedAt.Before(cutoff)
+	})
+	return int64(before - len(s.store.data.outbox)), nil
+}
+
+func (s *OutboxStore) update(id string, fn func(m *event.Message)) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, m := range s.store.data.outbox {
+		if m.ID == id {
+			fn(m)
+			return
+		}
+	}
+}
+
+// copyMessage returns a deep copy so callers never share state with the store
+func copyMessage(m *event.Message) *event.Message {
+	c := *m
+	c.Payload = slices.Clone(m.Payload)
+	if m.DispatchedAt != nil {
+		dispatchedAt := *m.DispatchedAt
+		c.DispatchedAt = &dispatchedAt
+	}
+	return &c
+}
@@ -0,0 +1,119 @@
+// Package memory implements the repositories in process memory. It backs
+// the demo server and tests, and behaves like the PostgreSQL repositories
+// as checked by the repotest contract suite.
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/guisithos/save-my-read/internal/application"
+	"github.com/guisithos/save-my-read/internal/domain/book"
+	"github.com/guisithos/save-my-read/internal/domain/event"
+	"github.com/guisithos/save-my-read/internal/domain/user"
+)
+
+// locker is a sync.RWMutex, or nothing inside a unit of work that already
+// holds the store's lock
+type locker interface {
+	Lock()
+	Unlock()
+	RLock()
+	RUnlock()
+}
+
+type noLock struct{}
+
+func (noLock) Lock()    {}
+func (noLock) Unlock()  {}
+func (noLock) RLock()   {}
+func (noLock) RUnlock() {}
+
+// data is the content of a Store
+type data struct {
+	books map[string]*book.Book
+	users map[string]*user.User
+	// outbox holds the messages in the order they were added
+	outbox []*event.Message
+}
+
+func (d *data) clone() *data {
+	c := &data{
+		books: make(map[string]*book.Book, len(d.books)),
+		users: make(map[string]*user.User, len(d.users)),
+	}
+	for id, b := range d.books {
+		c.books[id] = copyBook(b)
+	}
+	for id, u := range d.users {
+		c.users[id] = copyUser(u)
+	}
+	for _, m := range d.outbox {
+		c.outbox = append(c.outbox, copyMessage(m))
+	}
+	return c
+}
+
+// Store holds the users and books shared by the repositories created from
+// it. It is safe for concurrent use.
+type Store struct {
+	mu   sync.RWMutex
+	data *data
+}
+
+// NewStore creates an empty Store
+func NewStore() *Store {
+	return &Store{data: &data{
+		books: make(map[string]*book.Book),
+		users: make(map[string]*user.User),
+	}}
+}
+
+// UnitOfWork implements application.UnitOfWork over a Store. Units of work
+// run one at a time with the store locked, and a failed one leaves the
+// store as it found it.
+type UnitOfWork struct {
+	store *Store
+}
+
+// NewUnitOfWork creates a UnitOfWork over store
+func NewUnitOfWork(store *Store) *UnitOfWork {
+	return &UnitOfWork{store: store}
+}
+
+// Do runs fn with the store locked and restores the previous content if fn fails
+func (u *UnitOfWork) Do(ctx context.Context, fn func(ctx context.Context, repos application.Repositories) error) error {
+	u.store.mu.Lock()
+	defer u.store.mu.Unlock()
+
+	snapshot := u.store.data.clone()
+	repos := application.Repositories{
+		Books:  &BookRepository{store: u.store, lock: noLock{}},
+		Users:  &UserRepository{store: u.store, lock: noLock{}},
+		Outbox: &OutboxStore{store: u.store, lock: noLock{}},
+	}
+	if err := fn(ctx, repos); err != nil {
+		u.store.data = snapshot
+		return err
+	}
+	return nil
+}
+
+// copyBook returns a deep copy so callers never share state with the store
+func copyBook(b *book.Book) *book.Book {
+	c := *b
+	c.Authors = append([]string(nil), b.Authors...)
+	c.Categories = append([]string(nil), b.Categories...)
+	if b.DeletedAt != nil {
+		deletedAt := *b.DeletedAt
+		c.DeletedAt = &deletedAt
+	}
+	return &c
+}
+
+// copyUser returns a deep copy so callers never share state with the store
+func copyUser(u *user.User) *user.User {
+	c := *u
+	c.Genres = append([]string(nil), u.Genres...)
+	return &c
+}
@@ -0,0 +1,78 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/guisithos/save-my-read/internal/domain/auth"
+	"github.com/guisithos/save-my-read/internal/domain/user"
+)
+
+// UserRepository implements the user.Repository interface over a Store
+type UserRepository struct {
+	store *Store
+	lock  locker
+}
+
+// NewUserRepository creates a user repository backed by store
+func NewUserRepository(store *Store) *UserRepository {
+	return &UserRepository{store: store, lock: &store.mu}
+}
+
+// Save stores a new user, refusing an email that is already registered
+func (r *UserRepository) Save(_ context.Context, u *user.User) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	users := r.store.data.users
+	if _, ok := users[u.ID]; ok {
+		return auth.ErrEmailAlreadyExists
+	}
+	for _, existing := range users {
+		if existing.Email == u.Email {
+			return auth.ErrEmailAlreadyExists
+		}
+	}
+	users[u.ID] = copyUser(u)
+	return nil
+}
+
+// FindByEmail retrieves the user registered with email
+func (r *UserRepository) FindByEmail(_ context.Context, email string) (*user.User, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	for _, u := range r.store.data.users {
+		if u.Email == email {
+			return copyUser(u), nil
+		}
+	}
+	return nil, user.ErrNotFound
+}
+
+// FindByID retrieves a user by ID
+func (r *UserRepository) FindByID(_ context.Context, id string) (*user.User, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	u, ok := r.store.data.users[id]
+	if !ok {
+		return nil, user.ErrNotFound
+	}
+	return copyUser(u), nil
+}
+
+// Update saves changes to an existing user
+func (r *UserRepository) Update(_ context.Context, u *user.User) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	existing, ok := r.store.data.users[u.ID]
+	if !ok {
+		return user.ErrNotFound
+	}
+
+	updated := copyUser(u)
+	updated.CreatedAt = existing.CreatedAt
+	r.store.data.users[u.ID] = updated
+	return nil
+}
@@ -0,0 +1,160 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/guisithos/save-my-read/internal/domain/webhook"
+)
+
+// WebhookRepository implements the webhook.Repository interface in memory.
+// It is safe for concurrent use.
+type WebhookRepository struct {
+	mu         sync.RWMutex
+	webhooks   map[string]*webhook.Webhook
+	deliveries []*webhook.Delivery
+}
+
+// NewWebhookRepository creates an empty WebhookRepository
+func NewWebhookRepository() *WebhookRepository {
+	return &WebhookRepository{webhooks: make(map[string]*webhook.Webhook)}
+}
+
+// Save stores a new webhook
+func (r *WebhookRepository) Save(_ context.Context, w *webhook.Webhook) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.webhooks[w.ID] = copyWebhook(w)
+	return nil
+}
+
+// FindByIDAndUserID retrieves a webhook by its ID if it belongs to the user
+func (r *WebhookRepository) FindByIDAndUserID(_ context.Context, id, userID string) (*webhook.Webhook, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	w, ok := r.webhooks[id]
+	if !ok || w.UserID != userID {
+		return nil, webhook.ErrNotFound
+	}
+	return copyWebhook(w), nil
+}
+
+// FindByUserID retrieves the user's webhooks, oldest first
+func (r *WebhookRepository) FindByUserID(_ context.Context, userID string) ([]*webhook.Webhook, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var webhooks []*webhook.Webhook
+	for _, w := range r.webhooks {
+		if w.UserID == userID {
+			webhooks = append(webhooks, copyWebhook(w))
+		}
+	}
+	sort.Slice(webhooks, func(i, j int) bool {
+		if !webhooks[i].CreatedAt.Equal(webhooks[j].CreatedAt) {
+			return webhooks[i].CreatedAt.Before(webhooks[j].CreatedAt)
+		}
+		return webhooks[i].ID < webhooks[j].ID
+	})
+	return webhooks, nil
+}
+
+// Update saves the URL, events and active flag of a webhook owned by w.UserID
+func (r *WebhookRepository) Update(_ context.Context, w *webhook.Webhook) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.webhooks[w.ID]
+	if !ok || existing.UserID != w.UserID {
+		return webhook.ErrNotFound
+	}
+	existing.URL = w.URL
+	existing.Events = slices.Clone(w.Events)
+	existing.Active = w.Active
+	existing.UpdatedAt = w.UpdatedAt
+	return nil
+}
+
+// Delete removes a webhook owned by the user along with its deliveries
+func (r *WebhookRepository) Delete(_ context.Context, id, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	w, ok := r.webhooks[id]
+	if !ok || w.UserID != userID {
+		return webhook.ErrNotFound
+	}
+	delete(r.webhooks, id)
+	r.deliveries = slices.DeleteFunc(r.deliveries, func(d *webhook.Delivery) bool {
+		return d.WebhookID == id
+	})
+	return nil
+}
+
+// SaveDelivery adds a delivery to the log
+func (r *WebhookRepository) SaveDelivery(_ context.Context, d *webhook.Delivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c := *d
+	r.deliveries = append(r.deliveries, &c)
+	return nil
+}
+
+// FindDeliveries retrieves up to limit deliveries of a webhook, newest first
+func (r *WebhookRepository) FindDeliveries(_ context.Context, webhookID string, limit int) ([]*webhook.Delivery, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var deliveries []*webhook.Delivery
+	for _, d := range r.deliveries {
+		if d.WebhookID == webhookID {
+			c := *d
+			deliveries = append(deliveries, &c)
+		}
+	}
+	sort.Slice(deliveries, func(i, j int) bool {
+		if !deliveries[i].CreatedAt.Equal(deliveries[j].CreatedAt) {
+			return deliveries[i].CreatedAt.After(deliveries[j].CreatedAt)
+		}
+		return deliveries[i].ID < deliveries[j].ID
+	})
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+	return deliveries, nil
+}
+
+// Delivered reports whether an event has been delivered to a webhook successfully
+func (r *WebhookRepository) Delivered(_ context.Context, webhookID, eventID string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return slices.ContainsFunc(r.deliveries, func(d *webhook.Delivery) bool {
+		return d.WebhookID == webhookID && d.EventID == eventID && d.Success
+	}), nil
+}
+
+// DeleteDeliveriesBefore removes deliveries older than cutoff
+func (r *WebhookRepository) DeleteDeliveriesBefore(_ context.Context, cutoff time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	before := len(r.deliveries)
+	r.deliveries = slices.DeleteFunc(r.deliveries, func(d *webhook.Delivery) bool {
+		return d.CreatedAt.Before(cutoff)
+	})
+	return int64(before - len(r.deliveries)), nil
+}
+
+// copyWebhook returns a deep copy so callers never share state with the repository
+func copyWebhook(w *webhook.Webhook) *webhook.Webhook {
+	c := *w
+	c.Events = slices.Clone(w.Events)
+	return &c
+}
@@ -1,148 +1,227 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/guisithos/save-my-read/internal/domain/book"
-	_ "github.com/lib/pq"
+	"github.com/guisithos/save-my-read/internal/tracing"
+	"github.com/lib/pq"
 )
 
+// bookColumns lists the columns read by every book query, in scan order
+const bookColumns = `id, google_id, title, authors, description, categories,
+			   image_url, isbn, status, user_id, created_at, updated_at, version, deleted_at`
+
 // BookRepository implements the book.Repository interface using PostgreSQL
 type BookRepository struct {
-	db *sql.DB
+	db           dbtx
+	queryTimeout time.Duration
 }
 
 // NewBookRepository creates a new PostgreSQL book repository
-func NewBookRepository(db *sql.DB) *BookRepository {
-	return &BookRepository{db: db}
+func NewBookRepository(db *sql.DB, queryTimeout time.Duration) *BookRepository {
+	return &BookRepository{db: db, queryTimeout: queryTimeout}
 }
 
 // Save stores a new book in the database
-func (r *BookRepository) Save(book *book.Book) error {
+func (r *BookRepository) Save(ctx context.Context, b *book.Book) (err error) {
 	query := `
 		INSERT INTO books (
-			google_id, title, authors, description, categories, 
-			image_url, status, user_id, created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-		RETURNING id`
+			id, google_id, title, authors, description, categories,
+			image_url, isbn, status, user_id, created_at, updated_at, version
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`
+
+	ctx, span := startSpan(ctx, "INSERT", "books", query)
+	defer func() { tracing.End(span, err) }()
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
 
-	err := r.db.QueryRow(
+	_, err = r.db.ExecContext(
+		ctx,
 		query,
-		book.GoogleID,
-		book.Title,
-		book.Authors,
-		book.Description,
-		book.Categories,
-		book.ImageURL,
-		book.Status,
-		book.UserID,
-		book.CreatedAt,
-		book.UpdatedAt,
-	).Scan(&book.ID)
+		b.ID,
+		b.GoogleID,
+		b.Title,
+		pq.Array(b.Authors),
+		b.Description,
+		pq.Array(b.Categories),
+		b.ImageURL,
+		b.ISBN,
+		b.Status,
+		b.UserID,
+		b.CreatedAt,
+		b.UpdatedAt,
+		b.Version,
+	)
 
 	if err != nil {
-		return fmt.Errorf("error saving book: %w", err)
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			return book.ErrDuplicate
+		}
+		return fmt.Errorf("error saving book: %w", queryError(ctx, err))
 	}
 
 	return nil
 }
 
 // FindByID retrieves a book by its ID
-func (r *BookRepository) FindByID(id string) (*book.Book, error) {
-	query := `
-		SELECT id, google_id, title, authors, description, categories,
-			   image_url, status, user_id, created_at, updated_at
-		FROM books WHERE id = $1`
+func (r *BookRepository) FindByID(ctx context.Context, id string) (_ *book.Book, err error) {
+	if !validID(id) {
+		return nil, book.ErrNotFound
+	}
 
-	b := &book.Book{}
-	err := r.db.QueryRow(query, id).Scan(
-		&b.ID, &b.GoogleID, &b.Title, &b.Authors, &b.Description,
-		&b.Categories, &b.ImageURL, &b.Status, &b.UserID,
-		&b.CreatedAt, &b.UpdatedAt,
-	)
+	query := `SELECT ` + bookColumns + ` FROM books WHERE id = $1 AND deleted_at IS NULL`
+
+	ctx, span := startSpan(ctx, "SELECT", "books", query)
+	defer func() { tracing.End(span, err) }()
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
 
+	b, err := scanBook(r.db.QueryRowContext(ctx, query, id))
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("book not found")
+		return nil, book.ErrNotFound
 	}
 	if err != nil {
-		return nil, fmt.Errorf("error finding book: %w", err)
+		return nil, fmt.Errorf("error finding book: %w", queryError(ctx, err))
 	}
 
 	return b, nil
 }
 
-// FindByUserID retrieves all books for a user
-func (r *BookRepository) FindByUserID(userID string) ([]*book.Book, error) {
-	query := `
-		SELECT id, google_id, title, authors, description, categories,
-			   image_url, status, user_id, created_at, updated_at
-		FROM books WHERE user_id = $1`
+// FindByIDAndUserID retrieves a book by its ID if it belongs to the user
+func (r *BookRepository) FindByIDAndUserID(ctx context.Context, id, userID string) (_ *book.Book, err error) {
+	if !validID(id) {
+		return nil, book.ErrNotFound
+	}
+
+	query := `SELECT ` + bookColumns + ` FROM books WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`
+
+	ctx, span := startSpan(ctx, "SELECT", "books", query)
+	defer func() { tracing.End(span, err) }()
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
 
-	rows, err := r.db.Query(query, userID)
+	b, err := scanBook(r.db.QueryRowContext(ctx, query, id, userID))
+	if err == sql.ErrNoRows {
+		return nil, book.ErrNotFound
+	}
 	if err != nil {
-		return nil, fmt.Errorf("error querying books: %w", err)
+		return nil, fmt.Errorf("error finding book: %w", queryError(ctx, err))
 	}
-	defer rows.Close()
 
-	var books []*book.Book
-	for rows.Next() {
-		b := &book.Book{}
-		err := rows.Scan(
-			&b.ID, &b.GoogleID, &b.Title, &b.Authors, &b.Description,
-			&b.Categories, &b.ImageURL, &b.Status, &b.UserID,
-			&b.CreatedAt, &b.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("error scanning book: %w", err)
-		}
-		books = append(books, b)
+	return b, nil
+}
+
+// FindByUserIDAndGoogleID retrieves the user's entry for a Google Books volume
+func (r *BookRepository) FindByUserIDAndGoogleID(ctx context.Context, userID, googleID string) (_ *book.Book, err error) {
+	query := `SELECT ` + bookColumns + ` FROM books WHERE user_id = $1 AND google_id = $2 AND deleted_at IS NULL`
+
+	ctx, span := startSpan(ctx, "SELECT", "books", query)
+	defer func() { tracing.End(span, err) }()
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	b, err := scanBook(r.db.QueryRowContext(ctx, query, userID, googleID))
+	if err == sql.ErrNoRows {
+		return nil, book.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error finding book: %w", queryError(ctx, err))
 	}
 
-	return books, nil
+	return b, nil
+}
+
+// FindByUserID retrieves all books for a user
+func (r *BookRepository) FindByUserID(ctx context.Context, userID string) ([]*book.Book, error) {
+	query := `SELECT ` + bookColumns + ` FROM books WHERE user_id = $1 AND deleted_at IS NULL`
+
+	return r.queryBooks(ctx, query, userID)
 }
 
 // FindByUserIDAndStatus retrieves books for a user with specific status
-func (r *BookRepository) FindByUserIDAndStatus(userID string, status book.Status) ([]*book.Book, error) {
+func (r *BookRepository) FindByUserIDAndStatus(ctx context.Context, userID string, status book.Status) ([]*book.Book, error) {
+	query := `SELECT ` + bookColumns + ` FROM books WHERE user_id = $1 AND status = $2 AND deleted_at IS NULL`
+
+	return r.queryBooks(ctx, query, userID, status)
+}
+
+// Update updates an existing book owned by b.UserID if it is still at b.Version
+func (r *BookRepository) Update(ctx context.Context, b *book.Book) (err error) {
+	if !validID(b.ID) {
+		return book.ErrNotFound
+	}
+
 	query := `
-		SELECT id, google_id, title, authors, description, categories,
-			   image_url, status, user_id, created_at, updated_at
-		FROM books WHERE user_id = $1 AND status = $2`
+		UPDATE books
+		SET title = $1, authors = $2, description = $3, categories = $4,
+			image_url = $5, isbn = $6, status = $7, created_at = $8, updated_at = $9,
+			version = version + 1
+		WHERE id = $10 AND user_id = $11 AND deleted_at IS NULL AND version = $12`
 
-	rows, err := r.db.Query(query, userID, status)
+	ctx, span := startSpan(ctx, "UPDATE", "books", query)
+	defer func() { tracing.End(span, err) }()
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	result, err := r.db.ExecContext(
+		ctx,
+		query,
+		b.Title,
+		pq.Array(b.Authors),
+		b.Description,
+		pq.Array(b.Categories),
+		b.ImageURL,
+		b.ISBN,
+		b.Status,
+		b.CreatedAt,
+		time.Now(),
+		b.ID,
+		b.UserID,
+		b.Version,
+	)
 	if err != nil {
-		return nil, fmt.Errorf("error querying books: %w", err)
+		return fmt.Errorf("error updating book: %w", queryError(ctx, err))
 	}
-	defer rows.Close()
 
-	var books []*book.Book
-	for rows.Next() {
-		b := &book.Book{}
-		err := rows.Scan(
-			&b.ID, &b.GoogleID, &b.Title, &b.Authors, &b.Description,
-			&b.Categories, &b.ImageURL, &b.Status, &b.UserID,
-			&b.CreatedAt, &b.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("error scanning book: %w", err)
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error checking rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		// Either the book is gone or another update got there first
+		if _, err := r.FindByIDAndUserID(ctx, b.ID, b.UserID); err != nil {
+			return err
 		}
-		books = append(books, b)
+		return book.ErrModified
 	}
 
-	return books, nil
+	b.Version++
+	return nil
 }
 
-// Update updates an existing book
-func (r *BookRepository) Update(book *book.Book) error {
-	query := `
-		UPDATE books 
-		SET status = $1, updated_at = $2
-		WHERE id = $3`
+// Delete moves a user's book to the trash
+func (r *BookRepository) Delete(ctx context.Context, id, userID string) (err error) {
+	if !validID(id) {
+		return book.ErrNotFound
+	}
+
+	query := `UPDATE books SET deleted_at = $1 WHERE id = $2 AND user_id = $3 AND deleted_at IS NULL`
+
+	ctx, span := startSpan(ctx, "UPDATE", "books", query)
+	defer func() { tracing.End(span, err) }()
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
 
-	result, err := r.db.Exec(query, book.Status, time.Now(), book.ID)
+	result, err := r.db.ExecContext(ctx, query, time.Now().UTC(), id, userID)
 	if err != nil {
-		return fmt.Errorf("error updating book: %w", err)
+		return fmt.Errorf("error deleting book: %w", queryError(ctx, err))
 	}
 
 	rows, err := result.RowsAffected()
@@ -151,19 +230,40 @@ func (r *BookRepository) Update(book *book.Book) error {
 	}
 
 	if rows == 0 {
-		return fmt.Errorf("book not found")
+		return book.ErrNotFound
 	}
 
 	return nil
 }
 
-// Delete removes a book from the database
-func (r *BookRepository) Delete(id string) error {
-	query := `DELETE FROM books WHERE id = $1`
+// FindDeletedByUserID lists the user's trash, most recently deleted first
+func (r *BookRepository) FindDeletedByUserID(ctx context.Context, userID string) ([]*book.Book, error) {
+	query := `SELECT ` + bookColumns + ` FROM books WHERE user_id = $1 AND deleted_at IS NOT NULL ORDER BY deleted_at DESC`
 
-	result, err := r.db.Exec(query, id)
+	return r.queryBooks(ctx, query, userID)
+}
+
+// Restore takes a user's book out of the trash
+func (r *BookRepository) Restore(ctx context.Context, id, userID string) (err error) {
+	if !validID(id) {
+		return book.ErrNotFound
+	}
+
+	query := `UPDATE books SET deleted_at = NULL, updated_at = $1, version = version + 1
+		WHERE id = $2 AND user_id = $3 AND deleted_at IS NOT NULL`
+
+	ctx, span := startSpan(ctx, "UPDATE", "books", query)
+	defer func() { tracing.End(span, err) }()
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, query, time.Now(), id, userID)
 	if err != nil {
-		return fmt.Errorf("error deleting book: %w", err)
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			return book.ErrDuplicate
+		}
+		return fmt.Errorf("error restoring book: %w", queryError(ctx, err))
 	}
 
 	rows, err := result.RowsAffected()
@@ -172,8 +272,91 @@ func (r *BookRepository) Delete(id string) error {
 	}
 
 	if rows == 0 {
-		return fmt.Errorf("book not found")
+		return book.ErrNotFound
 	}
 
 	return nil
 }
+
+// PurgeDeleted permanently removes books trashed before cutoff
+func (r *BookRepository) PurgeDeleted(ctx context.Context, cutoff time.Time) (_ []string, err error) {
+	query := `DELETE FROM books WHERE deleted_at < $1 RETURNING id`
+
+	ctx, span := startSpan(ctx, "DELETE", "books", query)
+	defer func() { tracing.End(span, err) }()
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, cutoff.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("error purging books: %w", queryError(ctx, err))
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("error scanning purged book: %w", queryError(ctx, err))
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating purged books: %w", queryError(ctx, err))
+	}
+
+	return ids, nil
+}
+
+func (r *BookRepository) queryBooks(ctx context.Context, query string, args ...interface{}) (_ []*book.Book, err error) {
+	ctx, span := startSpan(ctx, "SELECT", "books", query)
+	defer func() { tracing.End(span, err) }()
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying books: %w", queryError(ctx, err))
+	}
+	defer rows.Close()
+
+	var books []*book.Book
+	for rows.Next() {
+		b, err := scanBook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning book: %w", queryError(ctx, err))
+		}
+		books = append(books, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating books: %w", queryError(ctx, err))
+	}
+
+	return books, nil
+}
+
+// scanner is implemented by both *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanBook(row scanner) (*book.Book, error) {
+	b := &book.Book{}
+	var description, imageURL sql.NullString
+	var deletedAt sql.NullTime
+	err := row.Scan(
+		&b.ID, &b.GoogleID, &b.Title, pq.Array(&b.Authors), &description,
+		pq.Array(&b.Categories), &imageURL, &b.ISBN, &b.Status, &b.UserID,
+		&b.CreatedAt, &b.UpdatedAt, &b.Version, &deletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	b.Description = description.String
+	b.ImageURL = imageURL.String
+	if deletedAt.Valid {
+		b.DeletedAt = &deletedAt.Time
+	}
+	return b, nil
+}
@@ -0,0 +1,43 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// PostgreSQL error codes checked by the repositories
+const (
+	uniqueViolation = "23505"
+	undefinedTable  = "42P01"
+	queryCanceled   = "57014"
+
+	serializationFailure = "40001"
+	deadlockDetected     = "40P01"
+)
+
+// queryError attaches ctx.Err() to an error caused by ctx ending, which
+// the driver may report as a cancelled statement, so callers can tell a
+// timeout or a client disconnect from a database fault
+func queryError(ctx context.Context, err error) error {
+	ctxErr := ctx.Err()
+	if ctxErr == nil || errors.Is(err, ctxErr) {
+		return err
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code != queryCanceled {
+		return err
+	}
+	return fmt.Errorf("%w: %w", ctxErr, err)
+}
+
+// validID reports whether id can be stored in a UUID column. Any other id
+// matches no row, but PostgreSQL fails the whole query on it, so the
+// repositories report such ids as not found without asking.
+func validID(id string) bool {
+	_, err := uuid.Parse(id)
+	return err == nil
+}
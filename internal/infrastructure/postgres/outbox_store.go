@@ -0,0 +1,179 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/guisithos/save-my-read/internal/domain/event"
+	"github.com/guisithos/save-my-read/internal/tracing"
+)
+
+// OutboxStore implements the event.OutboxStore interface using PostgreSQL
+type OutboxStore struct {
+	db           dbtx
+	queryTimeout time.Duration
+}
+
+// NewOutboxStore creates a new PostgreSQL outbox store
+func NewOutboxStore(db *sql.DB, queryTimeout time.Duration) *OutboxStore {
+	return &OutboxStore{db: db, queryTimeout: queryTimeout}
+}
+
+const outboxColumns = `id, name, payload, occurred_at, status, attempts, next_attempt_at, last_error, dispatched_at`
+
+// Add inserts pending messages in a single statement
+func (s *OutboxStore) Add(ctx context.Context, msgs ...*event.Message) (err error) {
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	var query strings.Builder
+	query.WriteString("INSERT INTO outbox_events (id, name, payload, occurred_at, status, attempts, next_attempt_at) VALUES ")
+	args := make([]interface{}, 0, len(msgs)*6)
+	for i, m := range msgs {
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		n := len(args)
+		fmt.Fprintf(&query, "($%d, $%d, $%d, $%d, 'pending', 0, $%d)", n+1, n+2, n+3, n+4, n+5)
+		args = append(args, m.ID, m.Name, string(m.Payload), m.OccurredAt.UTC(), m.NextAttemptAt.UTC())
+	}
+
+	ctx, span := startSpan(ctx, "INSERT", "outbox_events", query.String())
+	defer func() { tracing.End(span, err) }()
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	if _, err = s.db.ExecContext(ctx, query.String(), args...); err != nil {
+		return fmt.Errorf("error adding outbox events: %w", queryError(ctx, err))
+	}
+
+	return nil
+}
+
+// Claim leases up to limit pending messages due at now, oldest first. Rows
+// locked by another dispatcher's claim are skipped rather than waited on.
+func (s *OutboxStore) Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) (_ []*event.Message, err error) {
+	query := `
+		UPDATE outbox_events
+		SET attempts = attempts + 1, next_attempt_at = $1
+		WHERE id IN (
+			SELECT id FROM outbox_events
+			WHERE status = 'pending' AND next_attempt_at <= $2
+			ORDER BY occurred_at, id
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + outboxColumns
+
+	ctx, span := startSpan(ctx, "UPDATE", "outbox_events", query)
+	defer func() { tracing.End(span, err) }()
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, now.Add(lease).UTC(), now.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("error claiming outbox events: %w", queryError(ctx, err))
+	}
+	defer rows.Close()
+
+	var msgs []*event.Message
+	for rows.Next() {
+		m := &event.Message{}
+		var dispatchedAt sql.NullTime
+		if err := rows.Scan(
+			&m.ID,
+			&m.Name,
+			&m.Payload,
+			&m.OccurredAt,
+			&m.Status,
+			&m.Attempts,
+			&m.NextAttemptAt,
+			&m.LastError,
+			&dispatchedAt,
+		); err != nil {
+			return nil, fmt.Errorf("error scanning outbox event: %w", queryError(ctx, err))
+		}
+		if dispatchedAt.Valid {
+			m.DispatchedAt = &dispatchedAt.Time
+		}
+		msgs = append(msgs, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating outbox events: %w", queryError(ctx, err))
+	}
+
+	// RETURNING doesn't follow the subquery's order
+	sort.Slice(msgs, func(i, j int) bool {
+		if !msgs[i].OccurredAt.Equal(msgs[j].OccurredAt) {
+			return msgs[i].OccurredAt.Before(msgs[j].OccurredAt)
+		}
+		return msgs[i].ID < msgs[j].ID
+	})
+
+	return msgs, nil
+}
+
+// MarkDispatched records that the message was delivered
+func (s *OutboxStore) MarkDispatched(ctx context.Context, id string, at time.Time) error {
+	return s.settle(ctx, `
+		UPDATE outbox_events
+		SET status = 'dispatched', dispatched_at = $1, last_error = ''
+		WHERE id = $2`, at.UTC(), id)
+}
+
+// Retry records a failed delivery to be tried again at retryAt
+func (s *OutboxStore) Retry(ctx context.Context, id, lastError string, retryAt time.Time) error {
+	return s.settle(ctx, `
+		UPDATE outbox_events
+		SET next_attempt_at = $1, last_error = $2
+		WHERE id = $3 AND status = 'pending'`, retryAt.UTC(), lastError, id)
+}
+
+// Bury records a failed delivery that will not be retried
+func (s *OutboxStore) Bury(ctx context.Context, id, lastError string) error {
+	return s.settle(ctx, `
+		UPDATE outbox_events
+		SET status = 'dead', last_error = $1
+		WHERE id = $2 AND status = 'pending'`, lastError, id)
+}
+
+// settle runs an update recording the outcome of a delivery
+func (s *OutboxStore) settle(ctx context.Context, query string, args ...interface{}) (err error) {
+	ctx, span := startSpan(ctx, "UPDATE", "outbox_events", query)
+	defer func() { tracing.End(span, err) }()
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	if _, err = s.db.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("error updating outbox event: %w", queryError(ctx, err))
+	}
+
+	return nil
+}
+
+// DeleteDispatched removes messages dispatched before cutoff
+func (s *OutboxStore) DeleteDispatched(ctx context.Context, cutoff time.Time) (_ int64, err error) {
+	query := `DELETE FROM outbox_events WHERE status = 'dispatched' AND dispatched_at < $1`
+
+	ctx, span := startSpan(ctx, "DELETE", "outbox_events", query)
+	defer func() { tracing.End(span, err) }()
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, query, cutoff.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("error deleting dispatched outbox events: %w", queryError(ctx, err))
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error checking rows affected: %w", err)
+	}
+
+	return rows, nil
+}
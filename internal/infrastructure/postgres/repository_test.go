@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"database/sql"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/guisithos/save-my-read/internal/infrastructure/repotest"
+)
+
+// TestRepositories runs the repository contract suite against the migrated
+// database in DATABASE_URL. The suite truncates the tables, so it only runs
+// against a local instance.
+func TestRepositories(t *testing.T) {
+	db := openTestDB(t)
+
+	repotest.Run(t, func(t *testing.T) repotest.Setup {
+		if _, err := db.Exec(`TRUNCATE webhook_deliveries, webhooks, outbox_events, audit_events, idempotency_keys, books, users`); err != nil {
+			t.Fatalf("truncating tables: %v", err)
+		}
+		return repotest.Setup{
+			Books:       NewBookRepository(db, 5*time.Second),
+			Users:       NewUserRepository(db, 5*time.Second),
+			UnitOfWork:  NewUnitOfWork(db, 5*time.Second),
+			Idempotency: NewIdempotencyStore(db, 5*time.Second),
+			Audit:       NewAuditStore(db, 5*time.Second),
+			Outbox:      NewOutboxStore(db, 5*time.Second),
+			Webhooks:    NewWebhookRepository(db, 5*time.Second),
+		}
+	})
+}
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv("DATABASE_URL")
+	if dsn == "" {
+		t.Skip("DATABASE_URL not set")
+	}
+	if !isLocal(dsn) {
+		t.Skip("DATABASE_URL does not point at a local database")
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("opening database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.Ping(); err != nil {
+		t.Fatalf("connecting to database: %v", err)
+	}
+	return db
+}
+
+// isLocal reports whether a postgres:// URL names this machine
+func isLocal(dsn string) bool {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return false
+	}
+	host := u.Hostname()
+	if host == "" {
+		// A socket directory in the query, or libpq's default socket
+		host = u.Query().Get("host")
+		return host == "" || strings.HasPrefix(host, "/")
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
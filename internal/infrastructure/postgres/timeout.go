@@ -0,0 +1,15 @@
+package postgres
+
+import (
+	"context"
+	"time"
+)
+
+// withTimeout bounds a statement by d in addition to any deadline ctx
+// already carries; a d of zero leaves the caller's deadline alone
+func withTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, d)
+}
@@ -0,0 +1,26 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/guisithos/save-my-read/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = tracing.Tracer("github.com/guisithos/save-my-read/internal/infrastructure/postgres")
+
+// startSpan starts a client span for one statement, named after the
+// operation and table as the database semantic conventions suggest.
+// Statements are parameterized, so the query text carries no user data.
+func startSpan(ctx context.Context, operation, table, query string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, operation+" "+table,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.operation.name", operation),
+			attribute.String("db.collection.name", table),
+			attribute.String("db.query.text", query),
+		),
+	)
+}
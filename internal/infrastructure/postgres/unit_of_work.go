@@ -0,0 +1,103 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/guisithos/save-my-read/internal/application"
+	"github.com/guisithos/save-my-read/internal/tracing"
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	// defaultMaxAttempts bounds how often a transaction is retried after
+	// serialization failures and deadlocks
+	defaultMaxAttempts = 3
+	retryBaseDelay     = 20 * time.Millisecond
+)
+
+// dbtx is implemented by both *sql.DB and *sql.Tx, so repositories can run
+// on their own or inside a unit of work
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// UnitOfWork implements application.UnitOfWork with serializable
+// PostgreSQL transactions
+type UnitOfWork struct {
+	db           *sql.DB
+	queryTimeout time.Duration
+	maxAttempts  int
+}
+
+// NewUnitOfWork creates a UnitOfWork whose repositories bound each
+// statement by queryTimeout
+func NewUnitOfWork(db *sql.DB, queryTimeout time.Duration) *UnitOfWork {
+	return &UnitOfWork{db: db, queryTimeout: queryTimeout, maxAttempts: defaultMaxAttempts}
+}
+
+// Do runs fn in a transaction and commits it if fn succeeds. Serialization
+// failures and deadlocks, which PostgreSQL resolves by aborting one of the
+// transactions involved, are retried with a short jittered backoff.
+func (u *UnitOfWork) Do(ctx context.Context, fn func(ctx context.Context, repos application.Repositories) error) (err error) {
+	ctx, span := tracer.Start(ctx, "UnitOfWork")
+	defer func() { tracing.End(span, err) }()
+
+	for attempt := 1; ; attempt++ {
+		span.SetAttributes(attribute.Int("db.transaction.attempts", attempt))
+
+		err = u.attempt(ctx, fn)
+		if err == nil || attempt >= u.maxAttempts || !retryable(err) {
+			return err
+		}
+
+		delay := retryBaseDelay << (attempt - 1)
+		delay += rand.N(delay)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %w", ctx.Err(), err)
+		case <-time.After(delay):
+		}
+	}
+}
+
+// attempt runs fn in a single transaction
+func (u *UnitOfWork) attempt(ctx context.Context, fn func(ctx context.Context, repos application.Repositories) error) error {
+	tx, err := u.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", queryError(ctx, err))
+	}
+	// Rollback after a commit is a no-op
+	defer tx.Rollback()
+
+	repos := application.Repositories{
+		Books:  &BookRepository{db: tx, queryTimeout: u.queryTimeout},
+		Users:  &UserRepository{db: tx, queryTimeout: u.queryTimeout},
+		Outbox: &OutboxStore{db: tx, queryTimeout: u.queryTimeout},
+	}
+	if err := fn(ctx, repos); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", queryError(ctx, err))
+	}
+	return nil
+}
+
+// retryable reports whether err means the transaction lost a conflict and
+// would likely succeed if run again
+func retryable(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	return pqErr.Code == serializationFailure || pqErr.Code == deadlockDetected
+}
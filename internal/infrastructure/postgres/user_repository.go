@@ -1,69 +1,79 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/guisithos/save-my-read/internal/domain/auth"
 	"github.com/guisithos/save-my-read/internal/domain/user"
+	"github.com/guisithos/save-my-read/internal/tracing"
 	"github.com/lib/pq"
 )
 
 type UserRepository struct {
-	db *sql.DB
+	db           dbtx
+	queryTimeout time.Duration
 }
 
-func NewUserRepository(db *sql.DB) *UserRepository {
-	return &UserRepository{db: db}
+func NewUserRepository(db *sql.DB, queryTimeout time.Duration) *UserRepository {
+	return &UserRepository{db: db, queryTimeout: queryTimeout}
 }
 
-func (r *UserRepository) Save(user *user.User) error {
-	fmt.Printf("Attempting to save user: %+v\n", user)
-
+func (r *UserRepository) Save(ctx context.Context, u *user.User) (err error) {
 	query := `
 		INSERT INTO users (id, email, password_hash, name, genres, created_at, updated_at)
 		VALUES ($1, $2, $3, $4, $5, $6, $7)
 		RETURNING id`
 
-	result, err := r.db.Exec(
+	ctx, span := startSpan(ctx, "INSERT", "users", query)
+	defer func() { tracing.End(span, err) }()
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	_, err = r.db.ExecContext(
+		ctx,
 		query,
-		user.ID,
-		user.Email,
-		user.Password,
-		user.Name,
-		pq.Array(user.Genres),
-		user.CreatedAt,
-		user.UpdatedAt,
+		u.ID,
+		u.Email,
+		u.Password,
+		u.Name,
+		pq.Array(u.Genres),
+		u.CreatedAt,
+		u.UpdatedAt,
 	)
 
 	if err != nil {
-		fmt.Printf("Error saving user: %v\n", err)
 		// Check for unique constraint violation
-		if pqErr, ok := err.(*pq.Error); ok {
-			fmt.Printf("PostgreSQL Error Code: %s, Message: %s\n", pqErr.Code, pqErr.Message)
-			if pqErr.Code == "23505" {
-				return errors.New("email already exists")
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) {
+			if pqErr.Code == uniqueViolation {
+				return auth.ErrEmailAlreadyExists
 			}
 		}
-		return fmt.Errorf("error saving user: %w", err)
+		return fmt.Errorf("error saving user: %w", queryError(ctx, err))
 	}
 
-	rows, _ := result.RowsAffected()
-	fmt.Printf("Successfully saved user. Rows affected: %d\n", rows)
 	return nil
 }
 
-func (r *UserRepository) FindByEmail(email string) (*user.User, error) {
+func (r *UserRepository) FindByEmail(ctx context.Context, email string) (_ *user.User, err error) {
 	query := `
 		SELECT id, email, password_hash, name, genres, created_at, updated_at
 		FROM users
 		WHERE email = $1`
 
+	ctx, span := startSpan(ctx, "SELECT", "users", query)
+	defer func() { tracing.End(span, err) }()
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
 	u := &user.User{}
 	var genres []string
 
-	err := r.db.QueryRow(query, email).Scan(
+	err = r.db.QueryRowContext(ctx, query, email).Scan(
 		&u.ID,
 		&u.Email,
 		&u.Password,
@@ -74,26 +84,31 @@ func (r *UserRepository) FindByEmail(email string) (*user.User, error) {
 	)
 
 	if err == sql.ErrNoRows {
-		return nil, auth.ErrInvalidCredentials
+		return nil, user.ErrNotFound
 	}
 	if err != nil {
-		return nil, fmt.Errorf("error finding user: %w", err)
+		return nil, fmt.Errorf("error finding user: %w", queryError(ctx, err))
 	}
 
 	u.Genres = genres
 	return u, nil
 }
 
-func (r *UserRepository) FindByID(id string) (*user.User, error) {
+func (r *UserRepository) FindByID(ctx context.Context, id string) (_ *user.User, err error) {
 	query := `
 		SELECT id, email, password_hash, name, genres, created_at, updated_at
 		FROM users
 		WHERE id = $1`
 
+	ctx, span := startSpan(ctx, "SELECT", "users", query)
+	defer func() { tracing.End(span, err) }()
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
 	u := &user.User{}
 	var genres []string
 
-	err := r.db.QueryRow(query, id).Scan(
+	err = r.db.QueryRowContext(ctx, query, id).Scan(
 		&u.ID,
 		&u.Email,
 		&u.Password,
@@ -104,27 +119,30 @@ func (r *UserRepository) FindByID(id string) (*user.User, error) {
 	)
 
 	if err == sql.ErrNoRows {
-		return nil, errors.New("user not found")
+		return nil, user.ErrNotFound
 	}
 	if err != nil {
-		fmt.Printf("Error finding user by ID: %v\n", err)
-		return nil, fmt.Errorf("error finding user: %w", err)
+		return nil, fmt.Errorf("error finding user: %w", queryError(ctx, err))
 	}
 
 	u.Genres = genres
 	return u, nil
 }
 
-func (r *UserRepository) Update(user *user.User) error {
+func (r *UserRepository) Update(ctx context.Context, u *user.User) (err error) {
 	query := `
 		UPDATE users 
 		SET name = $1, email = $2, password_hash = $3, genres = $4, updated_at = $5
 		WHERE id = $6`
 
-	result, err := r.db.Exec(query, user.Name, user.Email, user.Password, pq.Array(user.Genres), user.UpdatedAt, user.ID)
+	ctx, span := startSpan(ctx, "UPDATE", "users", query)
+	defer func() { tracing.End(span, err) }()
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, query, u.Name, u.Email, u.Password, pq.Array(u.Genres), u.UpdatedAt, u.ID)
 	if err != nil {
-		fmt.Printf("Error updating user: %v\n", err)
-		return fmt.Errorf("error updating user: %w", err)
+		return fmt.Errorf("error updating user: %w", queryError(ctx, err))
 	}
 
 	rows, err := result.RowsAffected()
@@ -133,7 +151,7 @@ func (r *UserRepository) Update(user *user.User) error {
 	}
 
 	if rows == 0 {
-		return fmt.Errorf("user not found")
+		return user.ErrNotFound
 	}
 
 	return nil
@@ -0,0 +1,1153 @@
+// Package repotest is a contract test suite for the book and user
+// repositories, the idempotency, audit and outbox stores, the webhook
+// repository and the unit of work.
+// Every implementation runs the same suite so they stay interchangeable
+// behind the domain interfaces.
+package repotest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guisithos/save-my-read/internal/application"
+	"github.com/guisithos/save-my-read/internal/domain/audit"
+	"github.com/guisithos/save-my-read/internal/domain/auth"
+	"github.com/guisithos/save-my-read/internal/domain/book"
+	"github.com/guisithos/save-my-read/internal/domain/event"
+	"github.com/guisithos/save-my-read/internal/domain/idempotency"
+	"github.com/guisithos/save-my-read/internal/domain/user"
+	"github.com/guisithos/save-my-read/internal/domain/webhook"
+)
+
+// Setup is a set of repositories sharing one empty store
+type Setup struct {
+	Books      book.Repository
+	Users      user.Repository
+	UnitOfWork application.UnitOfWork
+	// Idempotency shares the store with Users; its records belong to them
+	Idempotency idempotency.Store
+	Audit       audit.Store
+	// Outbox shares the store with UnitOfWork, whose repos add to it
+	Outbox event.OutboxStore
+	// Webhooks shares the store with Users; webhooks belong to them
+	Webhooks webhook.Repository
+}
+
+// Run runs the contract suite, calling newSetup for a fresh, empty store
+// in every subtest
+func Run(t *testing.T, newSetup func(t *testing.T) Setup) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, s Setup)
+	}{
+		{"Users/SaveAndFind", testUserSaveAndFind},
+		{"Users/DuplicateEmail", testUserDuplicateEmail},
+		{"Users/NotFound", testUserNotFound},
+		{"Users/Update", testUserUpdate},
+		{"Books/SaveAndFind", testBookSaveAndFind},
+		{"Books/DuplicateVolume", testBookDuplicateVolume},
+		{"Books/OwnerScoping", testBookOwnerScoping},
+		{"Books/FindByUser", testBookFindByUser},
+		{"Books/Update", testBookUpdate},
+		{"Books/StaleUpdate", testBookStaleUpdate},
+		{"Books/Delete", testBookDelete},
+		{"Books/Trash", testBookTrash},
+		{"Books/RestoreConflict", testBookRestoreConflict},
+		{"Books/Purge", testBookPurge},
+		{"Books/MalformedID", testBookMalformedID},
+		{"Idempotency/Lifecycle", testIdempotencyLifecycle},
+		{"Idempotency/Release", testIdempotencyRelease},
+		{"Idempotency/Expiry", testIdempotencyExpiry},
+		{"Audit/AppendAndList", testAuditAppendAndList},
+		{"Audit/Filter", testAuditFilter},
+		{"Outbox/Lifecycle", testOutboxLifecycle},
+		{"Outbox/ClaimLimit", testOutboxClaimLimit},
+		{"Outbox/Rollback", testOutboxRollback},
+		{"Webhooks/SaveAndFind", testWebhookSaveAndFind},
+		{"Webhooks/UpdateAndDelete", testWebhookUpdateAndDelete},
+		{"Webhooks/Deliveries", testWebhookDeliveries},
+		{"Webhooks/MalformedID", testWebhookMalformedID},
+		{"UnitOfWork/Commit", testUnitOfWorkCommit},
+		{"UnitOfWork/Rollback", testUnitOfWorkRollback},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newSetup(t))
+		})
+	}
+}
+
+func testUserSaveAndFind(t *testing.T, s Setup) {
+	ctx := context.Background()
+	u := saveUser(t, s, "reader@example.com")
+
+	byID, err := s.Users.FindByID(ctx, u.ID)
+	if err != nil {
+		t.Fatalf("FindByID() error = %v", err)
+	}
+	assertUser(t, u, byID)
+
+	byEmail, err := s.Users.FindByEmail(ctx, u.Email)
+	if err != nil {
+		t.Fatalf("FindByEmail() error = %v", err)
+	}
+	assertUser(t, u, byEmail)
+}
+
+func testUserDuplicateEmail(t *testing.T, s Setup) {
+	saveUser(t, s, "reader@example.com")
+
+	err := s.Users.Save(context.Background(), newUser("reader@example.com"))
+	if !errors.Is(err, auth.ErrEmailAlreadyExists) {
+		t.Errorf("expected ErrEmailAlreadyExists, got %v", err)
+	}
+}
+
+func testUserNotFound(t *testing.T, s Setup) {
+	ctx := context.Background()
+
+	if _, err := s.Users.FindByID(ctx, uuid.NewString()); !errors.Is(err, user.ErrNotFound) {
+		t.Errorf("FindByID: expected ErrNotFound, got %v", err)
+	}
+	if _, err := s.Users.FindByEmail(ctx, "nobody@example.com"); !errors.Is(err, user.ErrNotFound) {
+		t.Errorf("FindByEmail: expected ErrNotFound, got %v", err)
+	}
+	if err := s.Users.Update(ctx, newUser("nobody@example.com")); !errors.Is(err, user.ErrNotFound) {
+		t.Errorf("Update: expected ErrNotFound, got %v", err)
+	}
+}
+
+func testUserUpdate(t *testing.T, s Setup) {
+	ctx := context.Background()
+	u := saveUser(t, s, "reader@example.com")
+
+	u.Name = "Renamed Reader"
+	u.Genres = []string{"fantasy", "history"}
+	u.UpdatedAt = u.UpdatedAt.Add(time.Minute)
+	if err := s.Users.Update(ctx, u); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	got, err := s.Users.FindByID(ctx, u.ID)
+	if err != nil {
+		t.Fatalf("FindByID() error = %v", err)
+	}
+	assertUser(t, u, got)
+}
+
+func testBookSaveAndFind(t *testing.T, s Setup) {
+	ctx := context.Background()
+	u := saveUser(t, s, "reader@example.com")
+	b := saveBook(t, s, u.ID, "g1", book.StatusToRead)
+
+	got, err := s.Books.FindByID(ctx, b.ID)
+	if err != nil {
+		t.Fatalf("FindByID() error = %v", err)
+	}
+	assertBook(t, b, got)
+
+	got, err = s.Books.FindByIDAndUserID(ctx, b.ID, u.ID)
+	if err != nil {
+		t.Fatalf("FindByIDAndUserID() error = %v", err)
+	}
+	assertBook(t, b, got)
+
+	got, err = s.Books.FindByUserIDAndGoogleID(ctx, u.ID, "g1")
+	if err != nil {
+		t.Fatalf("FindByUserIDAndGoogleID() error = %v", err)
+	}
+	assertBook(t, b, got)
+
+	if _, err := s.Books.FindByID(ctx, uuid.NewString()); !errors.Is(err, book.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a missing book, got %v", err)
+	}
+}
+
+func testBookDuplicateVolume(t *testing.T, s Setup) {
+	ctx := context.Background()
+	alice := saveUser(t, s, "alice@example.com")
+	bob := saveUser(t, s, "bob@example.com")
+	saveBook(t, s, alice.ID, "g1", book.StatusToRead)
+
+	if err := s.Books.Save(ctx, newBook(alice.ID, "g1", book.StatusReading)); !errors.Is(err, book.ErrDuplicate) {
+		t.Errorf("expected ErrDuplicate for the same volume twice, got %v", err)
+	}
+	if err := s.Books.Save(ctx, newBook(bob.ID, "g1", book.StatusReading)); err != nil {
+		t.Errorf("expected another user to add the same volume, got %v", err)
+	}
+}
+
+func testBookOwnerScoping(t *testing.T, s Setup) {
+	ctx := context.Background()
+	alice := saveUser(t, s, "alice@example.com")
+	bob := saveUser(t, s, "bob@example.com")
+	b := saveBook(t, s, alice.ID, "g1", book.StatusToRead)
+
+	if _, err := s.Books.FindByIDAndUserID(ctx, b.ID, bob.ID); !errors.Is(err, book.ErrNotFound) {
+		t.Errorf("FindByIDAndUserID: expected ErrNotFound, got %v", err)
+	}
+	if _, err := s.Books.FindByUserIDAndGoogleID(ctx, bob.ID, "g1"); !errors.Is(err, book.ErrNotFound) {
+		t.Errorf("FindByUserIDAndGoogleID: expected ErrNotFound, got %v", err)
+	}
+
+	foreign := *b
+	foreign.UserID = bob.ID
+	foreign.Status = book.StatusDNF
+	if err := s.Books.Update(ctx, &foreign); !errors.Is(err, book.ErrNotFound) {
+		t.Errorf("Update: expected ErrNotFound, got %v", err)
+	}
+	if err := s.Books.Delete(ctx, b.ID, bob.ID); !errors.Is(err, book.ErrNotFound) {
+		t.Errorf("Delete: expected ErrNotFound, got %v", err)
+	}
+
+	got, err := s.Books.FindByID(ctx, b.ID)
+	if err != nil {
+		t.Fatalf("FindByID() error = %v", err)
+	}
+	if got.Status != book.StatusToRead {
+		t.Errorf("another user's update changed the book to %s", got.Status)
+	}
+}
+
+func testBookFindByUser(t *testing.T, s Setup) {
+	ctx := context.Background()
+	alice := saveUser(t, s, "alice@example.com")
+	bob := saveUser(t, s, "bob@example.com")
+	toRead := saveBook(t, s, alice.ID, "g1", book.StatusToRead)
+	reading := saveBook(t, s, alice.ID, "g2", book.StatusReading)
+	saveBook(t, s, bob.ID, "g3", book.StatusReading)
+
+	all, err := s.Books.FindByUserID(ctx, alice.ID)
+	if err != nil {
+		t.Fatalf("FindByUserID() error = %v", err)
+	}
+	assertIDs(t, all, toRead.ID, reading.ID)
+
+	filtered, err := s.Books.FindByUserIDAndStatus(ctx, alice.ID, book.StatusReading)
+	if err != nil {
+		t.Fatalf("FindByUserIDAndStatus() error = %v", err)
+	}
+	assertIDs(t, filtered, reading.ID)
+
+	none, err := s.Books.FindByUserID(ctx, uuid.NewString())
+	if err != nil {
+		t.Fatalf("FindByUserID() for a user without books error = %v", err)
+	}
+	assertIDs(t, none)
+}
+
+func testBookUpdate(t *testing.T, s Setup) {
+	ctx := context.Background()
+	u := saveUser(t, s, "reader@example.com")
+	b := saveBook(t, s, u.ID, "g1", book.StatusToRead)
+
+	b.Status = book.StatusCompleted
+	b.Title = "Dune Messiah"
+	b.Categories = []string{"Fiction"}
+	b.ISBN = "9780441172696"
+	if err := s.Books.Update(ctx, b); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	got, err := s.Books.FindByID(ctx, b.ID)
+	if err != nil {
+		t.Fatalf("FindByID() error = %v", err)
+	}
+	if got.Status != b.Status || got.Title != b.Title || got.ISBN != b.ISBN || !slices.Equal(got.Categories, b.Categories) {
+		t.Errorf("update not stored: got %+v", got)
+	}
+	if got.UpdatedAt.Before(b.UpdatedAt) {
+		t.Errorf("expected UpdatedAt to move forward, got %v before %v", got.UpdatedAt, b.UpdatedAt)
+	}
+	if b.Version != 2 || got.Version != 2 {
+		t.Errorf("expected version 2 after one update, got %d (stored %d)", b.Version, got.Version)
+	}
+}
+
+func testBookStaleUpdate(t *testing.T, s Setup) {
+	ctx := context.Background()
+	u := saveUser(t, s, "reader@example.com")
+	b := saveBook(t, s, u.ID, "g1", book.StatusToRead)
+
+	// Two readers load the same version
+	first, err := s.Books.FindByIDAndUserID(ctx, b.ID, u.ID)
+	if err != nil {
+		t.Fatalf("FindByIDAndUserID() error = %v", err)
+	}
+	second, err := s.Books.FindByIDAndUserID(ctx, b.ID, u.ID)
+	if err != nil {
+		t.Fatalf("FindByIDAndUserID() error = %v", err)
+	}
+
+	first.Status = book.StatusReading
+	if err := s.Books.Update(ctx, first); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	second.Status = book.StatusDNF
+	if err := s.Books.Update(ctx, second); !errors.Is(err, book.ErrModified) {
+		t.Fatalf("expected ErrModified for a stale update, got %v", err)
+	}
+	if second.Version != 1 {
+		t.Errorf("expected a failed update to keep version 1, got %d", second.Version)
+	}
+
+	got, err := s.Books.FindByID(ctx, b.ID)
+	if err != nil {
+		t.Fatalf("FindByID() error = %v", err)
+	}
+	if got.Status != book.StatusReading || got.Version != 2 {
+		t.Errorf("expected the first update to win, got status %s version %d", got.Status, got.Version)
+	}
+
+	// A missing book is still reported as such, whatever the version
+	second.ID = "00000000-0000-0000-0000-000000000000"
+	if err := s.Books.Update(ctx, second); !errors.Is(err, book.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func testBookDelete(t *testing.T, s Setup) {
+	ctx := context.Background()
+	u := saveUser(t, s, "reader@example.com")
+	b := saveBook(t, s, u.ID, "g1", book.StatusToRead)
+
+	if err := s.Books.Delete(ctx, b.ID, u.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := s.Books.FindByID(ctx, b.ID); !errors.Is(err, book.ErrNotFound) {
+		t.Errorf("expected deleted book to be gone, got %v", err)
+	}
+	if err := s.Books.Delete(ctx, b.ID, u.ID); !errors.Is(err, book.ErrNotFound) {
+		t.Errorf("expected ErrNotFound deleting twice, got %v", err)
+	}
+}
+
+func testBookTrash(t *testing.T, s Setup) {
+	ctx := context.Background()
+	alice := saveUser(t, s, "alice@example.com")
+	bob := saveUser(t, s, "bob@example.com")
+	b := saveBook(t, s, alice.ID, "g1", book.StatusToRead)
+	kept := saveBook(t, s, alice.ID, "g2", book.StatusReading)
+
+	if err := s.Books.Delete(ctx, b.ID, alice.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	// Trashed books are hidden from every finder
+	if _, err := s.Books.FindByIDAndUserID(ctx, b.ID, alice.ID); !errors.Is(err, book.ErrNotFound) {
+		t.Errorf("FindByIDAndUserID: expected ErrNotFound, got %v", err)
+	}
+	if _, err := s.Books.FindByUserIDAndGoogleID(ctx, alice.ID, "g1"); !errors.Is(err, book.ErrNotFound) {
+		t.Errorf("FindByUserIDAndGoogleID: expected ErrNotFound, got %v", err)
+	}
+	active, err := s.Books.FindByUserID(ctx, alice.ID)
+	if err != nil {
+		t.Fatalf("FindByUserID() error = %v", err)
+	}
+	assertIDs(t, active, kept.ID)
+	filtered, err := s.Books.FindByUserIDAndStatus(ctx, alice.ID, book.StatusToRead)
+	if err != nil {
+		t.Fatalf("FindByUserIDAndStatus() error = %v", err)
+	}
+	assertIDs(t, filtered)
+	if err := s.Books.Update(ctx, b); !errors.Is(err, book.ErrNotFound) {
+		t.Errorf("Update: expected ErrNotFound for a trashed book, got %v", err)
+	}
+
+	trash, err := s.Books.FindDeletedByUserID(ctx, alice.ID)
+	if err != nil {
+		t.Fatalf("FindDeletedByUserID() error = %v", err)
+	}
+	assertIDs(t, trash, b.ID)
+	if trash[0].DeletedAt == nil {
+		t.Error("expected DeletedAt on a trashed book")
+	}
+	others, err := s.Books.FindDeletedByUserID(ctx, bob.ID)
+	if err != nil {
+		t.Fatalf("FindDeletedByUserID() error = %v", err)
+	}
+	assertIDs(t, others)
+
+	if err := s.Books.Restore(ctx, b.ID, bob.ID); !errors.Is(err, book.ErrNotFound) {
+		t.Errorf("Restore by another user: expected ErrNotFound, got %v", err)
+	}
+	if err := s.Books.Restore(ctx, kept.ID, alice.ID); !errors.Is(err, book.ErrNotFound) {
+		t.Errorf("Restore of a book outside the trash: expected ErrNotFound, got %v", err)
+	}
+	if err := s.Books.Restore(ctx, b.ID, alice.ID); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	got, err := s.Books.FindByIDAndUserID(ctx, b.ID, alice.ID)
+	if err != nil {
+		t.Fatalf("expected restored book, got %v", err)
+	}
+	if got.DeletedAt != nil || got.Status != book.StatusToRead || got.Version != b.Version+1 {
+		t.Errorf("restored book = %+v", got)
+	}
+}
+
+func testBookRestoreConflict(t *testing.T, s Setup) {
+	ctx := context.Background()
+	u := saveUser(t, s, "reader@example.com")
+	trashed := saveBook(t, s, u.ID, "g1", book.StatusToRead)
+	if err := s.Books.Delete(ctx, trashed.ID, u.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	// The trash doesn't keep the volume from being added again...
+	again := saveBook(t, s, u.ID, "g1", book.StatusReading)
+
+	// ...but then the old entry can't come back next to it
+	if err := s.Books.Restore(ctx, trashed.ID, u.ID); !errors.Is(err, book.ErrDuplicate) {
+		t.Errorf("expected ErrDuplicate, got %v", err)
+	}
+	got, err := s.Books.FindByUserIDAndGoogleID(ctx, u.ID, "g1")
+	if err != nil {
+		t.Fatalf("FindByUserIDAndGoogleID() error = %v", err)
+	}
+	if got.ID != again.ID {
+		t.Errorf("expected the new entry %s, got %s", again.ID, got.ID)
+	}
+}
+
+func testBookPurge(t *testing.T, s Setup) {
+	ctx := context.Background()
+	u := saveUser(t, s, "reader@example.com")
+	old := saveBook(t, s, u.ID, "g1", book.StatusToRead)
+	live := saveBook(t, s, u.ID, "g2", book.StatusToRead)
+	if err := s.Books.Delete(ctx, old.ID, u.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	// Nothing was trashed before an hour ago
+	ids, err := s.Books.PurgeDeleted(ctx, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("PurgeDeleted() error = %v", err)
+	}
+	if len(ids) != 0 {
+		t.Errorf("expected nothing purged, got %v", ids)
+	}
+
+	ids, err = s.Books.PurgeDeleted(ctx, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("PurgeDeleted() error = %v", err)
+	}
+	if len(ids) != 1 || ids[0] != old.ID {
+		t.Errorf("expected %s purged, got %v", old.ID, ids)
+	}
+
+	trash, err := s.Books.FindDeletedByUserID(ctx, u.ID)
+	if err != nil {
+		t.Fatalf("FindDeletedByUserID() error = %v", err)
+	}
+	assertIDs(t, trash)
+	if err := s.Books.Restore(ctx, old.ID, u.ID); !errors.Is(err, book.ErrNotFound) {
+		t.Errorf("expected purged book to be gone, got %v", err)
+	}
+	if _, err := s.Books.FindByID(ctx, live.ID); err != nil {
+		t.Errorf("expected live book to survive the purge, got %v", err)
+	}
+}
+
+// malformedID is a book or webhook ID as a client might put in a URL; it
+// names nothing and must be reported as such
+const malformedID = "not-a-uuid"
+
+func testBookMalformedID(t *testing.T, s Setup) {
+	ctx := context.Background()
+	u := saveUser(t, s, "reader@example.com")
+	b := saveBook(t, s, u.ID, "g1", book.StatusToRead)
+
+	if _, err := s.Books.FindByID(ctx, malformedID); !errors.Is(err, book.ErrNotFound) {
+		t.Errorf("FindByID() expected ErrNotFound, got %v", err)
+	}
+	if _, err := s.Books.FindByIDAndUserID(ctx, malformedID, u.ID); !errors.Is(err, book.ErrNotFound) {
+		t.Errorf("FindByIDAndUserID() expected ErrNotFound, got %v", err)
+	}
+	stray := *b
+	stray.ID = malformedID
+	if err := s.Books.Update(ctx, &stray); !errors.Is(err, book.ErrNotFound) {
+		t.Errorf("Update() expected ErrNotFound, got %v", err)
+	}
+	if err := s.Books.Delete(ctx, malformedID, u.ID); !errors.Is(err, book.ErrNotFound) {
+		t.Errorf("Delete() expected ErrNotFound, got %v", err)
+	}
+	if err := s.Books.Restore(ctx, malformedID, u.ID); !errors.Is(err, book.ErrNotFound) {
+		t.Errorf("Restore() expected ErrNotFound, got %v", err)
+	}
+}
+
+func testIdempotencyLifecycle(t *testing.T, s Setup) {
+	ctx := context.Background()
+	alice := saveUser(t, s, "alice@example.com")
+	bob := saveUser(t, s, "bob@example.com")
+
+	rec := newRecord(alice.ID, "key-1", time.Hour)
+	if err := s.Idempotency.Reserve(ctx, rec); err != nil {
+		t.Fatalf("Reserve() error = %v", err)
+	}
+	if err := s.Idempotency.Reserve(ctx, newRecord(alice.ID, "key-1", time.Hour)); !errors.Is(err, idempotency.ErrExists) {
+		t.Errorf("expected ErrExists reserving a key twice, got %v", err)
+	}
+	// Keys are scoped to their user
+	if err := s.Idempotency.Reserve(ctx, newRecord(bob.ID, "key-1", time.Hour)); err != nil {
+		t.Errorf("expected bob to reserve the same key, got %v", err)
+	}
+
+	pending, err := s.Idempotency.FindByKey(ctx, alice.ID, "key-1")
+	if err != nil {
+		t.Fatalf("FindByKey() error = %v", err)
+	}
+	if pending.Completed() || pending.Fingerprint != rec.Fingerprint {
+		t.Errorf("expected a pending record with the request fingerprint, got %+v", pending)
+	}
+
+	rec.Status = 201
+	rec.Header = map[string][]string{"Content-Type": {"application/json"}, "Location": {"/api/books/1"}}
+	rec.Body = []byte(`{"success":true}`)
+	if err := s.Idempotency.Complete(ctx, rec); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if err := s.Idempotency.Complete(ctx, rec); !errors.Is(err, idempotency.ErrNotFound) {
+		t.Errorf("expected ErrNotFound completing twice, got %v", err)
+	}
+
+	got, err := s.Idempotency.FindByKey(ctx, alice.ID, "key-1")
+	if err != nil {
+		t.Fatalf("FindByKey() error = %v", err)
+	}
+	if got.Status != 201 || string(got.Body) != string(rec.Body) ||
+		!slices.Equal(got.Header["Location"], rec.Header["Location"]) || !got.ExpiresAt.Equal(rec.ExpiresAt) {
+		t.Errorf("stored response mismatch: got %+v", got)
+	}
+
+	// A completed record is not released
+	if err := s.Idempotency.Release(ctx, alice.ID, "key-1"); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if _, err := s.Idempotency.FindByKey(ctx, alice.ID, "key-1"); err != nil {
+		t.Errorf("expected the completed record to stay, got %v", err)
+	}
+}
+
+func testIdempotencyRelease(t *testing.T, s Setup) {
+	ctx := context.Background()
+	u := saveUser(t, s, "reader@example.com")
+
+	if err := s.Idempotency.Reserve(ctx, newRecord(u.ID, "key-1", time.Hour)); err != nil {
+		t.Fatalf("Reserve() error = %v", err)
+	}
+	if err := s.Idempotency.Release(ctx, u.ID, "key-1"); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if _, err := s.Idempotency.FindByKey(ctx, u.ID, "key-1"); !errors.Is(err, idempotency.ErrNotFound) {
+		t.Errorf("expected ErrNotFound after release, got %v", err)
+	}
+	if err := s.Idempotency.Reserve(ctx, newRecord(u.ID, "key-1", time.Hour)); err != nil {
+		t.Errorf("expected a released key to be reserved again, got %v", err)
+	}
+}
+
+func testIdempotencyExpiry(t *testing.T, s Setup) {
+	ctx := context.Background()
+	u := saveUser(t, s, "reader@example.com")
+
+	expired := newRecord(u.ID, "old", time.Hour)
+	expired.CreatedAt = now().Add(-2 * time.Hour)
+	expired.ExpiresAt = now().Add(-time.Hour)
+	if err := s.Idempotency.Reserve(ctx, expired); err != nil {
+		t.Fatalf("Reserve() error = %v", err)
+	}
+	if err := s.Idempotency.Reserve(ctx, newRecord(u.ID, "live", time.Hour)); err != nil {
+		t.Fatalf("Reserve() error = %v", err)
+	}
+
+	if _, err := s.Idempotency.FindByKey(ctx, u.ID, "old"); !errors.Is(err, idempotency.ErrNotFound) {
+		t.Errorf("expected an expired record to be invisible, got %v", err)
+	}
+
+	n, err := s.Idempotency.DeleteExpired(ctx, time.Now())
+	if err != nil {
+		t.Fatalf("DeleteExpired() error = %v", err)
+	}
+	if n != 1 {
+		t.Errorf("expected 1 expired record deleted, got %d", n)
+	}
+	if _, err := s.Idempotency.FindByKey(ctx, u.ID, "live"); err != nil {
+		t.Errorf("expected the live record to stay, got %v", err)
+	}
+
+	// An expired key can be reused before it is cleaned up
+	stale := newRecord(u.ID, "stale", time.Hour)
+	stale.CreatedAt = now().Add(-2 * time.Hour)
+	stale.ExpiresAt = now().Add(-time.Hour)
+	if err := s.Idempotency.Reserve(ctx, stale); err != nil {
+		t.Fatalf("Reserve() error = %v", err)
+	}
+	if err := s.Idempotency.Reserve(ctx, newRecord(u.ID, "stale", time.Hour)); err != nil {
+		t.Errorf("expected an expired key to be reserved again, got %v", err)
+	}
+}
+
+func testAuditAppendAndList(t *testing.T, s Setup) {
+	ctx := context.Background()
+	u := saveUser(t, s, "reader@example.com")
+
+	ts := now()
+	created := newEvent(u.ID, audit.ActionBookCreate, ts)
+	created.ActorID = u.ID
+	created.TargetType, created.TargetID = audit.TargetBook, uuid.NewString()
+	created.IP, created.UserAgent, created.RequestID = "192.0.2.1", "test-agent", "req-1"
+	created.Changes = map[string]audit.Change{"title": {To: "Dune"}, "version": {From: 1.0, To: 2.0}}
+	created.Details = map[string]string{"source": "test"}
+	failed := newEvent("", audit.ActionLoginFailed, ts.Add(time.Second))
+
+	if err := s.Audit.Append(ctx, created, failed); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+	if err := s.Audit.Append(ctx); err != nil {
+		t.Fatalf("Append() with no events error = %v", err)
+	}
+
+	events, err := s.Audit.List(ctx, audit.Filter{})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(events) != 2 || events[0].ID != failed.ID || events[1].ID != created.ID {
+		t.Fatalf("expected both events newest first, got %+v", events)
+	}
+	if events[0].UserID != "" || events[0].ActorID != "" || len(events[0].Changes) != 0 {
+		t.Errorf("expected an anonymous event without changes, got %+v", events[0])
+	}
+
+	got := events[1]
+	if got.Action != created.Action || got.UserID != u.ID || got.ActorID != u.ID ||
+		got.TargetType != created.TargetType || got.TargetID != created.TargetID ||
+		got.IP != created.IP || got.UserAgent != created.UserAgent || got.RequestID != created.RequestID ||
+		!got.OccurredAt.Equal(created.OccurredAt) {
+		t.Errorf("event mismatch:\n got  %+v\n want %+v", got, created)
+	}
+	if len(got.Changes) != 2 || got.Changes["title"].To != "Dune" || got.Changes["title"].From != nil ||
+		got.Changes["version"].From != 1.0 || got.Changes["version"].To != 2.0 {
+		t.Errorf("expected changes to round-trip, got %+v", got.Changes)
+	}
+	if got.Details["source"] != "test" {
+		t.Errorf("expected details to round-trip, got %+v", got.Details)
+	}
+}
+
+func testAuditFilter(t *testing.T, s Setup) {
+	ctx := context.Background()
+	alice := saveUser(t, s, "alice@example.com")
+	bob := saveUser(t, s, "bob@example.com")
+
+	ts := now()
+	first := newEvent(alice.ID, audit.ActionLogin, ts)
+	second := newEvent(alice.ID, audit.ActionBookCreate, ts.Add(time.Second))
+	third := newEvent(alice.ID, audit.ActionLogin, ts.Add(2*time.Second))
+	other := newEvent(bob.ID, audit.ActionLogin, ts.Add(1500*time.Millisecond))
+	if err := s.Audit.Append(ctx, first, second, third, other); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		filter audit.Filter
+		want   []*audit.Event
+	}{
+		{"user", audit.Filter{UserID: alice.ID}, []*audit.Event{third, second, first}},
+		{"action", audit.Filter{UserID: alice.ID, Action: audit.ActionLogin}, []*audit.Event{third, first}},
+		{"since", audit.Filter{Since: ts.Add(time.Second)}, []*audit.Event{third, other, second}},
+		{"until is exclusive", audit.Filter{UserID: alice.ID, Until: third.OccurredAt}, []*audit.Event{second, first}},
+		{"limit", audit.Filter{UserID: alice.ID, Limit: 1}, []*audit.Event{third}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := s.Audit.List(ctx, tt.filter)
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			got := make([]string, len(events))
+			for i, e := range events {
+				got[i] = e.ID
+			}
+			want := make([]string, len(tt.want))
+			for i, e := range tt.want {
+				want[i] = e.ID
+			}
+			if !slices.Equal(got, want) {
+				t.Errorf("expected events %v, got %v", want, got)
+			}
+		})
+	}
+}
+
+func testOutboxLifecycle(t *testing.T, s Setup) {
+	ctx := context.Background()
+	ts := now()
+	first := newMessage(ts)
+	second := newMessage(ts.Add(time.Second))
+	second.NextAttemptAt = ts
+
+	err := s.UnitOfWork.Do(ctx, func(ctx context.Context, repos application.Repositories) error {
+		return repos.Outbox.Add(ctx, second, first)
+	})
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+
+	claimed, err := s.Outbox.Claim(ctx, ts, time.Minute, 10)
+	if err != nil {
+		t.Fatalf("Claim() error = %v", err)
+	}
+	if len(claimed) != 2 || claimed[0].ID != first.ID || claimed[1].ID != second.ID {
+		t.Fatalf("expected both messages oldest first, got %+v", claimed)
+	}
+	got := claimed[0]
+	var payload map[string]string
+	if err := json.Unmarshal(got.Payload, &payload); err != nil || payload["book_id"] != "b1" {
+		t.Errorf("expected the payload to round-trip, got %s (%v)", got.Payload, err)
+	}
+	if got.Name != first.Name || got.Status != event.StatusPending || got.Attempts != 1 ||
+		!got.OccurredAt.Equal(first.OccurredAt) || !got.NextAttemptAt.Equal(ts.Add(time.Minute)) {
+		t.Errorf("claimed message mismatch: %+v", got)
+	}
+
+	if again, _ := s.Outbox.Claim(ctx, ts, time.Minute, 10); len(again) != 0 {
+		t.Errorf("expected leased messages to be skipped, got %d", len(again))
+	}
+
+	if err := s.Outbox.MarkDispatched(ctx, first.ID, ts); err != nil {
+		t.Fatalf("MarkDispatched() error = %v", err)
+	}
+	if err := s.Outbox.Retry(ctx, second.ID, "boom", ts.Add(time.Second)); err != nil {
+		t.Fatalf("Retry() error = %v", err)
+	}
+	claimed, err = s.Outbox.Claim(ctx, ts.Add(time.Second), time.Minute, 10)
+	if err != nil {
+		t.Fatalf("Claim() error = %v", err)
+	}
+	if len(claimed) != 1 || claimed[0].ID != second.ID || claimed[0].Attempts != 2 || claimed[0].LastError != "boom" {
+		t.Fatalf("expected the retried message on its second attempt, got %+v", claimed)
+	}
+
+	if err := s.Outbox.Bury(ctx, second.ID, "gave up"); err != nil {
+		t.Fatalf("Bury() error = %v", err)
+	}
+	if later, _ := s.Outbox.Claim(ctx, ts.Add(time.Hour), time.Minute, 10); len(later) != 0 {
+		t.Errorf("expected no dispatched or dead messages to be claimed, got %+v", later)
+	}
+
+	if n, err := s.Outbox.DeleteDispatched(ctx, ts); err != nil || n != 0 {
+		t.Errorf("expected nothing dispatched before the cutoff, got %d, %v", n, err)
+	}
+	if n, err := s.Outbox.DeleteDispatched(ctx, ts.Add(time.Second)); err != nil || n != 1 {
+		t.Errorf("expected the dispatched message deleted, got %d, %v", n, err)
+	}
+}
+
+func testOutboxClaimLimit(t *testing.T, s Setup) {
+	ctx := context.Background()
+	ts := now()
+	msgs := []*event.Message{newMessage(ts), newMessage(ts.Add(time.Second)), newMessage(ts.Add(2 * time.Second))}
+	if err := s.Outbox.Add(ctx, msgs...); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	claimed, err := s.Outbox.Claim(ctx, ts.Add(time.Minute), time.Minute, 2)
+	if err != nil {
+		t.Fatalf("Claim() error = %v", err)
+	}
+	if len(claimed) != 2 || claimed[0].ID != msgs[0].ID || claimed[1].ID != msgs[1].ID {
+		t.Errorf("expected the two oldest messages, got %+v", claimed)
+	}
+
+	// A message not yet due stays put
+	if claimed, _ := s.Outbox.Claim(ctx, ts.Add(time.Second), time.Minute, 10); len(claimed) != 0 {
+		t.Errorf("expected the remaining message not to be due, got %+v", claimed)
+	}
+}
+
+func testOutboxRollback(t *testing.T, s Setup) {
+	ctx := context.Background()
+	errAbort := errors.New("abort")
+
+	err := s.UnitOfWork.Do(ctx, func(ctx context.Context, repos application.Repositories) error {
+		if err := repos.Outbox.Add(ctx, newMessage(now())); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("expected the function's error, got %v", err)
+	}
+
+	claimed, err := s.Outbox.Claim(ctx, now().Add(time.Minute), time.Minute, 10)
+	if err != nil {
+		t.Fatalf("Claim() error = %v", err)
+	}
+	if len(claimed) != 0 {
+		t.Errorf("expected rolled back messages, got %+v", claimed)
+	}
+}
+
+func testWebhookSaveAndFind(t *testing.T, s Setup) {
+	ctx := context.Background()
+	alice := saveUser(t, s, "alice@example.com")
+	bob := saveUser(t, s, "bob@example.com")
+
+	first := newWebhook(alice.ID, now())
+	second := newWebhook(alice.ID, now().Add(time.Second))
+	second.Events = []string{book.EventAdded}
+	for _, w := range []*webhook.Webhook{second, first, newWebhook(bob.ID, now())} {
+		if err := s.Webhooks.Save(ctx, w); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+
+	got, err := s.Webhooks.FindByIDAndUserID(ctx, first.ID, alice.ID)
+	if err != nil {
+		t.Fatalf("FindByIDAndUserID() error = %v", err)
+	}
+	if got.URL != first.URL || !slices.Equal(got.Events, first.Events) || got.Secret != first.Secret ||
+		!got.Active || !got.CreatedAt.Equal(first.CreatedAt) {
+		t.Errorf("webhook mismatch:\n got  %+v\n want %+v", got, first)
+	}
+	if _, err := s.Webhooks.FindByIDAndUserID(ctx, first.ID, bob.ID); !errors.Is(err, webhook.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for another user's webhook, got %v", err)
+	}
+
+	webhooks, err := s.Webhooks.FindByUserID(ctx, alice.ID)
+	if err != nil {
+		t.Fatalf("FindByUserID() error = %v", err)
+	}
+	if len(webhooks) != 2 || webhooks[0].ID != first.ID || webhooks[1].ID != second.ID {
+		t.Errorf("expected alice's webhooks oldest first, got %+v", webhooks)
+	}
+}
+
+func testWebhookUpdateAndDelete(t *testing.T, s Setup) {
+	ctx := context.Background()
+	alice := saveUser(t, s, "alice@example.com")
+	bob := saveUser(t, s, "bob@example.com")
+	w := newWebhook(alice.ID, now())
+	if err := s.Webhooks.Save(ctx, w); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	w.URL = "https://example.com/other"
+	w.Events = []string{book.EventDeleted}
+	w.Active = false
+	w.UpdatedAt = now().Add(time.Minute)
+	if err := s.Webhooks.Update(ctx, w); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	got, err := s.Webhooks.FindByIDAndUserID(ctx, w.ID, alice.ID)
+	if err != nil {
+		t.Fatalf("FindByIDAndUserID() error = %v", err)
+	}
+	if got.URL != w.URL || !slices.Equal(got.Events, w.Events) || got.Active || !got.UpdatedAt.Equal(w.UpdatedAt) {
+		t.Errorf("expected the update to be saved, got %+v", got)
+	}
+
+	stolen := *w
+	stolen.UserID = bob.ID
+	if err := s.Webhooks.Update(ctx, &stolen); !errors.Is(err, webhook.ErrNotFound) {
+		t.Errorf("expected ErrNotFound updating another user's webhook, got %v", err)
+	}
+	if err := s.Webhooks.Delete(ctx, w.ID, bob.ID); !errors.Is(err, webhook.ErrNotFound) {
+		t.Errorf("expected ErrNotFound deleting another user's webhook, got %v", err)
+	}
+	if err := s.Webhooks.Delete(ctx, w.ID, alice.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := s.Webhooks.FindByIDAndUserID(ctx, w.ID, alice.ID); !errors.Is(err, webhook.ErrNotFound) {
+		t.Errorf("expected the webhook to be gone, got %v", err)
+	}
+}
+
+func testWebhookMalformedID(t *testing.T, s Setup) {
+	ctx := context.Background()
+	u := saveUser(t, s, "alice@example.com")
+	w := newWebhook(u.ID, now())
+	if err := s.Webhooks.Save(ctx, w); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	if _, err := s.Webhooks.FindByIDAndUserID(ctx, malformedID, u.ID); !errors.Is(err, webhook.ErrNotFound) {
+		t.Errorf("FindByIDAndUserID() expected ErrNotFound, got %v", err)
+	}
+	stray := *w
+	stray.ID = malformedID
+	if err := s.Webhooks.Update(ctx, &stray); !errors.Is(err, webhook.ErrNotFound) {
+		t.Errorf("Update() expected ErrNotFound, got %v", err)
+	}
+	if err := s.Webhooks.Delete(ctx, malformedID, u.ID); !errors.Is(err, webhook.ErrNotFound) {
+		t.Errorf("Delete() expected ErrNotFound, got %v", err)
+	}
+}
+
+func testWebhookDeliveries(t *testing.T, s Setup) {
+	ctx := context.Background()
+	u := saveUser(t, s, "reader@example.com")
+	w := newWebhook(u.ID, now())
+	if err := s.Webhooks.Save(ctx, w); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	ts := now()
+	failed := newDelivery(w.ID, "e1", ts, false)
+	failed.StatusCode, failed.Error = 500, "receiver returned 500"
+	retried := newDelivery(w.ID, "e1", ts.Add(time.Second), true)
+	retried.Attempt = 2
+	other := newDelivery(w.ID, "e2", ts.Add(2*time.Second), false)
+	for _, d := range []*webhook.Delivery{failed, retried, other} {
+		if err := s.Webhooks.SaveDelivery(ctx, d); err != nil {
+			t.Fatalf("SaveDelivery() error = %v", err)
+		}
+	}
+
+	deliveries, err := s.Webhooks.FindDeliveries(ctx, w.ID, 2)
+	if err != nil {
+		t.Fatalf("FindDeliveries() error = %v", err)
+	}
+	if len(deliveries) != 2 || deliveries[0].ID != other.ID || deliveries[1].ID != retried.ID {
+		t.Fatalf("expected the two newest deliveries, got %+v", deliveries)
+	}
+	if got := deliveries[1]; got.EventID != "e1" || got.Event != book.EventAdded || got.Attempt != 2 ||
+		got.StatusCode != 200 || !got.Success || got.DurationMS != 42 || !got.CreatedAt.Equal(retried.CreatedAt) {
+		t.Errorf("delivery mismatch:\n got  %+v\n want %+v", got, retried)
+	}
+
+	for eventID, want := range map[string]bool{"e1": true, "e2": false, "e3": false} {
+		if got, err := s.Webhooks.Delivered(ctx, w.ID, eventID); err != nil || got != want {
+			t.Errorf("Delivered(%s) = %v, %v; want %v", eventID, got, err, want)
+		}
+	}
+
+	if n, err := s.Webhooks.DeleteDeliveriesBefore(ctx, ts.Add(time.Second)); err != nil || n != 1 {
+		t.Errorf("expected the oldest delivery deleted, got %d, %v", n, err)
+	}
+	if err := s.Webhooks.Delete(ctx, w.ID, u.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if deliveries, _ := s.Webhooks.FindDeliveries(ctx, w.ID, 10); len(deliveries) != 0 {
+		t.Errorf("expected deliveries removed with the webhook, got %+v", deliveries)
+	}
+}
+
+func testUnitOfWorkCommit(t *testing.T, s Setup) {
+	ctx := context.Background()
+	u := newUser("reader@example.com")
+	b := newBook(u.ID, "g1", book.StatusToRead)
+
+	err := s.UnitOfWork.Do(ctx, func(ctx context.Context, repos application.Repositories) error {
+		if err := repos.Users.Save(ctx, u); err != nil {
+			return err
+		}
+		// Reads inside the unit of work see its own writes
+		if _, err := repos.Users.FindByID(ctx, u.ID); err != nil {
+			return err
+		}
+		return repos.Books.Save(ctx, b)
+	})
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+
+	if _, err := s.Users.FindByID(ctx, u.ID); err != nil {
+		t.Errorf("expected committed user, got %v", err)
+	}
+	if _, err := s.Books.FindByID(ctx, b.ID); err != nil {
+		t.Errorf("expected committed book, got %v", err)
+	}
+}
+
+func testUnitOfWorkRollback(t *testing.T, s Setup) {
+	ctx := context.Background()
+	existing := saveUser(t, s, "alice@example.com")
+	u := newUser("reader@example.com")
+	errAbort := errors.New("abort")
+
+	err := s.UnitOfWork.Do(ctx, func(ctx context.Context, repos application.Repositories) error {
+		if err := repos.Users.Save(ctx, u); err != nil {
+			return err
+		}
+		existing.Name = "Changed"
+		if err := repos.Users.Update(ctx, existing); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("expected the function's error, got %v", err)
+	}
+
+	if _, err := s.Users.FindByID(ctx, u.ID); !errors.Is(err, user.ErrNotFound) {
+		t.Errorf("expected rolled back insert, got %v", err)
+	}
+	got, err := s.Users.FindByID(ctx, existing.ID)
+	if err != nil {
+		t.Fatalf("FindByID() error = %v", err)
+	}
+	if got.Name == "Changed" {
+		t.Error("expected rolled back update")
+	}
+}
+
+// now returns a timestamp every store keeps exactly
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+func newUser(email string) *user.User {
+	ts := now()
+	return &user.User{
+		ID:        uuid.NewString(),
+		Email:     email,
+		Password:  "$2a$10$hash",
+		Name:      "Reader",
+		Genres:    []string{"fiction"},
+		CreatedAt: ts,
+		UpdatedAt: ts,
+	}
+}
+
+func newRecord(userID, key string, ttl time.Duration) *idempotency.Record {
+	ts := now()
+	return &idempotency.Record{
+		UserID:      userID,
+		Key:         key,
+		Fingerprint: "fingerprint-" + key,
+		CreatedAt:   ts,
+		ExpiresAt:   ts.Add(ttl),
+	}
+}
+
+func newEvent(userID string, action audit.Action, at time.Time) *audit.Event {
+	return &audit.Event{
+		ID:         uuid.NewString(),
+		OccurredAt: at,
+		Action:     action,
+		UserID:     userID,
+	}
+}
+
+func newMessage(at time.Time) *event.Message {
+	return &event.Message{
+		ID:            uuid.NewString(),
+		Name:          "book.added",
+		Payload:       []byte(`{"book_id":"b1"}`),
+		OccurredAt:    at,
+		Status:        event.StatusPending,
+		NextAttemptAt: at,
+	}
+}
+
+func newWebhook(userID string, at time.Time) *webhook.Webhook {
+	return &webhook.Webhook{
+		ID:        uuid.NewString(),
+		UserID:    userID,
+		URL:       "https://example.com/hook",
+		Events:    []string{book.EventAdded, book.EventStatusChanged},
+		Secret:    "whsec_" + uuid.NewString(),
+		Active:    true,
+		CreatedAt: at,
+		UpdatedAt: at,
+	}
+}
+
+func newDelivery(webhookID, eventID string, at time.Time, success bool) *webhook.Delivery {
+	d := &webhook.Delivery{
+		ID:         uuid.NewString(),
+		WebhookID:  webhookID,
+		EventID:    eventID,
+		Event:      book.EventAdded,
+		Attempt:    1,
+		DurationMS: 42,
+		Success:    success,
+		CreatedAt:  at,
+	}
+	if success {
+		d.StatusCode = 200
+	}
+	return d
+}
+
+func newBook(userID, googleID string, status book.Status) *book.Book {
+	ts := now()
+	return &book.Book{
+		ID:          uuid.NewString(),
+		GoogleID:    googleID,
+		Title:       "Dune",
+		Authors:     []string{"Frank Herbert"},
+		Description: "Desert planet",
+		Categories:  []string{"Fiction", "Science Fiction"},
+		ImageURL:    "https://books.google.com/dune.jpg",
+		ISBN:        "9780441013593",
+		Status:      status,
+		UserID:      userID,
+		CreatedAt:   ts,
+		UpdatedAt:   ts,
+		Version:     1,
+	}
+}
+
+func saveUser(t *testing.T, s Setup, email string) *user.User {
+	t.Helper()
+	u := newUser(email)
+	if err := s.Users.Save(context.Background(), u); err != nil {
+		t.Fatalf("saving user: %v", err)
+	}
+	return u
+}
+
+func saveBook(t *testing.T, s Setup, userID, googleID string, status book.Status) *book.Book {
+	t.Helper()
+	b := newBook(userID, googleID, status)
+	if err := s.Books.Save(context.Background(), b); err != nil {
+		t.Fatalf("saving book: %v", err)
+	}
+	return b
+}
+
+func assertUser(t *testing.T, want, got *user.User) {
+	t.Helper()
+	if got.ID != want.ID || got.Email != want.Email || got.Password != want.Password || got.Name != want.Name ||
+		!slices.Equal(got.Genres, want.Genres) || !got.CreatedAt.Equal(want.CreatedAt) || !got.UpdatedAt.Equal(want.UpdatedAt) {
+		t.Errorf("user mismatch:\n got  %+v\n want %+v", got, want)
+	}
+}
+
+func assertBook(t *testing.T, want, got *book.Book) {
+	t.Helper()
+	if got.ID != want.ID || got.GoogleID != want.GoogleID || got.Title != want.Title ||
+		!slices.Equal(got.Authors, want.Authors) || got.Description != want.Description ||
+		!slices.Equal(got.Categories, want.Categories) || got.ImageURL != want.ImageURL || got.ISBN != want.ISBN ||
+		got.Status != want.Status || got.UserID != want.UserID || got.Version != want.Version ||
+		!got.CreatedAt.Equal(want.CreatedAt) || !got.UpdatedAt.Equal(want.UpdatedAt) {
+		t.Errorf("book mismatch:\n got  %+v\n want %+v", got, want)
+	}
+}
+
+// assertIDs checks that books holds exactly the given IDs in any order
+func assertIDs(t *testing.T, books []*book.Book, ids ...string) {
+	t.Helper()
+	got := make([]string, len(books))
+	for i, b := range books {
+		got[i] = b.ID
+	}
+	slices.Sort(got)
+	want := slices.Clone(ids)
+	slices.Sort(want)
+	if !slices.Equal(got, want) {
+		t.Errorf("expected books %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,170 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/guisithos/save-my-read/internal/domain/audit"
+	"github.com/guisithos/save-my-read/internal/tracing"
+)
+
+// AuditStore implements the audit.Store interface using SQLite
+type AuditStore struct {
+	db           dbtx
+	queryTimeout time.Duration
+}
+
+// NewAuditStore creates a new SQLite audit store
+func NewAuditStore(db *sql.DB, queryTimeout time.Duration) *AuditStore {
+	return &AuditStore{db: db, queryTimeout: queryTimeout}
+}
+
+const auditColumns = `id, occurred_at, action, user_id, actor_id, target_type, target_id, ip, user_agent, request_id, changes, details`
+
+// Append inserts events in a single statement
+func (s *AuditStore) Append(ctx context.Context, events ...*audit.Event) (err error) {
+	if len(events) == 0 {
+		return nil
+	}
+
+	var query strings.Builder
+	query.WriteString("INSERT INTO audit_events (" + auditColumns + ") VALUES ")
+	args := make([]interface{}, 0, len(events)*12)
+	for i, e := range events {
+		changes, err := json.Marshal(e.Changes)
+		if err != nil {
+			return fmt.Errorf("error encoding changes: %w", err)
+		}
+		details, err := json.Marshal(e.Details)
+		if err != nil {
+			return fmt.Errorf("error encoding details: %w", err)
+		}
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		query.WriteString("(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+		args = append(args,
+			e.ID,
+			e.OccurredAt.UTC(),
+			string(e.Action),
+			nullString(e.UserID),
+			nullString(e.ActorID),
+			e.TargetType,
+			e.TargetID,
+			e.IP,
+			e.UserAgent,
+			e.RequestID,
+			jsonObject(changes),
+			jsonObject(details),
+		)
+	}
+
+	ctx, span := startSpan(ctx, "INSERT", "audit_events", query.String())
+	defer func() { tracing.End(span, err) }()
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	if _, err = s.db.ExecContext(ctx, query.String(), args...); err != nil {
+		return fmt.Errorf("error appending audit events: %w", queryError(ctx, err))
+	}
+
+	return nil
+}
+
+// List returns the events matching f, newest first
+func (s *AuditStore) List(ctx context.Context, f audit.Filter) (_ []*audit.Event, err error) {
+	var where []string
+	var args []interface{}
+	add := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		where = append(where, cond)
+	}
+	if f.UserID != "" {
+		add("user_id = ?", f.UserID)
+	}
+	if f.Action != "" {
+		add("action = ?", string(f.Action))
+	}
+	if !f.Since.IsZero() {
+		add("occurred_at >= ?", f.Since.UTC())
+	}
+	if !f.Until.IsZero() {
+		add("occurred_at < ?", f.Until.UTC())
+	}
+
+	query := "SELECT " + auditColumns + " FROM audit_events"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY occurred_at DESC, id"
+	if f.Limit > 0 {
+		args = append(args, f.Limit)
+		query += " LIMIT ?"
+	}
+
+	ctx, span := startSpan(ctx, "SELECT", "audit_events", query)
+	defer func() { tracing.End(span, err) }()
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error listing audit events: %w", queryError(ctx, err))
+	}
+	defer rows.Close()
+
+	var events []*audit.Event
+	for rows.Next() {
+		e := &audit.Event{}
+		var action string
+		var userID, actorID sql.NullString
+		var changes, details string
+		if err := rows.Scan(
+			&e.ID,
+			&e.OccurredAt,
+			&action,
+			&userID,
+			&actorID,
+			&e.TargetType,
+			&e.TargetID,
+			&e.IP,
+			&e.UserAgent,
+			&e.RequestID,
+			&changes,
+			&details,
+		); err != nil {
+			return nil, fmt.Errorf("error scanning audit event: %w", queryError(ctx, err))
+		}
+		e.Action = audit.Action(action)
+		e.UserID, e.ActorID = userID.String, actorID.String
+		if err := json.Unmarshal([]byte(changes), &e.Changes); err != nil {
+			return nil, fmt.Errorf("error decoding changes: %w", err)
+		}
+		if err := json.Unmarshal([]byte(details), &e.Details); err != nil {
+			return nil, fmt.Errorf("error decoding details: %w", err)
+		}
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating audit events: %w", queryError(ctx, err))
+	}
+
+	return events, nil
+}
+
+// nullString stores an empty string as NULL
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// jsonObject stores a nil map, encoded as null, as an empty object
+func jsonObject(data []byte) string {
+	if string(data) == "null" {
+		return "{}"
+	}
+	return string(data)
+}
@@ -0,0 +1,316 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/guisithos/save-my-read/internal/domain/book"
+	"github.com/guisithos/save-my-read/internal/tracing"
+)
+
+// bookColumns lists the columns read by every book query, in scan order
+const bookColumns = `id, google_id, title, authors, description, categories,
+			   image_url, isbn, status, user_id, created_at, updated_at, version, deleted_at`
+
+// BookRepository implements the book.Repository interface using SQLite
+type BookRepository struct {
+	db           dbtx
+	queryTimeout time.Duration
+}
+
+// NewBookRepository creates a new SQLite book repository
+func NewBookRepository(db *sql.DB, queryTimeout time.Duration) *BookRepository {
+	return &BookRepository{db: db, queryTimeout: queryTimeout}
+}
+
+// Save stores a new book in the database
+func (r *BookRepository) Save(ctx context.Context, b *book.Book) (err error) {
+	query := `
+		INSERT INTO books (
+			id, google_id, title, authors, description, categories,
+			image_url, isbn, status, user_id, created_at, updated_at, version
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	ctx, span := startSpan(ctx, "INSERT", "books", query)
+	defer func() { tracing.End(span, err) }()
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	_, err = r.db.ExecContext(
+		ctx,
+		query,
+		b.ID,
+		b.GoogleID,
+		b.Title,
+		jsonArray(&b.Authors),
+		b.Description,
+		jsonArray(&b.Categories),
+		b.ImageURL,
+		b.ISBN,
+		b.Status,
+		b.UserID,
+		b.CreatedAt.UTC(),
+		b.UpdatedAt.UTC(),
+		b.Version,
+	)
+
+	if err != nil {
+		if isUniqueViolation(err) {
+			return book.ErrDuplicate
+		}
+		return fmt.Errorf("error saving book: %w", queryError(ctx, err))
+	}
+
+	return nil
+}
+
+// FindByID retrieves a book by its ID
+func (r *BookRepository) FindByID(ctx context.Context, id string) (*book.Book, error) {
+	query := `SELECT ` + bookColumns + ` FROM books WHERE id = ? AND deleted_at IS NULL`
+
+	return r.queryBook(ctx, query, id)
+}
+
+// FindByIDAndUserID retrieves a book by its ID if it belongs to the user
+func (r *BookRepository) FindByIDAndUserID(ctx context.Context, id, userID string) (*book.Book, error) {
+	query := `SELECT ` + bookColumns + ` FROM books WHERE id = ? AND user_id = ? AND deleted_at IS NULL`
+
+	return r.queryBook(ctx, query, id, userID)
+}
+
+// FindByUserIDAndGoogleID retrieves the user's entry for a Google Books volume
+func (r *BookRepository) FindByUserIDAndGoogleID(ctx context.Context, userID, googleID string) (*book.Book, error) {
+	query := `SELECT ` + bookColumns + ` FROM books WHERE user_id = ? AND google_id = ? AND deleted_at IS NULL`
+
+	return r.queryBook(ctx, query, userID, googleID)
+}
+
+// FindByUserID retrieves all books for a user
+func (r *BookRepository) FindByUserID(ctx context.Context, userID string) ([]*book.Book, error) {
+	query := `SELECT ` + bookColumns + ` FROM books WHERE user_id = ? AND deleted_at IS NULL`
+
+	return r.queryBooks(ctx, query, userID)
+}
+
+// FindByUserIDAndStatus retrieves books for a user with specific status
+func (r *BookRepository) FindByUserIDAndStatus(ctx context.Context, userID string, status book.Status) ([]*book.Book, error) {
+	query := `SELECT ` + bookColumns + ` FROM books WHERE user_id = ? AND status = ? AND deleted_at IS NULL`
+
+	return r.queryBooks(ctx, query, userID, status)
+}
+
+// Update updates an existing book owned by b.UserID if it is still at b.Version
+func (r *BookRepository) Update(ctx context.Context, b *book.Book) (err error) {
+	query := `
+		UPDATE books
+		SET title = ?, authors = ?, description = ?, categories = ?,
+			image_url = ?, isbn = ?, status = ?, created_at = ?, updated_at = ?,
+			version = version + 1
+		WHERE id = ? AND user_id = ? AND deleted_at IS NULL AND version = ?`
+
+	ctx, span := startSpan(ctx, "UPDATE", "books", query)
+	defer func() { tracing.End(span, err) }()
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	result, err := r.db.ExecContext(
+		ctx,
+		query,
+		b.Title,
+		jsonArray(&b.Authors),
+		b.Description,
+		jsonArray(&b.Categories),
+		b.ImageURL,
+		b.ISBN,
+		b.Status,
+		b.CreatedAt.UTC(),
+		time.Now().UTC(),
+		b.ID,
+		b.UserID,
+		b.Version,
+	)
+	if err != nil {
+		return fmt.Errorf("error updating book: %w", queryError(ctx, err))
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error checking rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		// Either the book is gone or another update got there first
+		if _, err := r.FindByIDAndUserID(ctx, b.ID, b.UserID); err != nil {
+			return err
+		}
+		return book.ErrModified
+	}
+
+	b.Version++
+	return nil
+}
+
+// Delete moves a user's book to the trash
+func (r *BookRepository) Delete(ctx context.Context, id, userID string) (err error) {
+	query := `UPDATE books SET deleted_at = ? WHERE id = ? AND user_id = ? AND deleted_at IS NULL`
+
+	ctx, span := startSpan(ctx, "UPDATE", "books", query)
+	defer func() { tracing.End(span, err) }()
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, query, time.Now().UTC(), id, userID)
+	if err != nil {
+		return fmt.Errorf("error deleting book: %w", queryError(ctx, err))
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error checking rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return book.ErrNotFound
+	}
+
+	return nil
+}
+
+// FindDeletedByUserID lists the user's trash, most recently deleted first
+func (r *BookRepository) FindDeletedByUserID(ctx context.Context, userID string) ([]*book.Book, error) {
+	query := `SELECT ` + bookColumns + ` FROM books WHERE user_id = ? AND deleted_at IS NOT NULL ORDER BY deleted_at DESC`
+
+	return r.queryBooks(ctx, query, userID)
+}
+
+// Restore takes a user's book out of the trash
+func (r *BookRepository) Restore(ctx context.Context, id, userID string) (err error) {
+	query := `UPDATE books SET deleted_at = NULL, updated_at = ?, version = version + 1
+		WHERE id = ? AND user_id = ? AND deleted_at IS NOT NULL`
+
+	ctx, span := startSpan(ctx, "UPDATE", "books", query)
+	defer func() { tracing.End(span, err) }()
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, query, time.Now().UTC(), id, userID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return book.ErrDuplicate
+		}
+		return fmt.Errorf("error restoring book: %w", queryError(ctx, err))
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error checking rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return book.ErrNotFound
+	}
+
+	return nil
+}
+
+// PurgeDeleted permanently removes books trashed before cutoff
+func (r *BookRepository) PurgeDeleted(ctx context.Context, cutoff time.Time) (_ []string, err error) {
+	query := `DELETE FROM books WHERE deleted_at < ? RETURNING id`
+
+	ctx, span := startSpan(ctx, "DELETE", "books", query)
+	defer func() { tracing.End(span, err) }()
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, cutoff.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("error purging books: %w", queryError(ctx, err))
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("error scanning purged book: %w", queryError(ctx, err))
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating purged books: %w", queryError(ctx, err))
+	}
+
+	return ids, nil
+}
+
+func (r *BookRepository) queryBook(ctx context.Context, query string, args ...interface{}) (_ *book.Book, err error) {
+	ctx, span := startSpan(ctx, "SELECT", "books", query)
+	defer func() { tracing.End(span, err) }()
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	b, err := scanBook(r.db.QueryRowContext(ctx, query, args...))
+	if err == sql.ErrNoRows {
+		return nil, book.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error finding book: %w", queryError(ctx, err))
+	}
+
+	return b, nil
+}
+
+func (r *BookRepository) queryBooks(ctx context.Context, query string, args ...interface{}) (_ []*book.Book, err error) {
+	ctx, span := startSpan(ctx, "SELECT", "books", query)
+	defer func() { tracing.End(span, err) }()
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying books: %w", queryError(ctx, err))
+	}
+	defer rows.Close()
+
+	var books []*book.Book
+	for rows.Next() {
+		b, err := scanBook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning book: %w", queryError(ctx, err))
+		}
+		books = append(books, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating books: %w", queryError(ctx, err))
+	}
+
+	return books, nil
+}
+
+// scanner is implemented by both *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanBook(row scanner) (*book.Book, error) {
+	b := &book.Book{}
+	var description, imageURL sql.NullString
+	var deletedAt sql.NullTime
+	err := row.Scan(
+		&b.ID, &b.GoogleID, &b.Title, jsonArray(&b.Authors), &description,
+		jsonArray(&b.Categories), &imageURL, &b.ISBN, &b.Status, &b.UserID,
+		&b.CreatedAt, &b.UpdatedAt, &b.Version, &deletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	b.Description = description.String
+	b.ImageURL = imageURL.String
+	if deletedAt.Valid {
+		b.DeletedAt = &deletedAt.Time
+	}
+	return b, nil
+}
@@ -0,0 +1,166 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/guisithos/save-my-read/internal/domain/idempotency"
+	"github.com/guisithos/save-my-read/internal/tracing"
+)
+
+// IdempotencyStore implements the idempotency.Store interface using SQLite
+type IdempotencyStore struct {
+	db           dbtx
+	queryTimeout time.Duration
+}
+
+// NewIdempotencyStore creates a new SQLite idempotency store
+func NewIdempotencyStore(db *sql.DB, queryTimeout time.Duration) *IdempotencyStore {
+	return &IdempotencyStore{db: db, queryTimeout: queryTimeout}
+}
+
+// Reserve stores rec as in progress unless a live record holds its key.
+// An expired record is replaced in the same statement.
+func (s *IdempotencyStore) Reserve(ctx context.Context, rec *idempotency.Record) (err error) {
+	query := `
+		INSERT INTO idempotency_keys (user_id, key, fingerprint, status, headers, body, created_at, expires_at)
+		VALUES (?, ?, ?, 0, '{}', NULL, ?, ?)
+		ON CONFLICT (user_id, key) DO UPDATE
+		SET fingerprint = EXCLUDED.fingerprint, status = 0, headers = '{}', body = NULL,
+			created_at = EXCLUDED.created_at, expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= EXCLUDED.created_at`
+
+	ctx, span := startSpan(ctx, "INSERT", "idempotency_keys", query)
+	defer func() { tracing.End(span, err) }()
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, query, rec.UserID, rec.Key, rec.Fingerprint, rec.CreatedAt.UTC(), rec.ExpiresAt.UTC())
+	if err != nil {
+		return fmt.Errorf("error reserving idempotency key: %w", queryError(ctx, err))
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error checking rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return idempotency.ErrExists
+	}
+
+	return nil
+}
+
+// FindByKey retrieves the user's live record for key
+func (s *IdempotencyStore) FindByKey(ctx context.Context, userID, key string) (_ *idempotency.Record, err error) {
+	query := `
+		SELECT user_id, key, fingerprint, status, headers, body, created_at, expires_at
+		FROM idempotency_keys
+		WHERE user_id = ? AND key = ? AND expires_at > ?`
+
+	ctx, span := startSpan(ctx, "SELECT", "idempotency_keys", query)
+	defer func() { tracing.End(span, err) }()
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	rec := &idempotency.Record{}
+	var headers string
+	err = s.db.QueryRowContext(ctx, query, userID, key, time.Now().UTC()).Scan(
+		&rec.UserID,
+		&rec.Key,
+		&rec.Fingerprint,
+		&rec.Status,
+		&headers,
+		&rec.Body,
+		&rec.CreatedAt,
+		&rec.ExpiresAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, idempotency.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error finding idempotency key: %w", queryError(ctx, err))
+	}
+
+	if err := json.Unmarshal([]byte(headers), &rec.Header); err != nil {
+		return nil, fmt.Errorf("error decoding stored headers: %w", err)
+	}
+
+	return rec, nil
+}
+
+// Complete stores the response held by rec for a reserved key
+func (s *IdempotencyStore) Complete(ctx context.Context, rec *idempotency.Record) (err error) {
+	query := `
+		UPDATE idempotency_keys
+		SET status = ?, headers = ?, body = ?
+		WHERE user_id = ? AND key = ? AND status = 0`
+
+	headers, err := json.Marshal(rec.Header)
+	if err != nil {
+		return fmt.Errorf("error encoding headers: %w", err)
+	}
+
+	ctx, span := startSpan(ctx, "UPDATE", "idempotency_keys", query)
+	defer func() { tracing.End(span, err) }()
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, query, rec.Status, string(headers), rec.Body, rec.UserID, rec.Key)
+	if err != nil {
+		return fmt.Errorf("error completing idempotency key: %w", queryError(ctx, err))
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error checking rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return idempotency.ErrNotFound
+	}
+
+	return nil
+}
+
+// Release drops a reserved key that has no response yet
+func (s *IdempotencyStore) Release(ctx context.Context, userID, key string) (err error) {
+	query := `DELETE FROM idempotency_keys WHERE user_id = ? AND key = ? AND status = 0`
+
+	ctx, span := startSpan(ctx, "DELETE", "idempotency_keys", query)
+	defer func() { tracing.End(span, err) }()
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	if _, err = s.db.ExecContext(ctx, query, userID, key); err != nil {
+		return fmt.Errorf("error releasing idempotency key: %w", queryError(ctx, err))
+	}
+
+	return nil
+}
+
+// DeleteExpired removes records that expired before now
+func (s *IdempotencyStore) DeleteExpired(ctx context.Context, now time.Time) (_ int64, err error) {
+	query := `DELETE FROM idempotency_keys WHERE expires_at <= ?`
+
+	ctx, span := startSpan(ctx, "DELETE", "idempotency_keys", query)
+	defer func() { tracing.End(span, err) }()
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, query, now.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("error deleting expired idempotency keys: %w", queryError(ctx, err))
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error checking rows affected: %w", err)
+	}
+
+	return rows, nil
+}
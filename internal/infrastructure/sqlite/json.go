@@ -0,0 +1,44 @@
+package sqlite
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// jsonArray stores a string slice as a JSON array in a TEXT column, the
+// way pq.Array does for PostgreSQL arrays. A nil slice is stored as [].
+func jsonArray(s *[]string) stringArray {
+	return stringArray{s}
+}
+
+type stringArray struct {
+	s *[]string
+}
+
+func (a stringArray) Value() (driver.Value, error) {
+	if *a.s == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(*a.s)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (a stringArray) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	case nil:
+		*a.s = nil
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into a string array", src)
+	}
+	return json.Unmarshal(data, a.s)
+}
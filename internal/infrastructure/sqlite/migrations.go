@@ -0,0 +1,45 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// SchemaVersion returns the migration version recorded by golang-migrate
+// and whether the last migration failed part way
+func SchemaVersion(ctx context.Context, db *sql.DB) (uint, bool, error) {
+	var version int64
+	var dirty bool
+	err := db.QueryRowContext(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+
+	if err != nil && strings.Contains(err.Error(), "no such table") {
+		return 0, false, errors.New("schema_migrations table not found; migrations have not been run")
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("error reading schema version: %w", err)
+	}
+	return uint(version), dirty, nil
+}
+
+// MigrationCheck fails unless the database schema is exactly at version want
+func MigrationCheck(db *sql.DB, want uint) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		version, dirty, err := SchemaVersion(ctx, db)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return fmt.Errorf("migration %d failed part way and needs fixing", version)
+		}
+		if version != want {
+			return fmt.Errorf("schema is at version %d, expected %d", version, want)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,81 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"io/fs"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/guisithos/save-my-read/internal/domain/audit"
+	"github.com/guisithos/save-my-read/internal/infrastructure/repotest"
+	"github.com/guisithos/save-my-read/migrations"
+)
+
+func TestRepositories(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repotest.Setup {
+		db, err := Open(Scheme + "://" + filepath.Join(t.TempDir(), "test.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+		migrate(t, db)
+
+		return repotest.Setup{
+			Books:       NewBookRepository(db, 5*time.Second),
+			Users:       NewUserRepository(db, 5*time.Second),
+			UnitOfWork:  NewUnitOfWork(db, 5*time.Second),
+			Idempotency: NewIdempotencyStore(db, 5*time.Second),
+			Audit:       NewAuditStore(db, 5*time.Second),
+			Outbox:      NewOutboxStore(db, 5*time.Second),
+			Webhooks:    NewWebhookRepository(db, 5*time.Second),
+		}
+	})
+}
+
+func TestAuditStore_AppendOnly(t *testing.T) {
+	db, err := Open(Scheme + "://" + filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	migrate(t, db)
+
+	e := &audit.Event{ID: "event-1", OccurredAt: time.Now(), Action: audit.ActionLogin}
+	if err := NewAuditStore(db, 5*time.Second).Append(context.Background(), e); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`UPDATE audit_events SET action = 'tampered'`); err == nil {
+		t.Error("expected audit events to be immutable")
+	}
+	if _, err := db.Exec(`DELETE FROM audit_events`); err == nil {
+		t.Error("expected audit events not to be deleted")
+	}
+}
+
+func TestOpen_InvalidURL(t *testing.T) {
+	for _, u := range []string{"sqlite://", "postgres://localhost/db", "app.db"} {
+		if _, err := Open(u); err == nil {
+			t.Errorf("Open(%q): expected error", u)
+		}
+	}
+}
+
+// migrate applies the embedded up migrations in order, as golang-migrate would
+func migrate(t *testing.T, db *sql.DB) {
+	t.Helper()
+	files, err := fs.Glob(migrations.SQLite(), "*.up.sql")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no SQLite migrations found: %v", err)
+	}
+	for _, f := range files {
+		query, err := fs.ReadFile(migrations.SQLite(), f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.Exec(string(query)); err != nil {
+			t.Fatalf("applying %s: %v", f, err)
+		}
+	}
+}
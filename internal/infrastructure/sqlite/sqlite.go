@@ -0,0 +1,49 @@
+// Package sqlite implements the repositories on a single SQLite file, for
+// running the whole app as one process without a database server. It
+// behaves like the PostgreSQL repositories as checked by the repotest
+// contract suite; list columns are stored as JSON arrays.
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+
+	_ "modernc.org/sqlite"
+)
+
+// Scheme is the DATABASE_URL scheme that selects SQLite, as in
+// sqlite://data/save-my-read.db or sqlite:///var/lib/save-my-read.db
+const Scheme = "sqlite"
+
+// IsURL reports whether databaseURL names a SQLite database
+func IsURL(databaseURL string) bool {
+	return strings.HasPrefix(databaseURL, Scheme+"://")
+}
+
+// Open opens the database file named by a sqlite:// URL. Foreign keys are
+// enforced, the journal runs in WAL mode so reads don't wait for writes,
+// and transactions take the write lock up front so concurrent writers queue
+// on the busy timeout instead of failing.
+func Open(databaseURL string) (*sql.DB, error) {
+	path, ok := strings.CutPrefix(databaseURL, Scheme+"://")
+	if !ok || path == "" {
+		return nil, fmt.Errorf("invalid SQLite URL: expected %s://path/to/file.db", Scheme)
+	}
+	// Query parameters are meant for the migration tool, not the driver
+	path, _, _ = strings.Cut(path, "?")
+
+	params := url.Values{}
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Add("_pragma", "journal_mode(WAL)")
+	params.Add("_pragma", "busy_timeout(5000)")
+	params.Set("_txlock", "immediate")
+	params.Set("_time_format", "sqlite")
+
+	db, err := sql.Open("sqlite", "file:"+path+"?"+params.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("error opening database: %w", err)
+	}
+	return db, nil
+}
@@ -0,0 +1,60 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/guisithos/save-my-read/internal/application"
+	"github.com/guisithos/save-my-read/internal/tracing"
+)
+
+// dbtx is implemented by both *sql.DB and *sql.Tx, so repositories can run
+// on their own or inside a unit of work
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// UnitOfWork implements application.UnitOfWork with SQLite transactions
+type UnitOfWork struct {
+	db           *sql.DB
+	queryTimeout time.Duration
+}
+
+// NewUnitOfWork creates a UnitOfWork whose repositories bound each
+// statement by queryTimeout
+func NewUnitOfWork(db *sql.DB, queryTimeout time.Duration) *UnitOfWork {
+	return &UnitOfWork{db: db, queryTimeout: queryTimeout}
+}
+
+// Do runs fn in a transaction and commits it if fn succeeds. Transactions
+// take the write lock when they begin, so they run one at a time and never
+// need retrying the way PostgreSQL's serializable ones do.
+func (u *UnitOfWork) Do(ctx context.Context, fn func(ctx context.Context, repos application.Repositories) error) (err error) {
+	ctx, span := tracer.Start(ctx, "UnitOfWork")
+	defer func() { tracing.End(span, err) }()
+
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", queryError(ctx, err))
+	}
+	// Rollback after a commit is a no-op
+	defer tx.Rollback()
+
+	repos := application.Repositories{
+		Books:  &BookRepository{db: tx, queryTimeout: u.queryTimeout},
+		Users:  &UserRepository{db: tx, queryTimeout: u.queryTimeout},
+		Outbox: &OutboxStore{db: tx, queryTimeout: u.queryTimeout},
+	}
+	if err := fn(ctx, repos); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", queryError(ctx, err))
+	}
+	return nil
+}
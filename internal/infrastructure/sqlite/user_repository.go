@@ -0,0 +1,133 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/guisithos/save-my-read/internal/domain/auth"
+	"github.com/guisithos/save-my-read/internal/domain/user"
+	"github.com/guisithos/save-my-read/internal/tracing"
+)
+
+// userColumns lists the columns read by every user query, in scan order
+const userColumns = `id, email, password_hash, name, genres, created_at, updated_at`
+
+// UserRepository implements the user.Repository interface using SQLite
+type UserRepository struct {
+	db           dbtx
+	queryTimeout time.Duration
+}
+
+// NewUserRepository creates a new SQLite user repository
+func NewUserRepository(db *sql.DB, queryTimeout time.Duration) *UserRepository {
+	return &UserRepository{db: db, queryTimeout: queryTimeout}
+}
+
+// Save stores a new user, failing with auth.ErrEmailAlreadyExists when the
+// email is taken
+func (r *UserRepository) Save(ctx context.Context, u *user.User) (err error) {
+	query := `
+		INSERT INTO users (id, email, password_hash, name, genres, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`
+
+	ctx, span := startSpan(ctx, "INSERT", "users", query)
+	defer func() { tracing.End(span, err) }()
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	_, err = r.db.ExecContext(
+		ctx,
+		query,
+		u.ID,
+		u.Email,
+		u.Password,
+		u.Name,
+		jsonArray(&u.Genres),
+		u.CreatedAt.UTC(),
+		u.UpdatedAt.UTC(),
+	)
+
+	if err != nil {
+		if isUniqueViolation(err) {
+			return auth.ErrEmailAlreadyExists
+		}
+		return fmt.Errorf("error saving user: %w", queryError(ctx, err))
+	}
+
+	return nil
+}
+
+// FindByEmail retrieves a user by email
+func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*user.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE email = ?`
+
+	return r.queryUser(ctx, query, email)
+}
+
+// FindByID retrieves a user by ID
+func (r *UserRepository) FindByID(ctx context.Context, id string) (*user.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = ?`
+
+	return r.queryUser(ctx, query, id)
+}
+
+// Update updates an existing user's profile and credentials
+func (r *UserRepository) Update(ctx context.Context, u *user.User) (err error) {
+	query := `
+		UPDATE users
+		SET name = ?, email = ?, password_hash = ?, genres = ?, updated_at = ?
+		WHERE id = ?`
+
+	ctx, span := startSpan(ctx, "UPDATE", "users", query)
+	defer func() { tracing.End(span, err) }()
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, query, u.Name, u.Email, u.Password, jsonArray(&u.Genres), u.UpdatedAt.UTC(), u.ID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return auth.ErrEmailAlreadyExists
+		}
+		return fmt.Errorf("error updating user: %w", queryError(ctx, err))
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error checking rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return user.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *UserRepository) queryUser(ctx context.Context, query string, args ...interface{}) (_ *user.User, err error) {
+	ctx, span := startSpan(ctx, "SELECT", "users", query)
+	defer func() { tracing.End(span, err) }()
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	u := &user.User{}
+	err = r.db.QueryRowContext(ctx, query, args...).Scan(
+		&u.ID,
+		&u.Email,
+		&u.Password,
+		&u.Name,
+		jsonArray(&u.Genres),
+		&u.CreatedAt,
+		&u.UpdatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, user.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error finding user: %w", queryError(ctx, err))
+	}
+
+	return u, nil
+}
@@ -0,0 +1,286 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/guisithos/save-my-read/internal/domain/webhook"
+	"github.com/guisithos/save-my-read/internal/tracing"
+)
+
+// WebhookRepository implements the webhook.Repository interface using SQLite
+type WebhookRepository struct {
+	db           dbtx
+	queryTimeout time.Duration
+}
+
+// NewWebhookRepository creates a new SQLite webhook repository
+func NewWebhookRepository(db *sql.DB, queryTimeout time.Duration) *WebhookRepository {
+	return &WebhookRepository{db: db, queryTimeout: queryTimeout}
+}
+
+const (
+	webhookColumns  = `id, user_id, url, events, secret, active, created_at, updated_at`
+	deliveryColumns = `id, webhook_id, event_id, event, attempt, status_code, error, duration_ms, success, created_at`
+)
+
+// Save stores a new webhook
+func (r *WebhookRepository) Save(ctx context.Context, w *webhook.Webhook) (err error) {
+	query := `INSERT INTO webhooks (` + webhookColumns + `) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+
+	ctx, span := startSpan(ctx, "INSERT", "webhooks", query)
+	defer func() { tracing.End(span, err) }()
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	_, err = r.db.ExecContext(ctx, query,
+		w.ID,
+		w.UserID,
+		w.URL,
+		jsonArray(&w.Events),
+		w.Secret,
+		w.Active,
+		w.CreatedAt.UTC(),
+		w.UpdatedAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("error saving webhook: %w", queryError(ctx, err))
+	}
+
+	return nil
+}
+
+// FindByIDAndUserID retrieves a webhook by its ID if it belongs to the user
+func (r *WebhookRepository) FindByIDAndUserID(ctx context.Context, id, userID string) (_ *webhook.Webhook, err error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE id = ? AND user_id = ?`
+
+	ctx, span := startSpan(ctx, "SELECT", "webhooks", query)
+	defer func() { tracing.End(span, err) }()
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	w, err := scanWebhook(r.db.QueryRowContext(ctx, query, id, userID))
+	if err == sql.ErrNoRows {
+		return nil, webhook.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error finding webhook: %w", queryError(ctx, err))
+	}
+
+	return w, nil
+}
+
+// FindByUserID retrieves the user's webhooks, oldest first
+func (r *WebhookRepository) FindByUserID(ctx context.Context, userID string) (_ []*webhook.Webhook, err error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE user_id = ? ORDER BY created_at, id`
+
+	ctx, span := startSpan(ctx, "SELECT", "webhooks", query)
+	defer func() { tracing.End(span, err) }()
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying webhooks: %w", queryError(ctx, err))
+	}
+	defer rows.Close()
+
+	var webhooks []*webhook.Webhook
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning webhook: %w", queryError(ctx, err))
+		}
+		webhooks = append(webhooks, w)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhooks: %w", queryError(ctx, err))
+	}
+
+	return webhooks, nil
+}
+
+// Update saves the URL, events and active flag of a webhook owned by w.UserID
+func (r *WebhookRepository) Update(ctx context.Context, w *webhook.Webhook) (err error) {
+	query := `
+		UPDATE webhooks
+		SET url = ?, events = ?, active = ?, updated_at = ?
+		WHERE id = ? AND user_id = ?`
+
+	ctx, span := startSpan(ctx, "UPDATE", "webhooks", query)
+	defer func() { tracing.End(span, err) }()
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, query, w.URL, jsonArray(&w.Events), w.Active, w.UpdatedAt.UTC(), w.ID, w.UserID)
+	if err != nil {
+		return fmt.Errorf("error updating webhook: %w", queryError(ctx, err))
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error checking rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return webhook.ErrNotFound
+	}
+
+	return nil
+}
+
+// Delete removes a webhook owned by the user; its deliveries cascade
+func (r *WebhookRepository) Delete(ctx context.Context, id, userID string) (err error) {
+	query := `DELETE FROM webhooks WHERE id = ? AND user_id = ?`
+
+	ctx, span := startSpan(ctx, "DELETE", "webhooks", query)
+	defer func() { tracing.End(span, err) }()
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return fmt.Errorf("error deleting webhook: %w", queryError(ctx, err))
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error checking rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return webhook.ErrNotFound
+	}
+
+	return nil
+}
+
+// SaveDelivery adds a delivery to the log
+func (r *WebhookRepository) SaveDelivery(ctx context.Context, d *webhook.Delivery) (err error) {
+	query := `INSERT INTO webhook_deliveries (` + deliveryColumns + `) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	ctx, span := startSpan(ctx, "INSERT", "webhook_deliveries", query)
+	defer func() { tracing.End(span, err) }()
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	_, err = r.db.ExecContext(ctx, query,
+		d.ID,
+		d.WebhookID,
+		d.EventID,
+		d.Event,
+		d.Attempt,
+		d.StatusCode,
+		d.Error,
+		d.DurationMS,
+		d.Success,
+		d.CreatedAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("error saving webhook delivery: %w", queryError(ctx, err))
+	}
+
+	return nil
+}
+
+// FindDeliveries retrieves up to limit deliveries of a webhook, newest first
+func (r *WebhookRepository) FindDeliveries(ctx context.Context, webhookID string, limit int) (_ []*webhook.Delivery, err error) {
+	query := `
+		SELECT ` + deliveryColumns + `
+		FROM webhook_deliveries
+		WHERE webhook_id = ?
+		ORDER BY created_at DESC, id
+		LIMIT ?`
+
+	ctx, span := startSpan(ctx, "SELECT", "webhook_deliveries", query)
+	defer func() { tracing.End(span, err) }()
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, webhookID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error querying webhook deliveries: %w", queryError(ctx, err))
+	}
+	defer rows.Close()
+
+	var deliveries []*webhook.Delivery
+	for rows.Next() {
+		d := &webhook.Delivery{}
+		if err := rows.Scan(
+			&d.ID,
+			&d.WebhookID,
+			&d.EventID,
+			&d.Event,
+			&d.Attempt,
+			&d.StatusCode,
+			&d.Error,
+			&d.DurationMS,
+			&d.Success,
+			&d.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("error scanning webhook delivery: %w", queryError(ctx, err))
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhook deliveries: %w", queryError(ctx, err))
+	}
+
+	return deliveries, nil
+}
+
+// Delivered reports whether an event has been delivered to a webhook successfully
+func (r *WebhookRepository) Delivered(ctx context.Context, webhookID, eventID string) (_ bool, err error) {
+	query := `SELECT EXISTS (SELECT 1 FROM webhook_deliveries WHERE webhook_id = ? AND event_id = ? AND success)`
+
+	ctx, span := startSpan(ctx, "SELECT", "webhook_deliveries", query)
+	defer func() { tracing.End(span, err) }()
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	var delivered bool
+	if err = r.db.QueryRowContext(ctx, query, webhookID, eventID).Scan(&delivered); err != nil {
+		return false, fmt.Errorf("error checking webhook delivery: %w", queryError(ctx, err))
+	}
+
+	return delivered, nil
+}
+
+// DeleteDeliveriesBefore removes deliveries older than cutoff
+func (r *WebhookRepository) DeleteDeliveriesBefore(ctx context.Context, cutoff time.Time) (_ int64, err error) {
+	query := `DELETE FROM webhook_deliveries WHERE created_at < ?`
+
+	ctx, span := startSpan(ctx, "DELETE", "webhook_deliveries", query)
+	defer func() { tracing.End(span, err) }()
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, query, cutoff.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("error deleting webhook deliveries: %w", queryError(ctx, err))
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error checking rows affected: %w", err)
+	}
+
+	return rows, nil
+}
+
+func scanWebhook(row scanner) (*webhook.Webhook, error) {
+	w := &webhook.Webhook{}
+	err := row.Scan(
+		&w.ID,
+		&w.UserID,
+		&w.URL,
+		jsonArray(&w.Events),
+		&w.Secret,
+		&w.Active,
+		&w.CreatedAt,
+		&w.UpdatedAt,
+	)
+	return w, err
+}
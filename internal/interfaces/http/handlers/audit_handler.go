@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guisithos/save-my-read/internal/application"
+	"github.com/guisithos/save-my-read/internal/domain/audit"
+	"github.com/guisithos/save-my-read/internal/interfaces/http/middleware"
+	"github.com/guisithos/save-my-read/internal/interfaces/http/response"
+	"github.com/guisithos/save-my-read/internal/validation"
+)
+
+// auditActions lists the actions accepted by the action query parameter
+var auditActions = []string{
+	string(audit.ActionRegister),
+	string(audit.ActionLogin),
+	string(audit.ActionLoginFailed),
+	string(audit.ActionTokenIssued),
+	string(audit.ActionBookCreate),
+	string(audit.ActionBookUpdate),
+	string(audit.ActionBookDelete),
+	string(audit.ActionBookRestore),
+	string(audit.ActionBookMerge),
+	string(audit.ActionWebhookCreate),
+	string(audit.ActionWebhookUpdate),
+	string(audit.ActionWebhookDelete),
+}
+
+// AuditHandler serves the audit log
+type AuditHandler struct {
+	auditService *application.AuditService
+}
+
+// NewAuditHandler creates a new AuditHandler
+func NewAuditHandler(auditService *application.AuditService) *AuditHandler {
+	return &AuditHandler{auditService: auditService}
+}
+
+// Activity lists the authenticated user's account activity, newest first
+func (h *AuditHandler) Activity(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		response.Error(w, r, errUnauthenticated)
+		return
+	}
+
+	v := validation.New()
+	filter := auditFilter(r, v)
+	if err := v.Err(); err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	events, err := h.auditService.Activity(r.Context(), userID, filter)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+	writeEvents(w, events)
+}
+
+// Events lists the events of every user, newest first. It is only served
+// on the admin listener.
+func (h *AuditHandler) Events(w http.ResponseWriter, r *http.Request) {
+	v := validation.New()
+	filter := auditFilter(r, v)
+	if userID := r.URL.Query().Get("user_id"); userID != "" {
+		_, err := uuid.Parse(userID)
+		v.Check(err == nil, "user_id", "must be a UUID")
+		filter.UserID = userID
+	}
+	if err := v.Err(); err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	events, err := h.auditService.Events(r.Context(), filter)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+	writeEvents(w, events)
+}
+
+func writeEvents(w http.ResponseWriter, events []*audit.Event) {
+	if events == nil {
+		events = []*audit.Event{}
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	response.Success(w, http.StatusOK, events)
+}
+
+// auditFilter reads the action, since, until and limit query parameters.
+// To page back, pass the occurred_at of the oldest event received as until.
+func auditFilter(r *http.Request, v *validation.Validator) audit.Filter {
+	q := r.URL.Query()
+	action := q.Get("action")
+	v.OneOf("action", action, auditActions...)
+	filter := audit.Filter{
+		Action: audit.Action(action),
+		Since:  parseTime(v, "since", q.Get("since")),
+		Until:  parseTime(v, "until", q.Get("until")),
+	}
+	if limit := q.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		v.Check(err == nil && n >= 1 && n <= 200, "limit", "must be a number from 1 to 200")
+		filter.Limit = n
+	}
+	return filter
+}
+
+func parseTime(v *validation.Validator, field, value string) time.Time {
+	if value == "" {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	v.Check(err == nil, field, "must be an RFC 3339 timestamp")
+	return t
+}
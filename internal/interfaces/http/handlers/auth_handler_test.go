@@ -2,8 +2,8 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -11,47 +11,10 @@ import (
 	"github.com/guisithos/save-my-read/internal/application"
 	"github.com/guisithos/save-my-read/internal/domain/auth"
 	"github.com/guisithos/save-my-read/internal/domain/user"
+	"github.com/guisithos/save-my-read/internal/infrastructure/memory"
+	"github.com/guisithos/save-my-read/internal/logging"
 )
 
-var errUserNotFound = errors.New("user not found")
-
-type mockUserRepo struct {
-	users map[string]*user.User
-}
-
-func newMockUserRepo() *mockUserRepo {
-	return &mockUserRepo{users: make(map[string]*user.User)}
-}
-
-func (m *mockUserRepo) Save(user *user.User) error {
-	m.users[user.Email] = user
-	return nil
-}
-
-func (m *mockUserRepo) FindByEmail(email string) (*user.User, error) {
-	if user, exists := m.users[email]; exists {
-		return user, nil
-	}
-	return nil, errUserNotFound
-}
-
-func (m *mockUserRepo) FindByID(id string) (*user.User, error) {
-	for _, u := range m.users {
-		if u.ID == id {
-			return u, nil
-		}
-	}
-	return nil, errUserNotFound
-}
-
-func (m *mockUserRepo) Update(user *user.User) error {
-	if _, exists := m.users[user.Email]; !exists {
-		return errUserNotFound
-	}
-	m.users[user.Email] = user
-	return nil
-}
-
 type mockTokenService struct{}
 
 func (m *mockTokenService) GenerateToken(userID, email string) (string, error) {
@@ -62,11 +25,13 @@ func (m *mockTokenService) ValidateToken(tokenString string) (*auth.Claims, erro
 	return &auth.Claims{UserID: "mock_id", Email: "test@example.com"}, nil
 }
 
+// newTestAuthService creates an AuthService over store that issues mock tokens
+func newTestAuthService(store *memory.Store) *application.AuthService {
+	return application.NewAuthService(memory.NewUserRepository(store), memory.NewUnitOfWork(store), &mockTokenService{}, nil, logging.Discard())
+}
+
 func TestAuthHandler_Register(t *testing.T) {
-	repo := newMockUserRepo()
-	tokenService := &mockTokenService{}
-	authService := application.NewAuthService(repo, tokenService)
-	handler := NewAuthHandler(authService)
+	handler := NewAuthHandler(newTestAuthService(memory.NewStore()))
 
 	tests := []struct {
 		name           string
@@ -91,7 +56,7 @@ func TestAuthHandler_Register(t *testing.T) {
 				"name":     "Test User",
 				"genres":   []string{"fiction"},
 			},
-			expectedStatus: http.StatusBadRequest,
+			expectedStatus: http.StatusConflict,
 		},
 	}
 
@@ -111,14 +76,12 @@ func TestAuthHandler_Register(t *testing.T) {
 }
 
 func TestAuthHandler_Login(t *testing.T) {
-	repo := newMockUserRepo()
-	tokenService := &mockTokenService{}
-	authService := application.NewAuthService(repo, tokenService)
-	handler := NewAuthHandler(authService)
+	store := memory.NewStore()
+	handler := NewAuthHandler(newTestAuthService(store))
 
 	// Create a test user first
 	testUser, _ := user.NewUser("test@example.com", "password123", "Test User", []string{"fiction"})
-	repo.Save(testUser)
+	memory.NewUserRepository(store).Save(context.Background(), testUser)
 
 	tests := []struct {
 		name           string
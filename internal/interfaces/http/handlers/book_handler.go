@@ -1,14 +1,16 @@
 package handlers
 
 import (
-	"encoding/json"
-	"log"
+	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/guisithos/save-my-read/internal/application"
 	"github.com/guisithos/save-my-read/internal/domain/book"
 	"github.com/guisithos/save-my-read/internal/infrastructure/googlebooks"
 	"github.com/guisithos/save-my-read/internal/interfaces/http/middleware"
+	"github.com/guisithos/save-my-read/internal/interfaces/http/response"
+	"github.com/guisithos/save-my-read/internal/validation"
 )
 
 // BookHandler handles HTTP requests for book operations
@@ -27,65 +29,42 @@ func NewBookHandler(bookService *application.BookService, googleClient *googlebo
 
 // SearchBooks handles book search requests
 func (h *BookHandler) SearchBooks(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
 	query := r.URL.Query().Get("q")
-	if query == "" {
-		http.Error(w, "Query parameter 'q' is required", http.StatusBadRequest)
+	v := validation.New()
+	v.Required("q", query)
+	v.Length("q", query, 1, 256)
+	if err := v.Err(); err != nil {
+		response.Error(w, r, err)
 		return
 	}
 
-	log.Printf("Searching for books with query: %s", query)
-	books, err := h.googleClient.SearchBooks(query)
+	books, err := h.googleClient.SearchBooks(r.Context(), query)
 	if err != nil {
-		log.Printf("Search error: %v", err)
-		http.Error(w, "Failed to search books", http.StatusInternalServerError)
+		// The cause is logged but only errSearchFailed reaches the client
+		response.Error(w, r, fmt.Errorf("%w: %w", errSearchFailed, err))
 		return
 	}
 
-	log.Printf("Found %d books", len(books.Items))
-	respondJSON(w, http.StatusOK, books)
+	response.JSON(w, http.StatusOK, books)
 }
 
 // AddBook handles adding a book to user's list
 func (h *BookHandler) AddBook(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
 	// Get user ID from context
 	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		response.Error(w, r, errUnauthenticated)
 		return
 	}
 
-	var req struct {
-		GoogleBookID string   `json:"google_book_id"`
-		Title        string   `json:"title"`
-		Authors      []string `json:"authors"`
-		Description  string   `json:"description"`
-		Categories   []string `json:"categories"`
-		ImageURL     string   `json:"image_url"`
-		Status       string   `json:"status"`
-	}
-
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
-		return
-	}
-
-	status := book.Status(req.Status)
-	if !status.IsValid() {
-		http.Error(w, "Invalid book status", http.StatusBadRequest)
+	var req AddBookRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		response.Error(w, r, err)
 		return
 	}
 
 	newBook, err := h.bookService.AddBookToList(
+		r.Context(),
 		userID,
 		req.GoogleBookID,
 		req.Title,
@@ -93,105 +72,220 @@ func (h *BookHandler) AddBook(w http.ResponseWriter, r *http.Request) {
 		req.Description,
 		req.Categories,
 		req.ImageURL,
-		status,
+		req.ISBN,
+		book.Status(req.Status),
+		req.AllowSimilar,
 	)
 
+	var dupErr *book.DuplicateError
+	if errors.As(err, &dupErr) {
+		w.Header().Set("Location", "/api/books/"+dupErr.ExistingID)
+	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		response.Error(w, r, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success": true,
-		"data":    newBook,
-	})
+	w.Header().Set("Location", "/api/books/"+newBook.ID)
+	w.Header().Set("ETag", bookETag(newBook))
+	response.Success(w, http.StatusCreated, newBook)
 }
 
 // GetBooks handles retrieving user's books
 func (h *BookHandler) GetBooks(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
 	// Get user ID from context
 	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		response.Error(w, r, errUnauthenticated)
 		return
 	}
 
 	status := r.URL.Query().Get("status")
+	v := validation.New()
+	v.OneOf("status", status, bookStatuses...)
+	if err := v.Err(); err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
 	var books []*book.Book
 	var err error
 
 	if status != "" {
-		bookStatus := book.Status(status)
-		if !bookStatus.IsValid() {
-			http.Error(w, "Invalid status parameter", http.StatusBadRequest)
-			return
-		}
-		books, err = h.bookService.GetUserBooksByStatus(userID, bookStatus)
+		books, err = h.bookService.GetUserBooksByStatus(r.Context(), userID, book.Status(status))
 	} else {
-		books, err = h.bookService.GetUserBooks(userID)
+		books, err = h.bookService.GetUserBooks(r.Context(), userID)
+	}
+
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	response.Conditional(w, r, "", books)
+}
+
+// GetBook handles retrieving a single book from the user's list
+func (h *BookHandler) GetBook(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from context
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		response.Error(w, r, errUnauthenticated)
+		return
 	}
 
+	b, err := h.bookService.GetBook(r.Context(), userID, r.PathValue("id"))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		response.Error(w, r, err)
+		return
+	}
+
+	response.Conditional(w, r, bookETag(b), b)
+}
+
+// UpdateBook handles partial updates to a book; only the status can be changed.
+// The If-Match header must hold the book's current ETag.
+func (h *BookHandler) UpdateBook(w http.ResponseWriter, r *http.Request) {
+	var req UpdateBookRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		response.Error(w, r, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success": true,
-		"data":    books,
-	})
+	h.updateStatus(w, r, r.PathValue("id"), book.Status(req.Status))
 }
 
-// UpdateBookStatus handles updating a book's status
+// UpdateBookStatus handles updating a book's status with the book ID in the body
+// and its ETag in If-Match.
+//
+// Deprecated: use PATCH /api/books/{id}.
 func (h *BookHandler) UpdateBookStatus(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPut {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	var req UpdateBookStatusRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	h.updateStatus(w, r, req.BookID, book.Status(req.Status))
+}
+
+func (h *BookHandler) updateStatus(w http.ResponseWriter, r *http.Request, bookID string, status book.Status) {
+	// Get user ID from context
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		response.Error(w, r, errUnauthenticated)
+		return
+	}
+
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	updated, err := h.bookService.UpdateBookStatus(r.Context(), userID, bookID, status, version)
+	if err != nil {
+		response.Error(w, r, err)
 		return
 	}
 
-	// Get user ID from context (for future authorization checks)
-	_, ok := r.Context().Value(middleware.UserIDKey).(string)
+	w.Header().Set("ETag", bookETag(updated))
+	response.Success(w, http.StatusOK, nil)
+}
+
+// DeleteBook handles moving a book from the user's list to the trash
+func (h *BookHandler) DeleteBook(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from context
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		response.Error(w, r, errUnauthenticated)
+		return
+	}
+
+	err := h.bookService.DeleteBook(r.Context(), userID, r.PathValue("id"))
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetTrash handles listing the user's deleted books
+func (h *BookHandler) GetTrash(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from context
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		response.Error(w, r, errUnauthenticated)
+		return
+	}
+
+	books, err := h.bookService.GetTrash(r.Context(), userID)
+	if err != nil {
+		response.Error(w, r, err)
 		return
 	}
 
-	var req struct {
-		BookID string `json:"book_id"`
-		Status string `json:"status"`
+	response.Conditional(w, r, "", books)
+}
+
+// RestoreBook handles taking a book out of the trash
+func (h *BookHandler) RestoreBook(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from context
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		response.Error(w, r, errUnauthenticated)
+		return
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	b, err := h.bookService.RestoreBook(r.Context(), userID, r.PathValue("id"))
+	if err != nil {
+		response.Error(w, r, err)
 		return
 	}
 
-	status := book.Status(req.Status)
-	if !status.IsValid() {
-		http.Error(w, "Invalid book status", http.StatusBadRequest)
+	w.Header().Set("ETag", bookETag(b))
+	response.Success(w, http.StatusOK, b)
+}
+
+// MergeBook handles folding a duplicate entry into the book identified by the path
+func (h *BookHandler) MergeBook(w http.ResponseWriter, r *http.Request) {
+	var req MergeBookRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		response.Error(w, r, err)
 		return
 	}
 
-	if err := h.bookService.UpdateBookStatus(req.BookID, status); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	h.merge(w, r, r.PathValue("id"), req.SourceID)
+}
+
+// MergeBooks handles folding a duplicate entry into another one with both IDs in the body.
+//
+// Deprecated: use POST /api/books/{id}/merge.
+func (h *BookHandler) MergeBooks(w http.ResponseWriter, r *http.Request) {
+	var req MergeBooksRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		response.Error(w, r, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success": true,
-	})
+	h.merge(w, r, req.TargetID, req.SourceID)
 }
 
-func respondJSON(w http.ResponseWriter, status int, data interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(data)
+func (h *BookHandler) merge(w http.ResponseWriter, r *http.Request, targetID, sourceID string) {
+	// Get user ID from context
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		response.Error(w, r, errUnauthenticated)
+		return
+	}
+
+	merged, err := h.bookService.MergeBooks(r.Context(), userID, targetID, sourceID)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", bookETag(merged))
+	response.Success(w, http.StatusOK, merged)
 }
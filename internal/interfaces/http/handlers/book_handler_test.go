@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/guisithos/save-my-read/internal/application"
+	"github.com/guisithos/save-my-read/internal/domain/book"
+	"github.com/guisithos/save-my-read/internal/domain/user"
+	"github.com/guisithos/save-my-read/internal/infrastructure/memory"
+	"github.com/guisithos/save-my-read/internal/interfaces/http/middleware"
+	"github.com/guisithos/save-my-read/internal/logging"
+)
+
+// newTestBookService creates a BookService over store
+func newTestBookService(store *memory.Store) *application.BookService {
+	return application.NewBookService(memory.NewBookRepository(store), memory.NewUserRepository(store), memory.NewUnitOfWork(store), nil, nil, nil, logging.Discard())
+}
+
+func TestBookHandler_CrossUserAccess(t *testing.T) {
+	store := memory.NewStore()
+	userRepo := memory.NewUserRepository(store)
+	alice, _ := user.NewUser("alice@example.com", "password123", "Alice", nil)
+	bob, _ := user.NewUser("bob@example.com", "password123", "Bob", nil)
+	userRepo.Save(context.Background(), alice)
+	userRepo.Save(context.Background(), bob)
+
+	bookService := newTestBookService(store)
+	handler := NewBookHandler(bookService, nil)
+
+	aliceBook, err := bookService.AddBookToList(context.Background(), alice.ID, "g1", "Dune", []string{"Frank Herbert"}, "", nil, "", "", book.StatusToRead, false)
+	if err != nil {
+		t.Fatalf("AddBookToList() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		method  string
+		body    string
+		handler http.HandlerFunc
+	}{
+		{"get", http.MethodGet, "", handler.GetBook},
+		{"patch", http.MethodPatch, `{"status":"COMPLETED"}`, handler.UpdateBook},
+		{"delete", http.MethodDelete, "", handler.DeleteBook},
+		{"merge", http.MethodPost, `{"source_id":"unknown"}`, handler.MergeBook},
+		{"restore", http.MethodPost, "", handler.RestoreBook},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/api/books/"+aliceBook.ID, strings.NewReader(tt.body))
+			req.SetPathValue("id", aliceBook.ID)
+			req.Header.Set("If-Match", `"1"`)
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, bob.ID))
+			w := httptest.NewRecorder()
+
+			tt.handler(w, req)
+
+			if w.Code != http.StatusNotFound {
+				t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+			}
+		})
+	}
+
+	stored, _ := memory.NewBookRepository(store).FindByID(context.Background(), aliceBook.ID)
+	if stored == nil || stored.Status != book.StatusToRead {
+		t.Errorf("alice's book was modified by another user: %+v", stored)
+	}
+}
+
+func TestBookHandler_ConditionalRequests(t *testing.T) {
+	store := memory.NewStore()
+	alice, _ := user.NewUser("alice@example.com", "password123", "Alice", nil)
+	memory.NewUserRepository(store).Save(context.Background(), alice)
+
+	bookService := newTestBookService(store)
+	handler := NewBookHandler(bookService, nil)
+
+	b, err := bookService.AddBookToList(context.Background(), alice.ID, "g1", "Dune", []string{"Frank Herbert"}, "", nil, "", "", book.StatusToRead, false)
+	if err != nil {
+		t.Fatalf("AddBookToList() error = %v", err)
+	}
+
+	serve := func(h http.HandlerFunc, method, body string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/books/"+b.ID, strings.NewReader(body))
+		req.SetPathValue("id", b.ID)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, alice.ID))
+		w := httptest.NewRecorder()
+		h(w, req)
+		return w
+	}
+
+	w := serve(handler.GetBook, http.MethodGet, "", nil)
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || etag != `"1"` {
+		t.Fatalf("expected 200 with ETag \"1\", got %d and %q", w.Code, etag)
+	}
+	if w := serve(handler.GetBook, http.MethodGet, "", map[string]string{"If-None-Match": etag}); w.Code != http.StatusNotModified {
+		t.Errorf("expected 304 for a current ETag, got %d", w.Code)
+	}
+	list := serve(handler.GetBooks, http.MethodGet, "", nil)
+	listETag := list.Header().Get("ETag")
+	if w := serve(handler.GetBooks, http.MethodGet, "", map[string]string{"If-None-Match": listETag}); w.Code != http.StatusNotModified {
+		t.Errorf("expected 304 for an unchanged list, got %d", w.Code)
+	}
+
+	tests := []struct {
+		name    string
+		ifMatch string
+		status  int
+	}{
+		{"missing", "", http.StatusPreconditionRequired},
+		{"malformed", "1", http.StatusBadRequest},
+		{"weak", `W/"1"`, http.StatusBadRequest},
+		{"stale", `"7"`, http.StatusPreconditionFailed},
+		{"current", etag, http.StatusOK},
+		{"reused", etag, http.StatusPreconditionFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := map[string]string{}
+			if tt.ifMatch != "" {
+				headers["If-Match"] = tt.ifMatch
+			}
+			w := serve(handler.UpdateBook, http.MethodPatch, `{"status":"READING"}`, headers)
+			if w.Code != tt.status {
+				t.Errorf("expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+			if tt.status == http.StatusOK && w.Header().Get("ETag") != `"2"` {
+				t.Errorf("expected the new ETag \"2\", got %q", w.Header().Get("ETag"))
+			}
+		})
+	}
+
+	if w := serve(handler.GetBook, http.MethodGet, "", map[string]string{"If-None-Match": etag}); w.Code != http.StatusOK {
+		t.Errorf("expected 200 once the book changed, got %d", w.Code)
+	}
+	if w := serve(handler.GetBooks, http.MethodGet, "", map[string]string{"If-None-Match": listETag}); w.Code != http.StatusOK {
+		t.Errorf("expected 200 once the list changed, got %d", w.Code)
+	}
+}
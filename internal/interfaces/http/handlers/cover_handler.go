@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/guisithos/save-my-read/internal/application"
+	"github.com/guisithos/save-my-read/internal/domain/cover"
+	"github.com/guisithos/save-my-read/internal/interfaces/http/response"
+	"github.com/guisithos/save-my-read/internal/validation"
+)
+
+// CoverHandler serves locally cached book covers
+type CoverHandler struct {
+	coverService *application.CoverService
+}
+
+// NewCoverHandler creates a new CoverHandler
+func NewCoverHandler(coverService *application.CoverService) *CoverHandler {
+	return &CoverHandler{coverService: coverService}
+}
+
+// GetCover handles requests for a book's cover image
+func (h *CoverHandler) GetCover(w http.ResponseWriter, r *http.Request) {
+	size := cover.Size(r.URL.Query().Get("size"))
+	if size == "" {
+		size = cover.SizeOriginal
+	}
+	v := validation.New()
+	v.OneOf("size", string(size), string(cover.SizeOriginal), string(cover.SizeSmall), string(cover.SizeMedium), string(cover.SizeLarge))
+	if err := v.Err(); err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	img, err := h.coverService.GetCover(r.Context(), r.PathValue("id"), size)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	sum := sha256.Sum256(img.Data)
+	w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+	w.Header().Set("Content-Type", img.ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if img.Placeholder {
+		// Short lifetime so the real cover replaces it once it's available
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'")
+	} else {
+		w.Header().Set("Cache-Control", "public, max-age=604800")
+	}
+
+	// ServeContent answers If-None-Match and If-Modified-Since with 304
+	http.ServeContent(w, r, "", img.ModTime, bytes.NewReader(img.Data))
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/guisithos/save-my-read/internal/domain/domainerr"
+)
+
+// maxBodyBytes caps the size of JSON request bodies
+const maxBodyBytes = 1 << 20
+
+// validator is implemented by request DTOs that check their own fields
+type validator interface {
+	Validate() error
+}
+
+// decodeJSON reads a single JSON object into dst, rejecting oversized
+// bodies, unknown fields and trailing data, then validates dst if it
+// implements validator
+func decodeJSON(w http.ResponseWriter, r *http.Request, dst interface{}) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(dst); err != nil {
+		return decodeError(err)
+	}
+	if err := dec.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		return domainerr.Validation("body", "must contain a single JSON object")
+	}
+
+	if v, ok := dst.(validator); ok {
+		return v.Validate()
+	}
+	return nil
+}
+
+// decodeError turns a json.Decoder failure into a client-facing error
+func decodeError(err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var maxErr *http.MaxBytesError
+
+	switch {
+	case errors.As(err, &maxErr):
+		return domainerr.TooLarge("body_too_large", fmt.Sprintf("request body must not exceed %d bytes", maxErr.Limit))
+	case errors.As(err, &syntaxErr):
+		return domainerr.Validation("body", fmt.Sprintf("malformed JSON at offset %d", syntaxErr.Offset))
+	case errors.As(err, &typeErr):
+		return domainerr.Validation(typeErr.Field, "must be a "+typeErr.Type.String())
+	case errors.Is(err, io.EOF):
+		return domainerr.Validation("body", "must not be empty")
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return domainerr.Validation("body", "malformed JSON")
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return domainerr.Validation(field, "unknown field")
+	default:
+		return errInvalidBody
+	}
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/guisithos/save-my-read/internal/infrastructure/memory"
+	"github.com/guisithos/save-my-read/internal/interfaces/http/response"
+)
+
+func TestDecodeJSON(t *testing.T) {
+	handler := NewAuthHandler(newTestAuthService(memory.NewStore()))
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectedFields []string
+	}{
+		{
+			name:           "unknown field",
+			body:           `{"email":"a@example.com","password":"password123","name":"Ann","admin":true}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedFields: []string{"admin"},
+		},
+		{
+			name:           "trailing data",
+			body:           `{"email":"a@example.com","password":"password123","name":"Ann"}{}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedFields: []string{"body"},
+		},
+		{
+			name:           "wrong type",
+			body:           `{"email":"a@example.com","password":"password123","name":"Ann","genres":"fiction"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedFields: []string{"genres"},
+		},
+		{
+			name:           "every invalid field reported",
+			body:           `{"email":"not-an-email","password":"short","name":""}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedFields: []string{"email", "password", "name"},
+		},
+		{
+			name:           "body too large",
+			body:           `{"email":"` + strings.Repeat("a", maxBodyBytes) + `"}`,
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/auth/register", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			handler.Register(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body)
+			}
+
+			var env response.Envelope
+			if err := json.NewDecoder(w.Body).Decode(&env); err != nil {
+				t.Fatalf("failed to decode envelope: %v", err)
+			}
+			if len(env.Error.Fields) != len(tt.expectedFields) {
+				t.Fatalf("expected fields %v, got %+v", tt.expectedFields, env.Error.Fields)
+			}
+			for i, f := range env.Error.Fields {
+				if f.Field != tt.expectedFields[i] {
+					t.Errorf("expected field %q, got %q", tt.expectedFields[i], f.Field)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,11 @@
+package handlers
+
+import "github.com/guisithos/save-my-read/internal/domain/domainerr"
+
+var (
+	errUnauthenticated = domainerr.Unauthorized("unauthenticated", "authentication required")
+	errInvalidBody     = domainerr.Validation("body", "invalid JSON request body")
+	errSearchFailed    = domainerr.Upstream("search_failed", "failed to search books")
+	errIfMatchRequired = domainerr.PreconditionRequired("if_match_required", "If-Match header with the book's ETag required")
+	errInvalidIfMatch  = domainerr.Validation("If-Match", "must be a single ETag returned for the book")
+)
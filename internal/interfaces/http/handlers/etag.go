@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/guisithos/save-my-read/internal/domain/book"
+)
+
+// bookETag is the entity tag of a book, its quoted version
+func bookETag(b *book.Book) string {
+	return `"` + strconv.Itoa(b.Version) + `"`
+}
+
+// ifMatchVersion reads the book version a write is based on from the
+// If-Match header, which must hold exactly one tag from bookETag
+func ifMatchVersion(r *http.Request) (int, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" {
+		return 0, errIfMatchRequired
+	}
+
+	// Weak tags and lists can't name the single version the write is based on
+	unquoted, ok := strings.CutPrefix(header, `"`)
+	if ok {
+		unquoted, ok = strings.CutSuffix(unquoted, `"`)
+	}
+	version, err := strconv.Atoi(unquoted)
+	if !ok || err != nil || version < 1 {
+		return 0, errInvalidIfMatch
+	}
+	return version, nil
+}
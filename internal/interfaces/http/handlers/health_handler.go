@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/guisithos/save-my-read/internal/health"
+	"github.com/guisithos/save-my-read/internal/interfaces/http/response"
+	"github.com/guisithos/save-my-read/internal/logging"
+)
+
+// HealthHandler serves liveness and readiness probes
+type HealthHandler struct {
+	checker *health.Checker
+	logger  *slog.Logger
+}
+
+// NewHealthHandler creates a new HealthHandler; a nil checker always
+// reports ready and a nil logger uses slog.Default()
+func NewHealthHandler(checker *health.Checker, logger *slog.Logger) *HealthHandler {
+	return &HealthHandler{checker: checker, logger: logger}
+}
+
+// Live reports that the process is up and serving requests
+func (h *HealthHandler) Live(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	response.JSON(w, http.StatusOK, health.Report{Status: health.StatusOK, Checks: []health.Result{}})
+}
+
+// Ready reports whether the dependencies needed to serve traffic are
+// usable. Degraded non-critical dependencies still count as ready. Only the
+// status of each check is served, as the probe may be public; why a check
+// failed is logged.
+func (h *HealthHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	report := h.run(r)
+	for _, c := range report.Checks {
+		if c.Error != "" {
+			logging.OrDefault(h.logger).WarnContext(r.Context(), "readiness check failed",
+				"check", c.Name, "critical", c.Critical, "error", c.Error)
+		}
+	}
+	h.writeReady(w, report.Redacted())
+}
+
+// ReadyDetail is Ready with the error of each failing check, for the
+// admin listener
+func (h *HealthHandler) ReadyDetail(w http.ResponseWriter, r *http.Request) {
+	h.writeReady(w, h.run(r))
+}
+
+func (h *HealthHandler) run(r *http.Request) health.Report {
+	if h.checker == nil {
+		return health.Report{Status: health.StatusOK, Checks: []health.Result{}}
+	}
+	return h.checker.Run(r.Context())
+}
+
+func (h *HealthHandler) writeReady(w http.ResponseWriter, report health.Report) {
+	status := http.StatusOK
+	if report.Status == health.StatusFailing {
+		status = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	response.JSON(w, status, report)
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/guisithos/save-my-read/internal/domain/book"
+	"github.com/guisithos/save-my-read/internal/domain/webhook"
+	"github.com/guisithos/save-my-read/internal/validation"
+)
+
+// bookStatuses lists the values accepted for a book status
+var bookStatuses = []string{
+	string(book.StatusToRead),
+	string(book.StatusReading),
+	string(book.StatusCompleted),
+	string(book.StatusDNF),
+}
+
+// RegisterRequest is the body of POST /api/auth/register
+type RegisterRequest struct {
+	Email    string   `json:"email"`
+	Password string   `json:"password"`
+	Name     string   `json:"name"`
+	Genres   []string `json:"genres"`
+}
+
+func (r RegisterRequest) Validate() error {
+	v := validation.New()
+	v.Required("email", r.Email)
+	v.Length("email", r.Email, 3, 254)
+	v.Email("email", r.Email)
+	v.Required("password", r.Password)
+	// bcrypt ignores everything past 72 bytes
+	v.Length("password", r.Password, 8, 72)
+	v.Required("name", r.Name)
+	v.Length("name", r.Name, 2, 255)
+	v.Items("genres", len(r.Genres), 0, 20)
+	v.EachLength("genres", r.Genres, 50)
+	return v.Err()
+}
+
+// LoginRequest is the body of POST /api/auth/login
+type LoginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+func (r LoginRequest) Validate() error {
+	v := validation.New()
+	v.Required("email", r.Email)
+	v.Length("email", r.Email, 3, 254)
+	v.Required("password", r.Password)
+	v.Length("password", r.Password, 1, 72)
+	return v.Err()
+}
+
+// AddBookRequest is the body of POST /api/books
+type AddBookRequest struct {
+	GoogleBookID string   `json:"google_book_id"`
+	Title        string   `json:"title"`
+	Authors      []string `json:"authors"`
+	Description  string   `json:"description"`
+	Categories   []string `json:"categories"`
+	ImageURL     string   `json:"image_url"`
+	ISBN         string   `json:"isbn"`
+	Status       string   `json:"status"`
+	// AllowSimilar adds the book even if it looks like an existing entry
+	AllowSimilar bool `json:"allow_similar"`
+}
+
+func (r AddBookRequest) Validate() error {
+	v := validation.New()
+	v.Required("google_book_id", r.GoogleBookID)
+	v.Length("google_book_id", r.GoogleBookID, 1, 255)
+	v.Required("title", r.Title)
+	v.Length("title", r.Title, 1, 255)
+	v.Items("authors", len(r.Authors), 1, 20)
+	v.EachLength("authors", r.Authors, 200)
+	v.Length("description", r.Description, 0, 10000)
+	v.Items("categories", len(r.Categories), 0, 20)
+	v.EachLength("categories", r.Categories, 100)
+	v.Length("image_url", r.ImageURL, 0, 2048)
+	v.URL("image_url", r.ImageURL)
+	v.Check(r.ISBN == "" || book.NormalizeISBN(r.ISBN) != "", "isbn", "must be a valid ISBN-10 or ISBN-13")
+	v.Required("status", r.Status)
+	v.OneOf("status", r.Status, bookStatuses...)
+	return v.Err()
+}
+
+// UpdateBookRequest is the body of PATCH /api/books/{id}
+type UpdateBookRequest struct {
+	Status string `json:"status"`
+}
+
+func (r UpdateBookRequest) Validate() error {
+	v := validation.New()
+	v.Required("status", r.Status)
+	v.OneOf("status", r.Status, bookStatuses...)
+	return v.Err()
+}
+
+// UpdateBookStatusRequest is the body of the deprecated PUT /api/books/status
+type UpdateBookStatusRequest struct {
+	BookID string `json:"book_id"`
+	Status string `json:"status"`
+}
+
+func (r UpdateBookStatusRequest) Validate() error {
+	v := validation.New()
+	v.Required("book_id", r.BookID)
+	v.Required("status", r.Status)
+	v.OneOf("status", r.Status, bookStatuses...)
+	return v.Err()
+}
+
+// MergeBookRequest is the body of POST /api/books/{id}/merge
+type MergeBookRequest struct {
+	SourceID string `json:"source_id"`
+}
+
+func (r MergeBookRequest) Validate() error {
+	v := validation.New()
+	v.Required("source_id", r.SourceID)
+	return v.Err()
+}
+
+// MergeBooksRequest is the body of the deprecated POST /api/books/merge
+type MergeBooksRequest struct {
+	TargetID string `json:"target_id"`
+	SourceID string `json:"source_id"`
+}
+
+func (r MergeBooksRequest) Validate() error {
+	v := validation.New()
+	v.Required("target_id", r.TargetID)
+	v.Required("source_id", r.SourceID)
+	v.Check(r.TargetID == "" || r.TargetID != r.SourceID, "source_id", "must differ from target_id")
+	return v.Err()
+}
+
+// CreateWebhookRequest is the body of POST /api/webhooks
+type CreateWebhookRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+}
+
+func (r CreateWebhookRequest) Validate() error {
+	v := validation.New()
+	v.Required("url", r.URL)
+	validateWebhook(v, r.URL, r.Events)
+	v.Items("events", len(r.Events), 1, len(webhook.Events))
+	return v.Err()
+}
+
+// UpdateWebhookRequest is the body of PATCH /api/webhooks/{id}; omitted
+// fields are left as they are
+type UpdateWebhookRequest struct {
+	URL    *string  `json:"url"`
+	Events []string `json:"events"`
+	Active *bool    `json:"active"`
+}
+
+func (r UpdateWebhookRequest) Validate() error {
+	v := validation.New()
+	url := ""
+	if r.URL != nil {
+		url = *r.URL
+		v.Required("url", url)
+	}
+	validateWebhook(v, url, r.Events)
+	if r.Events != nil {
+		v.Items("events", len(r.Events), 1, len(webhook.Events))
+	}
+	return v.Err()
+}
+
+func validateWebhook(v *validation.Validator, url string, events []string) {
+	v.Length("url", url, 0, 2048)
+	v.URL("url", url)
+	for i, name := range events {
+		field := fmt.Sprintf("events[%d]", i)
+		v.Required(field, name)
+		v.OneOf(field, name, webhook.Events...)
+	}
+}
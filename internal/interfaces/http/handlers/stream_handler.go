@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/guisithos/save-my-read/internal/application"
+	"github.com/guisithos/save-my-read/internal/interfaces/http/middleware"
+	"github.com/guisithos/save-my-read/internal/interfaces/http/response"
+)
+
+const (
+	// defaultHeartbeat keeps idle streams from being closed by proxies
+	defaultHeartbeat = 25 * time.Second
+	// streamRetry is how long clients wait before reconnecting, in ms
+	streamRetry = 3000
+)
+
+// EventReset tells a client it may have missed events and should reload
+const EventReset = "reset"
+
+// StreamHandler streams changes to the user's library as Server-Sent Events
+type StreamHandler struct {
+	hub       *application.EventHub
+	heartbeat time.Duration
+}
+
+// NewStreamHandler creates a new StreamHandler
+func NewStreamHandler(hub *application.EventHub) *StreamHandler {
+	return &StreamHandler{hub: hub, heartbeat: defaultHeartbeat}
+}
+
+// Stream sends the user's book events as they happen. A client
+// reconnecting with Last-Event-ID first gets the events it missed, or a
+// reset event when they are no longer known.
+func (h *StreamHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		response.Error(w, r, errUnauthenticated)
+		return
+	}
+
+	sub := h.hub.Subscribe(userID, r.Header.Get("Last-Event-ID"))
+	defer sub.Close()
+
+	// The stream outlives the server's write timeout; where the deadline
+	// cannot be lifted the client reconnects when it hits and resumes
+	rc := http.NewResponseController(w)
+	rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", streamRetry)
+	if sub.Stale {
+		fmt.Fprintf(w, "event: %s\ndata: {}\n\n", EventReset)
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+			fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", e.ID, e.Name, e.Data)
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/guisithos/save-my-read/internal/application"
+	"github.com/guisithos/save-my-read/internal/domain/book"
+	"github.com/guisithos/save-my-read/internal/interfaces/http/middleware"
+)
+
+// sseEvent is one event read from a stream
+type sseEvent struct {
+	id, name, data string
+}
+
+// openStream connects to the stream as userID and returns its events
+func openStream(t *testing.T, url, userID, lastEventID string) (<-chan sseEvent, func()) {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	req.Header.Set("X-User", userID)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if ct := resp.Header.Get("Content-Type"); resp.StatusCode != http.StatusOK || ct != "text/event-stream" {
+		t.Fatalf("expected an event stream, got %d %s", resp.StatusCode, ct)
+	}
+
+	events := make(chan sseEvent, 16)
+	go func() {
+		defer close(events)
+		var e sseEvent
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			field, value, _ := strings.Cut(scanner.Text(), ": ")
+			switch field {
+			case "id":
+				e.id = value
+			case "event":
+				e.name = value
+			case "data":
+				e.data = value
+			case "":
+				if e.name != "" {
+					events <- e
+				}
+				e = sseEvent{}
+			}
+		}
+	}()
+	return events, func() { resp.Body.Close() }
+}
+
+func nextEvent(t *testing.T, events <-chan sseEvent) sseEvent {
+	t.Helper()
+	select {
+	case e := <-events:
+		return e
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for an event")
+		return sseEvent{}
+	}
+}
+
+func TestStreamHandler(t *testing.T) {
+	hub := application.NewEventHub(0)
+	h := NewStreamHandler(hub)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), middleware.UserIDKey, r.Header.Get("X-User"))
+		h.Stream(w, r.WithContext(ctx))
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+
+	events, closeStream := openStream(t, srv.URL, "alice", "")
+	// Wait for the subscription before publishing
+	for i := 0; ; i++ {
+		hub.Publish(ctx, book.Added{BookID: "b1", UserID: "alice", Title: "Dune"})
+		select {
+		case e := <-events:
+			if e.name != book.EventAdded || !strings.Contains(e.data, `"book_id":"b1"`) {
+				t.Fatalf("unexpected event %+v", e)
+			}
+		case <-time.After(50 * time.Millisecond):
+			if i == 40 {
+				t.Fatal("timed out waiting for the stream")
+			}
+			continue
+		}
+		break
+	}
+	hub.Publish(ctx, book.Added{BookID: "b2", UserID: "bob"})
+	hub.Publish(ctx, book.StatusChanged{BookID: "b1", UserID: "alice", From: book.StatusToRead, To: book.StatusReading})
+	e := nextEvent(t, events)
+	if e.name != book.EventStatusChanged || e.id == "" {
+		t.Fatalf("expected alice's status change, got %+v", e)
+	}
+	closeStream()
+
+	hub.Publish(ctx, book.Deleted{BookID: "b1", UserID: "alice"})
+	resumed, closeResumed := openStream(t, srv.URL, "alice", e.id)
+	defer closeResumed()
+	if e := nextEvent(t, resumed); e.name != book.EventDeleted {
+		t.Errorf("expected the missed deletion on resume, got %+v", e)
+	}
+
+	stale, closeStale := openStream(t, srv.URL, "alice", "unknown-1")
+	defer closeStale()
+	if e := nextEvent(t, stale); e.name != EventReset {
+		t.Errorf("expected a reset for an unknown ID, got %+v", e)
+	}
+}
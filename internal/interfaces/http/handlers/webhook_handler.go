@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/guisithos/save-my-read/internal/application"
+	"github.com/guisithos/save-my-read/internal/domain/webhook"
+	"github.com/guisithos/save-my-read/internal/interfaces/http/middleware"
+	"github.com/guisithos/save-my-read/internal/interfaces/http/response"
+	"github.com/guisithos/save-my-read/internal/validation"
+)
+
+// WebhookHandler handles HTTP requests for the user's webhooks
+type WebhookHandler struct {
+	webhookService *application.WebhookService
+}
+
+// NewWebhookHandler creates a new WebhookHandler
+func NewWebhookHandler(webhookService *application.WebhookService) *WebhookHandler {
+	return &WebhookHandler{webhookService: webhookService}
+}
+
+// CreatedWebhook is a newly created webhook along with its secret, which
+// is only returned this once
+type CreatedWebhook struct {
+	*webhook.Webhook
+	Secret string `json:"secret"`
+}
+
+// CreateWebhook handles registering a webhook
+func (h *WebhookHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		response.Error(w, r, errUnauthenticated)
+		return
+	}
+
+	var req CreateWebhookRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	hook, err := h.webhookService.Create(r.Context(), userID, req.URL, req.Events)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Location", "/api/webhooks/"+hook.ID)
+	w.Header().Set("Cache-Control", "no-store")
+	response.Success(w, http.StatusCreated, CreatedWebhook{Webhook: hook, Secret: hook.Secret})
+}
+
+// GetWebhooks handles listing the user's webhooks
+func (h *WebhookHandler) GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		response.Error(w, r, errUnauthenticated)
+		return
+	}
+
+	hooks, err := h.webhookService.List(r.Context(), userID)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+	if hooks == nil {
+		hooks = []*webhook.Webhook{}
+	}
+	response.Success(w, http.StatusOK, hooks)
+}
+
+// GetWebhook handles retrieving one of the user's webhooks
+func (h *WebhookHandler) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		response.Error(w, r, errUnauthenticated)
+		return
+	}
+
+	hook, err := h.webhookService.Get(r.Context(), userID, r.PathValue("id"))
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+	response.Success(w, http.StatusOK, hook)
+}
+
+// UpdateWebhook handles changing a webhook's URL, events or active flag
+func (h *WebhookHandler) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		response.Error(w, r, errUnauthenticated)
+		return
+	}
+
+	var req UpdateWebhookRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	hook, err := h.webhookService.Update(r.Context(), userID, r.PathValue("id"), application.WebhookUpdate{
+		URL:    req.URL,
+		Events: req.Events,
+		Active: req.Active,
+	})
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+	response.Success(w, http.StatusOK, hook)
+}
+
+// DeleteWebhook handles removing a webhook and its delivery log
+func (h *WebhookHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		response.Error(w, r, errUnauthenticated)
+		return
+	}
+
+	if err := h.webhookService.Delete(r.Context(), userID, r.PathValue("id")); err != nil {
+		response.Error(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// TestWebhook handles sending a test event to a webhook; the response is
+// the delivery attempt, whether or not the receiver accepted it
+func (h *WebhookHandler) TestWebhook(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		response.Error(w, r, errUnauthenticated)
+		return
+	}
+
+	delivery, err := h.webhookService.SendTest(r.Context(), userID, r.PathValue("id"))
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+	response.Success(w, http.StatusOK, delivery)
+}
+
+// GetDeliveries handles listing a webhook's latest delivery attempts,
+// newest first
+func (h *WebhookHandler) GetDeliveries(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		response.Error(w, r, errUnauthenticated)
+		return
+	}
+
+	limit := 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		v := validation.New()
+		n, err := strconv.Atoi(value)
+		v.Check(err == nil && n >= 1 && n <= 100, "limit", "must be a number from 1 to 100")
+		if err := v.Err(); err != nil {
+			response.Error(w, r, err)
+			return
+		}
+		limit = n
+	}
+
+	deliveries, err := h.webhookService.Deliveries(r.Context(), userID, r.PathValue("id"), limit)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+	if deliveries == nil {
+		deliveries = []*webhook.Delivery{}
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	response.Success(w, http.StatusOK, deliveries)
+}
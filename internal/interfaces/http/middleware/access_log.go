@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/guisithos/save-my-read/internal/logging"
+)
+
+// requestInfoKey holds the requestInfo shared by the middlewares of one request
+const requestInfoKey contextKey = "requestInfo"
+
+// requestInfo lets inner middlewares and the mux report back to outer
+// middlewares, since context values and request copies made further down
+// the chain are not visible to them
+type requestInfo struct {
+	userID string
+	// pattern is the ServeMux pattern the request matched, set by Route
+	pattern string
+}
+
+// withRequestInfo returns the requestInfo of the request, adding one if no
+// outer middleware has
+func withRequestInfo(r *http.Request) (*requestInfo, *http.Request) {
+	if info, ok := r.Context().Value(requestInfoKey).(*requestInfo); ok {
+		return info, r
+	}
+	info := &requestInfo{}
+	return info, r.WithContext(context.WithValue(r.Context(), requestInfoKey, info))
+}
+
+// statusRecorder captures the status and size of a response
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += n
+	return n, err
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// AccessLog logs one line per request with its status, size, latency,
+// route and authenticated user. The route is reported by Route, which must
+// wrap the mux. The request ID comes from the context, so logger should use
+// a logging.ContextHandler. Handlers further down log through logger too,
+// as it is attached to the context with logging.WithLogger.
+func AccessLog(logger *slog.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			info, req := withRequestInfo(r)
+			req = req.WithContext(logging.WithLogger(req.Context(), logger))
+			rec := &statusRecorder{ResponseWriter: w}
+			next.ServeHTTP(rec, req)
+
+			status := rec.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			level := slog.LevelInfo
+			if status >= http.StatusInternalServerError {
+				level = slog.LevelError
+			}
+
+			logger.LogAttrs(r.Context(), level, "request",
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.String("route", info.pattern),
+				slog.Int("status", status),
+				slog.Int("bytes", rec.bytes),
+				slog.Duration("latency", time.Since(start)),
+				slog.String("user_id", info.userID),
+				slog.String("remote_addr", r.RemoteAddr),
+				slog.String("user_agent", r.UserAgent()),
+			)
+		})
+	}
+}
+
+// Route records the pattern the ServeMux matched for the middlewares
+// further out, which only see their own copies of the request. It must wrap
+// the mux directly, as the mux sets the pattern on the request it is given.
+func Route(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		info, r := withRequestInfo(r)
+		// Recorded even if the handler panics, so the error is reported
+		// against its route
+		defer func() { info.pattern = r.Pattern }()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// recordUserID makes the authenticated user visible to the access log
+func recordUserID(ctx context.Context, userID string) {
+	if info, ok := ctx.Value(requestInfoKey).(*requestInfo); ok {
+		info.userID = userID
+	}
+}
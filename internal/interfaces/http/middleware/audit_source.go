@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/guisithos/save-my-read/internal/domain/audit"
+)
+
+// maxUserAgentLength bounds the user agent kept in the audit log
+const maxUserAgentLength = 512
+
+// AuditSourceOptions configures where the client address is taken from
+type AuditSourceOptions struct {
+	// TrustedProxies lists the proxies whose Forwarded or X-Forwarded-For
+	// headers are believed. Without any, the client address is the direct
+	// peer's.
+	TrustedProxies []netip.Prefix
+}
+
+// AuditSource stores the client address, user agent and request ID in the
+// context for audit events. It must run after RequestID.
+func AuditSource(opts AuditSourceOptions) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ua := r.UserAgent()
+			if len(ua) > maxUserAgentLength {
+				ua = ua[:maxUserAgentLength]
+			}
+
+			ctx := audit.WithSource(r.Context(), audit.Source{
+				IP:        opts.clientIP(r),
+				UserAgent: ua,
+				RequestID: RequestIDFrom(r.Context()),
+			})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// clientIP returns the direct peer's address unless it is a trusted proxy.
+// Then the forwarded hops are walked from the nearest, and the first one
+// that is not a trusted proxy is the client; anything further left could
+// have been written by the client itself.
+func (o AuditSourceOptions) clientIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	peer, err := netip.ParseAddr(ip)
+	if err != nil || !o.trusted(peer) {
+		return ip
+	}
+
+	hops := forwardedFor(r.Header)
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, ok := parseHop(hops[i])
+		if !ok {
+			break
+		}
+		peer = addr
+		if !o.trusted(addr) {
+			break
+		}
+	}
+	return peer.String()
+}
+
+func (o AuditSourceOptions) trusted(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, p := range o.TrustedProxies {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// forwardedFor lists the hops a request passed through, the client first,
+// from the Forwarded header or, when it is missing, X-Forwarded-For
+func forwardedFor(h http.Header) []string {
+	var hops []string
+	if values := h.Values("Forwarded"); len(values) > 0 {
+		for _, v := range values {
+			for _, elem := range strings.Split(v, ",") {
+				for _, pair := range strings.Split(elem, ";") {
+					key, value, _ := strings.Cut(strings.TrimSpace(pair), "=")
+					if strings.EqualFold(key, "for") {
+						hops = append(hops, strings.Trim(value, `"`))
+					}
+				}
+			}
+		}
+		return hops
+	}
+	for _, v := range h.Values("X-Forwarded-For") {
+		for _, hop := range strings.Split(v, ",") {
+			hops = append(hops, strings.TrimSpace(hop))
+		}
+	}
+	return hops
+}
+
+// parseHop reads an address as proxies write it: bare, with a port, or as
+// a bracketed IPv6 address
+func parseHop(hop string) (netip.Addr, bool) {
+	if addr, err := netip.ParseAddr(hop); err == nil {
+		return addr.Unmap(), true
+	}
+	if host, _, err := net.SplitHostPort(hop); err == nil {
+		hop = host
+	}
+	addr, err := netip.ParseAddr(strings.Trim(hop, "[]"))
+	return addr.Unmap(), err == nil
+}
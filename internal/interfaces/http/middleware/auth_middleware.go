@@ -2,23 +2,32 @@ package middleware
 
 import (
 	"context"
+	"log/slog"
 	"net/http"
 	"strings"
 
 	"github.com/guisithos/save-my-read/internal/domain/auth"
+	"github.com/guisithos/save-my-read/internal/domain/domainerr"
+	"github.com/guisithos/save-my-read/internal/interfaces/http/response"
+	"github.com/guisithos/save-my-read/internal/logging"
 )
 
 type contextKey string
 
 const UserIDKey contextKey = "userID"
 
-// AuthMiddleware creates a middleware that validates JWT tokens
+var (
+	errMissingToken   = domainerr.Unauthorized("missing_token", "authorization header required")
+	errMalformedToken = domainerr.Unauthorized("malformed_token", "authorization header must use the Bearer scheme")
+)
+
+// NewAuthMiddleware creates a middleware that validates JWT tokens
 func NewAuthMiddleware(tokenService auth.TokenService) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			authHeader := r.Header.Get("Authorization")
 			if authHeader == "" {
-				http.Error(w, "Authorization header required", http.StatusUnauthorized)
+				response.Error(w, r, errMissingToken)
 				return
 			}
 
@@ -26,19 +35,21 @@ func NewAuthMiddleware(tokenService auth.TokenService) func(http.Handler) http.H
 			// Format: "Bearer <token>"
 			parts := strings.Split(authHeader, " ")
 			if len(parts) != 2 || parts[0] != "Bearer" {
-				http.Error(w, "Invalid authorization header format", http.StatusUnauthorized)
+				response.Error(w, r, errMalformedToken)
 				return
 			}
 
 			token := parts[1]
 			claims, err := tokenService.ValidateToken(token)
 			if err != nil {
-				http.Error(w, "Invalid token", http.StatusUnauthorized)
+				response.Error(w, r, auth.ErrInvalidToken)
 				return
 			}
 
 			// Add user ID to request context
+			recordUserID(r.Context(), claims.UserID)
 			ctx := context.WithValue(r.Context(), UserIDKey, claims.UserID)
+			ctx = logging.With(ctx, slog.String("user_id", claims.UserID))
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSOptions configures cross-origin access to the API
+type CORSOptions struct {
+	// AllowedOrigins lists origins such as https://app.example.com; "*"
+	// allows any origin. An empty list disables CORS.
+	AllowedOrigins []string
+	MaxAge         time.Duration
+}
+
+var (
+	corsAllowedMethods = "GET, POST, PUT, PATCH, DELETE, OPTIONS"
+	corsAllowedHeaders = "Authorization, Content-Type, X-Request-ID, If-Match, If-None-Match, Idempotency-Key"
+	corsExposedHeaders = "Location, X-Request-ID, Deprecation, Link, ETag, Idempotent-Replayed"
+)
+
+// CORS answers preflight requests and adds CORS headers for allowed origins
+func CORS(opts CORSOptions) Middleware {
+	allowAny := slices.Contains(opts.AllowedOrigins, "*")
+	maxAge := strconv.Itoa(int(opts.MaxAge.Seconds()))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" || len(opts.AllowedOrigins) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Add("Vary", "Origin")
+			allowed := allowAny || slices.ContainsFunc(opts.AllowedOrigins, func(o string) bool {
+				return strings.EqualFold(o, origin)
+			})
+			if allowed {
+				h.Set("Access-Control-Allow-Origin", origin)
+				h.Set("Access-Control-Expose-Headers", corsExposedHeaders)
+			}
+
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				if allowed {
+					h.Add("Vary", "Access-Control-Request-Method")
+					h.Add("Vary", "Access-Control-Request-Headers")
+					h.Set("Access-Control-Allow-Methods", corsAllowedMethods)
+					h.Set("Access-Control-Allow-Headers", corsAllowedHeaders)
+					h.Set("Access-Control-Max-Age", maxAge)
+				}
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
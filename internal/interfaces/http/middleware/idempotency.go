@@ -0,0 +1,218 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/guisithos/save-my-read/internal/domain/domainerr"
+	"github.com/guisithos/save-my-read/internal/domain/idempotency"
+	"github.com/guisithos/save-my-read/internal/interfaces/http/response"
+	"github.com/guisithos/save-my-read/internal/logging"
+)
+
+const (
+	maxIdempotencyKeyLength = 255
+	// maxIdempotentBodyBytes matches the limit the handlers put on JSON bodies
+	maxIdempotentBodyBytes = 1 << 20
+)
+
+var (
+	errInvalidIdempotencyKey  = domainerr.Validation("Idempotency-Key", "must be 1 to 255 visible ASCII characters")
+	errIdempotentBodyTooLarge = domainerr.TooLarge("body_too_large", "request body must not exceed 1048576 bytes")
+)
+
+// replayedHeaders are the response headers kept with a stored response
+var replayedHeaders = []string{"Content-Type", "Location", "ETag", "Deprecation", "Link"}
+
+// IdempotencyOptions configures the Idempotency middleware
+type IdempotencyOptions struct {
+	Store idempotency.Store
+	// TTL is how long a key and its response are kept
+	TTL time.Duration
+	// Logger receives failures to store responses; slog.Default() when nil
+	Logger *slog.Logger
+}
+
+// Idempotency lets clients retry writes safely. The response to a request
+// carrying an Idempotency-Key header is stored, and a retry with the same
+// key and body gets it back instead of running again. Reusing a key for a
+// different request fails with 422, and a retry arriving while the first
+// attempt still runs fails with 409. Server errors are not stored, so the
+// request can be retried. Keys are scoped to the user, so it must run after
+// authentication; requests without the header pass through.
+//
+// Requests without an authenticated user, such as registration, pass
+// through too, key or not. There is no user to scope their keys to, and
+// the writes they make are guarded otherwise: retrying a registration
+// fails with 409 as the email is taken, and the client can log in.
+func Idempotency(opts IdempotencyOptions) Middleware {
+	logger := logging.OrDefault(opts.Logger)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get("Idempotency-Key")
+			userID, ok := r.Context().Value(UserIDKey).(string)
+			if key == "" || !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !validIdempotencyKey(key) {
+				response.Error(w, r, errInvalidIdempotencyKey)
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBodyBytes))
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				response.Error(w, r, errIdempotentBodyTooLarge)
+				return
+			}
+			if err != nil {
+				response.Error(w, r, err)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			now := time.Now()
+			rec := &idempotency.Record{
+				UserID:      userID,
+				Key:         key,
+				Fingerprint: fingerprint(r, body),
+				CreatedAt:   now,
+				ExpiresAt:   now.Add(opts.TTL),
+			}
+			err = opts.Store.Reserve(r.Context(), rec)
+			if errors.Is(err, idempotency.ErrExists) {
+				replay(w, r, opts.Store, rec)
+				return
+			}
+			if err != nil {
+				response.Error(w, r, err)
+				return
+			}
+
+			// The outcome is stored even if the client has gone, since that
+			// is when it is most likely to retry
+			ctx := context.WithoutCancel(r.Context())
+			completed := false
+			defer func() {
+				if completed {
+					return
+				}
+				if err := opts.Store.Release(ctx, userID, key); err != nil {
+					logger.ErrorContext(ctx, "failed to release idempotency key", "error", err)
+				}
+			}()
+
+			capture := &responseCapture{ResponseWriter: w}
+			next.ServeHTTP(capture, r)
+
+			status := capture.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			if status >= http.StatusInternalServerError || status == response.StatusClientClosedRequest {
+				return
+			}
+
+			rec.Status = status
+			rec.Header = make(map[string][]string)
+			for _, name := range replayedHeaders {
+				if values := capture.Header().Values(name); len(values) > 0 {
+					rec.Header[name] = values
+				}
+			}
+			rec.Body = capture.body.Bytes()
+			if err := opts.Store.Complete(ctx, rec); err != nil {
+				logger.ErrorContext(ctx, "failed to store idempotent response", "error", err)
+				return
+			}
+			completed = true
+		})
+	}
+}
+
+// replay answers a request whose key is already taken with the stored response
+func replay(w http.ResponseWriter, r *http.Request, store idempotency.Store, rec *idempotency.Record) {
+	stored, err := store.FindByKey(r.Context(), rec.UserID, rec.Key)
+	if errors.Is(err, idempotency.ErrNotFound) {
+		// The first attempt failed and released the key just now
+		response.Error(w, r, idempotency.ErrInProgress)
+		return
+	}
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	switch {
+	case stored.Fingerprint != rec.Fingerprint:
+		response.Error(w, r, idempotency.ErrKeyReused)
+	case !stored.Completed():
+		response.Error(w, r, idempotency.ErrInProgress)
+	default:
+		for name, values := range stored.Header {
+			w.Header()[http.CanonicalHeaderKey(name)] = values
+		}
+		w.Header().Set("Idempotent-Replayed", "true")
+		w.WriteHeader(stored.Status)
+		w.Write(stored.Body)
+	}
+}
+
+// fingerprint identifies a request by its method, path, If-Match
+// precondition and body; a key reused for another version of the resource
+// is a different request
+func fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.Path+"\n")
+	io.WriteString(h, "If-Match: "+r.Header.Get("If-Match")+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func validIdempotencyKey(key string) bool {
+	if len(key) > maxIdempotencyKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x21 || key[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// responseCapture passes a response through while keeping a copy to store
+type responseCapture struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (c *responseCapture) WriteHeader(status int) {
+	if c.status == 0 {
+		c.status = status
+	}
+	c.ResponseWriter.WriteHeader(status)
+}
+
+func (c *responseCapture) Write(b []byte) (int, error) {
+	if c.status == 0 {
+		c.status = http.StatusOK
+	}
+	c.body.Write(b)
+	return c.ResponseWriter.Write(b)
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (c *responseCapture) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+)
+
+// RequestObserver records served requests, e.g. as Prometheus metrics
+type RequestObserver interface {
+	ObserveRequest(pattern, method string, status int, elapsed time.Duration)
+}
+
+// Metrics reports every request with the ServeMux pattern it matched, as
+// reported by Route
+func Metrics(observer RequestObserver) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			info, req := withRequestInfo(r)
+			rec := &statusRecorder{ResponseWriter: w}
+			next.ServeHTTP(rec, req)
+
+			status := rec.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			observer.ObserveRequest(info.pattern, r.Method, status, time.Since(start))
+		})
+	}
+}
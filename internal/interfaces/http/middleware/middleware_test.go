@@ -0,0 +1,452 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/guisithos/save-my-read/internal/domain/audit"
+	"github.com/guisithos/save-my-read/internal/domain/auth"
+	"github.com/guisithos/save-my-read/internal/domain/idempotency"
+	"github.com/guisithos/save-my-read/internal/infrastructure/memory"
+	"github.com/guisithos/save-my-read/internal/logging"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestChain_Order(t *testing.T) {
+	var calls []string
+	mark := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	h := Chain(mark("first"), mark("second"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "handler")
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if got := strings.Join(calls, ","); got != "first,second,handler" {
+		t.Errorf("expected first,second,handler, got %s", got)
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	var seen string
+	h := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestIDFrom(r.Context())
+	}))
+
+	tests := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{"generated when missing", "", false},
+		{"propagated from proxy", "abc-123", true},
+		{"replaced when unsafe", "bad\nid", false},
+		{"replaced when too long", strings.Repeat("a", 200), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.incoming != "" {
+				req.Header.Set(RequestIDHeader, tt.incoming)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			got := w.Header().Get(RequestIDHeader)
+			if got == "" || got != seen {
+				t.Fatalf("expected the same ID in header and context, got %q and %q", got, seen)
+			}
+			if (got == tt.incoming) != tt.keep {
+				t.Errorf("incoming %q: unexpected ID %q", tt.incoming, got)
+			}
+		})
+	}
+}
+
+func TestAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(logging.NewContextHandler(slog.NewJSONHandler(&buf, nil)))
+
+	tokens := auth.NewJWTService("secret", time.Hour)
+	token, err := tokens.GenerateToken("user-1", "user@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /things/{id}", NewAuthMiddleware(tokens)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte("short and stout"))
+	})))
+	h := Chain(RequestID, AccessLog(logger), Route)(mux)
+
+	req := httptest.NewRequest(http.MethodGet, "/things/42", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("expected one JSON log line, got %q", buf.String())
+	}
+	want := map[string]interface{}{
+		"msg":     "request",
+		"method":  "GET",
+		"path":    "/things/42",
+		"route":   "GET /things/{id}",
+		"status":  float64(http.StatusTeapot),
+		"bytes":   float64(len("short and stout")),
+		"user_id": "user-1",
+	}
+	for k, v := range want {
+		if entry[k] != v {
+			t.Errorf("expected %s=%v, got %v", k, v, entry[k])
+		}
+	}
+	if id, _ := entry["request_id"].(string); id == "" || entry["latency"] == nil {
+		t.Errorf("expected request_id and latency, got %v", entry)
+	}
+}
+
+func TestRecover(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	h := Recover(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected status 500, got %d", w.Code)
+	}
+	var body struct {
+		Success bool `json:"success"`
+		Error   struct {
+			Code string `json:"code"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("expected JSON body: %v", err)
+	}
+	if body.Success || body.Error.Code != "internal_error" {
+		t.Errorf("expected internal_error envelope, got %+v", body)
+	}
+	if !strings.Contains(buf.String(), "boom") {
+		t.Errorf("expected the panic to be logged, got %q", buf.String())
+	}
+}
+
+func TestCORS(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	h := CORS(CORSOptions{AllowedOrigins: []string{"https://app.example.com"}, MaxAge: time.Hour})(next)
+
+	tests := []struct {
+		name        string
+		method      string
+		origin      string
+		preflight   bool
+		wantStatus  int
+		wantAllowed bool
+	}{
+		{"allowed origin", http.MethodGet, "https://app.example.com", false, http.StatusOK, true},
+		{"other origin", http.MethodGet, "https://evil.example.com", false, http.StatusOK, false},
+		{"allowed preflight", http.MethodOptions, "https://app.example.com", true, http.StatusNoContent, true},
+		{"rejected preflight", http.MethodOptions, "https://evil.example.com", true, http.StatusNoContent, false},
+		{"same origin", http.MethodGet, "", false, http.StatusOK, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/api/books", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.preflight {
+				req.Header.Set("Access-Control-Request-Method", http.MethodPatch)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin") == tt.origin && tt.origin != ""; got != tt.wantAllowed {
+				t.Errorf("expected allowed=%v, got header %q", tt.wantAllowed, w.Header().Get("Access-Control-Allow-Origin"))
+			}
+			if tt.preflight && tt.wantAllowed && w.Header().Get("Access-Control-Max-Age") != "3600" {
+				t.Errorf("expected max age 3600, got %q", w.Header().Get("Access-Control-Max-Age"))
+			}
+		})
+	}
+}
+
+func TestAuditSource(t *testing.T) {
+	opts := AuditSourceOptions{TrustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		header     http.Header
+		wantIP     string
+	}{
+		{"direct client", "192.0.2.1:1234", nil, "192.0.2.1"},
+		{"untrusted peer forging the header", "192.0.2.1:1234", http.Header{"X-Forwarded-For": {"198.51.100.7"}}, "192.0.2.1"},
+		{"trusted proxy", "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"198.51.100.7"}}, "198.51.100.7"},
+		{"client forging behind a trusted proxy", "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"203.0.113.9, 198.51.100.7, 10.0.0.2"}}, "198.51.100.7"},
+		{"forwarded header", "10.0.0.1:1234", http.Header{"Forwarded": {`for="[2001:db8::1]:4711";proto=https`}, "X-Forwarded-For": {"198.51.100.7"}}, "2001:db8::1"},
+		{"trusted proxy without header", "10.0.0.1:1234", nil, "10.0.0.1"},
+		{"unparsable hop", "10.0.0.1:1234", http.Header{"Forwarded": {"for=unknown"}}, "10.0.0.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got audit.Source
+			h := AuditSource(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = audit.SourceFrom(r.Context())
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for k, v := range tt.header {
+				req.Header[k] = v
+			}
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got.IP != tt.wantIP {
+				t.Errorf("expected IP %q, got %q", tt.wantIP, got.IP)
+			}
+		})
+	}
+}
+
+func TestTracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+
+	var logs bytes.Buffer
+	logger := slog.New(logging.NewContextHandler(slog.NewJSONHandler(&logs, nil)))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/books/{id}", func(w http.ResponseWriter, r *http.Request) {
+		logger.InfoContext(r.Context(), "handled")
+	})
+	h := Chain(RequestID, Tracing, Route)(mux)
+
+	const parent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	req := httptest.NewRequest(http.MethodGet, "/api/books/b1", nil)
+	req.Header.Set("traceparent", parent)
+	req.Header.Set(RequestIDHeader, "req-1")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	span := spans[0]
+	if span.Name != "GET /api/books/{id}" {
+		t.Errorf("expected span named after the route, got %q", span.Name)
+	}
+	if got := span.SpanContext.TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("expected incoming trace to be continued, got trace %s", got)
+	}
+
+	attrs := map[string]string{}
+	for _, kv := range span.Attributes {
+		attrs[string(kv.Key)] = kv.Value.Emit()
+	}
+	if attrs["http.route"] != "/api/books/{id}" || attrs["http.request.id"] != "req-1" {
+		t.Errorf("unexpected span attributes: %v", attrs)
+	}
+	if !strings.Contains(logs.String(), `"trace_id":"4bf92f3577b34da6a3ce929d0e0e4736"`) {
+		t.Errorf("expected trace ID in request logs, got %s", logs.String())
+	}
+}
+
+func TestIdempotency(t *testing.T) {
+	calls := 0
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := io.ReadAll(r.Body)
+		if calls == 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Location", "/api/books/b1")
+		w.Header().Set("X-Other", "dropped")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"call":%d,"body":%q}`, calls, body)
+	})
+	h := Idempotency(IdempotencyOptions{Store: memory.NewIdempotencyStore(), TTL: time.Hour})(next)
+
+	send := func(user, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/books", strings.NewReader(body))
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+		req = req.WithContext(context.WithValue(req.Context(), UserIDKey, user))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := send("user-1", "k1", `{"title":"Dune"}`)
+	if first.Code != http.StatusCreated || first.Header().Get("Idempotent-Replayed") != "" {
+		t.Fatalf("expected the first request to run, got %d %v", first.Code, first.Header())
+	}
+
+	replayed := send("user-1", "k1", `{"title":"Dune"}`)
+	if calls != 1 {
+		t.Errorf("expected a retry not to run the handler again, got %d calls", calls)
+	}
+	if replayed.Code != http.StatusCreated || replayed.Body.String() != first.Body.String() {
+		t.Errorf("expected the stored response, got %d %s", replayed.Code, replayed.Body)
+	}
+	if replayed.Header().Get("Idempotent-Replayed") != "true" || replayed.Header().Get("Location") != "/api/books/b1" {
+		t.Errorf("expected replay headers, got %v", replayed.Header())
+	}
+	if replayed.Header().Get("X-Other") != "" {
+		t.Errorf("expected only listed headers to be replayed, got %v", replayed.Header())
+	}
+
+	if rec := send("user-1", "k1", `{"title":"Emma"}`); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 for a reused key, got %d", rec.Code)
+	}
+	if rec := send("user-2", "k1", `{"title":"Emma"}`); rec.Code != http.StatusCreated || calls != 2 {
+		t.Errorf("expected keys to be scoped to the user, got %d after %d calls", rec.Code, calls)
+	}
+
+	if rec := send("user-1", "k2", `{}`); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected the handler's failure, got %d", rec.Code)
+	}
+	if rec := send("user-1", "k2", `{}`); rec.Code != http.StatusCreated || calls != 4 {
+		t.Errorf("expected a failed request to be retried, got %d after %d calls", rec.Code, calls)
+	}
+
+	if rec := send("user-1", "", `{}`); rec.Code != http.StatusCreated || calls != 5 {
+		t.Errorf("expected requests without a key to pass through, got %d", rec.Code)
+	}
+	if rec := send("user-1", "bad key", `{}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid key, got %d", rec.Code)
+	}
+}
+
+func TestIdempotency_IfMatch(t *testing.T) {
+	calls := 0
+	h := Idempotency(IdempotencyOptions{Store: memory.NewIdempotencyStore(), TTL: time.Hour})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	send := func(ifMatch string) int {
+		req := httptest.NewRequest(http.MethodPatch, "/api/books/b1", strings.NewReader(`{"status":"READING"}`))
+		req.Header.Set("Idempotency-Key", "k1")
+		req.Header.Set("If-Match", ifMatch)
+		req = req.WithContext(context.WithValue(req.Context(), UserIDKey, "user-1"))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := send(`"1"`); code != http.StatusOK {
+		t.Fatalf("expected the first request to run, got %d", code)
+	}
+	if code := send(`"1"`); code != http.StatusOK || calls != 1 {
+		t.Errorf("expected a retry to be replayed, got %d after %d calls", code, calls)
+	}
+	if code := send(`"2"`); code != http.StatusUnprocessableEntity || calls != 1 {
+		t.Errorf("expected 422 for a key reused with another If-Match, got %d after %d calls", code, calls)
+	}
+}
+
+func TestIdempotency_Anonymous(t *testing.T) {
+	calls := 0
+	store := memory.NewIdempotencyStore()
+	h := Idempotency(IdempotencyOptions{Store: store, TTL: time.Hour})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/api/auth/register", strings.NewReader(`{"email":"a@example.com"}`))
+		req.Header.Set("Idempotency-Key", "k1")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusCreated || rec.Header().Get("Idempotent-Replayed") != "" {
+			t.Errorf("expected the request to run, got %d %v", rec.Code, rec.Header())
+		}
+	}
+	if calls != 2 {
+		t.Errorf("expected requests without a user to pass through, got %d calls", calls)
+	}
+	if _, err := store.FindByKey(context.Background(), "", "k1"); !errors.Is(err, idempotency.ErrNotFound) {
+		t.Errorf("expected no key stored without a user, got %v", err)
+	}
+}
+
+func TestIdempotency_InProgress(t *testing.T) {
+	store := memory.NewIdempotencyStore()
+	started, release := make(chan struct{}), make(chan struct{})
+	h := Idempotency(IdempotencyOptions{Store: store, TTL: time.Hour})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest(http.MethodDelete, "/api/books/b1", nil)
+		req.Header.Set("Idempotency-Key", "k1")
+		return req.WithContext(context.WithValue(req.Context(), UserIDKey, "user-1"))
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.ServeHTTP(httptest.NewRecorder(), newRequest())
+	}()
+	<-started
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, newRequest())
+	if rec.Code != http.StatusConflict {
+		t.Errorf("expected 409 while the first request runs, got %d", rec.Code)
+	}
+
+	close(release)
+	<-done
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, newRequest())
+	if rec.Code != http.StatusNoContent || rec.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("expected the stored response once finished, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/guisithos/save-my-read/internal/interfaces/http/response"
+)
+
+// errPanic is reported to clients when a handler panics
+var errPanic = errors.New("handler panicked")
+
+// Recover turns a panicking handler into a logged JSON 500 instead of a
+// dropped connection
+func Recover(logger *slog.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rec := &statusRecorder{ResponseWriter: w}
+
+			defer func() {
+				v := recover()
+				if v == nil {
+					return
+				}
+				// ErrAbortHandler is the documented way to abort a response
+				if v == http.ErrAbortHandler {
+					panic(v)
+				}
+
+				logger.ErrorContext(r.Context(), "panic serving request",
+					slog.String("method", r.Method),
+					slog.String("path", r.URL.Path),
+					slog.String("panic", fmt.Sprint(v)),
+					slog.String("stack", string(debug.Stack())),
+				)
+
+				// Too late for a clean error once the handler started writing
+				if rec.status != 0 {
+					return
+				}
+				status, body := response.FromError(errPanic)
+				response.JSON(rec, status, response.Envelope{Success: false, Error: body})
+			}()
+
+			next.ServeHTTP(rec, r)
+		})
+	}
+}
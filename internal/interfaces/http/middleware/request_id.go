@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/guisithos/save-my-read/internal/logging"
+)
+
+// RequestIDHeader carries the request ID between clients, proxies and the server
+const RequestIDHeader = "X-Request-ID"
+
+// RequestIDKey holds the request ID in the request context
+const RequestIDKey contextKey = "requestID"
+
+// maxRequestIDLength bounds IDs accepted from upstream proxies
+const maxRequestIDLength = 128
+
+// RequestID reuses a well-formed X-Request-ID from the client or proxy, or
+// generates one, and exposes it in the context and the response headers
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+
+		w.Header().Set(RequestIDHeader, id)
+		ctx := context.WithValue(r.Context(), RequestIDKey, id)
+		ctx = logging.With(ctx, slog.String("request_id", id))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RequestIDFrom returns the request ID stored in ctx, if any
+func RequestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(RequestIDKey).(string)
+	return id
+}
+
+// validRequestID accepts short printable tokens so IDs can't forge log lines
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':', c == '/', c == '+', c == '=':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/guisithos/save-my-read/internal/logging"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracing starts a server span per request, continuing any trace the
+// caller propagated. The span is renamed after the route Route reports once
+// the request is served, and its trace ID is attached to the request's log
+// records. It must run after RequestID to tag spans with the request ID.
+func Tracing(next http.Handler) http.Handler {
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		span := trace.SpanFromContext(ctx)
+		if sc := span.SpanContext(); sc.IsValid() {
+			ctx = logging.With(ctx, slog.String("trace_id", sc.TraceID().String()))
+		}
+		if id := RequestIDFrom(ctx); id != "" {
+			span.SetAttributes(attribute.String("http.request.id", id))
+		}
+
+		info, req := withRequestInfo(r.WithContext(ctx))
+		next.ServeHTTP(w, req)
+
+		if _, route, ok := strings.Cut(info.pattern, " "); ok {
+			span.SetName(r.Method + " " + route)
+			span.SetAttributes(attribute.String("http.route", route))
+		}
+	})
+
+	return otelhttp.NewHandler(inner, "http.server",
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return r.Method
+		}),
+	)
+}
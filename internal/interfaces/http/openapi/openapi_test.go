@@ -0,0 +1,104 @@
+package openapi
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type shelf string
+
+type sample struct {
+	ID       string            `json:"id"`
+	Tags     []string          `json:"tags,omitempty"`
+	Shelf    shelf             `json:"shelf"`
+	Created  time.Time         `json:"created_at"`
+	Extra    map[string]string `json:"extra"`
+	Parent   *sample           `json:"parent,omitempty"`
+	Ignored  string            `json:"-"`
+	Untagged int
+	private  bool
+}
+
+func TestSchema(t *testing.T) {
+	doc := New("test", "1", "")
+	doc.Enum(shelf(""), "a", "b")
+	doc.Require(sample{}, "id")
+
+	ref := doc.Schema(&sample{})
+	if ref.Ref != "#/components/schemas/sample" {
+		t.Fatalf("expected a component reference, got %+v", ref)
+	}
+
+	s := doc.Components.Schemas["sample"]
+	if s == nil || s.Type != "object" {
+		t.Fatalf("expected an object component, got %+v", s)
+	}
+
+	tests := []struct {
+		name string
+		want *Schema
+	}{
+		{"id", &Schema{Type: "string"}},
+		{"tags", &Schema{Type: "array", Items: &Schema{Type: "string"}}},
+		{"shelf", &Schema{Type: "string", Enum: []interface{}{"a", "b"}}},
+		{"created_at", &Schema{Type: "string", Format: "date-time"}},
+		{"extra", &Schema{Type: "object", AdditionalProperties: &Schema{Type: "string"}}},
+		{"parent", &Schema{Ref: "#/components/schemas/sample"}},
+		{"Untagged", &Schema{Type: "integer", Format: "int32"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.Properties[tt.name]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+
+	for _, name := range []string{"-", "Ignored", "private"} {
+		if _, ok := s.Properties[name]; ok {
+			t.Errorf("expected %q to be skipped", name)
+		}
+	}
+	if !reflect.DeepEqual(s.Required, []string{"id"}) {
+		t.Errorf("expected required [id], got %v", s.Required)
+	}
+}
+
+type base struct {
+	ID    string `json:"id"`
+	Owner string `json:"owner"`
+}
+
+type extended struct {
+	*base
+	Owner string `json:"owner_email"`
+	ID    int    `json:"id"`
+}
+
+func TestSchema_PromotesEmbeddedFields(t *testing.T) {
+	doc := New("test", "1", "")
+	doc.Schema(extended{})
+
+	s := doc.Components.Schemas["extended"]
+	want := map[string]*Schema{
+		"id":          {Type: "integer", Format: "int32"},
+		"owner":       {Type: "string"},
+		"owner_email": {Type: "string"},
+	}
+	if !reflect.DeepEqual(s.Properties, want) {
+		t.Errorf("expected %+v, got %+v", want, s.Properties)
+	}
+}
+
+func TestHas(t *testing.T) {
+	doc := New("test", "1", "")
+	doc.Add("GET", "/things/{id}", &Operation{Summary: "Get a thing"})
+
+	if !doc.Has("GET", "/things/{id}") || !doc.Has("get", "/things/{id}") {
+		t.Error("expected GET /things/{id} to be documented")
+	}
+	if doc.Has("DELETE", "/things/{id}") {
+		t.Error("expected DELETE /things/{id} to be undocumented")
+	}
+}
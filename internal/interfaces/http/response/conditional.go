@@ -0,0 +1,54 @@
+package response
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// Conditional writes data like Success and tags it with etag, or derives
+// the tag from the encoded body when etag is empty. A request whose
+// If-None-Match already holds the tag gets 304 Not Modified without a body.
+func Conditional(w http.ResponseWriter, r *http.Request, etag string, data interface{}) {
+	body, err := json.Marshal(Envelope{Success: true, Data: data})
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	body = append(body, '\n')
+
+	if etag == "" {
+		sum := sha256.Sum256(body)
+		etag = `"` + hex.EncodeToString(sum[:16]) + `"`
+	}
+	w.Header().Set("ETag", etag)
+	// Clients may keep the response but must check it's current before reuse
+	w.Header().Set("Cache-Control", "private, no-cache")
+
+	if NoneMatch(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+// NoneMatch reports whether an If-None-Match header value lists etag,
+// using the weak comparison RFC 9110 prescribes for it
+func NoneMatch(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package response
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestConditional(t *testing.T) {
+	w := httptest.NewRecorder()
+	Conditional(w, httptest.NewRequest(http.MethodGet, "/", nil), "", []string{"a", "b"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	etag := w.Header().Get("ETag")
+	if etag == "" || w.Body.Len() == 0 {
+		t.Fatalf("expected a tagged body, got ETag %q and %d bytes", etag, w.Body.Len())
+	}
+
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		data        []string
+		status      int
+	}{
+		{"same tag", etag, []string{"a", "b"}, http.StatusNotModified},
+		{"weak form", "W/" + etag, []string{"a", "b"}, http.StatusNotModified},
+		{"in a list", `"other", ` + etag, []string{"a", "b"}, http.StatusNotModified},
+		{"wildcard", "*", []string{"a", "b"}, http.StatusNotModified},
+		{"changed data", etag, []string{"a"}, http.StatusOK},
+		{"other tag", `"other"`, []string{"a", "b"}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("If-None-Match", tt.ifNoneMatch)
+			w := httptest.NewRecorder()
+
+			Conditional(w, r, "", tt.data)
+
+			if w.Code != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, w.Code)
+			}
+			if tt.status == http.StatusNotModified && w.Body.Len() != 0 {
+				t.Errorf("expected no body with 304, got %q", w.Body.String())
+			}
+		})
+	}
+
+	// An explicit tag is used as is
+	w = httptest.NewRecorder()
+	Conditional(w, httptest.NewRequest(http.MethodGet, "/", nil), `"7"`, "book")
+	if got := w.Header().Get("ETag"); got != `"7"` {
+		t.Errorf("expected ETag \"7\", got %s", got)
+	}
+}
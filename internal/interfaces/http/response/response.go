@@ -0,0 +1,127 @@
+// Package response writes JSON API responses, mapping errors to HTTP status
+// codes and a consistent error envelope.
+package response
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/guisithos/save-my-read/internal/domain/domainerr"
+	"github.com/guisithos/save-my-read/internal/logging"
+)
+
+// Envelope is the body of every JSON API response
+type Envelope struct {
+	Success bool        `json:"success"`
+	Data    interface{} `json:"data,omitempty"`
+	Error   *ErrorBody  `json:"error,omitempty"`
+}
+
+// ErrorBody describes why a request failed. Code is stable and meant for
+// programs; Message is meant for people.
+type ErrorBody struct {
+	Code    string                 `json:"code"`
+	Message string                 `json:"message"`
+	Fields  []domainerr.FieldError `json:"fields,omitempty"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// detailer is implemented by errors that carry extra data for the client
+type detailer interface {
+	Details() map[string]interface{}
+}
+
+// StatusClientClosedRequest reports a request the client abandoned before
+// it finished; only the access log and metrics ever see it
+const StatusClientClosedRequest = 499
+
+// categories maps each error category to its status and fallback code
+var categories = []struct {
+	kind   error
+	status int
+	code   string
+}{
+	{domainerr.ErrValidation, http.StatusBadRequest, "validation_failed"},
+	{domainerr.ErrUnauthorized, http.StatusUnauthorized, "unauthorized"},
+	{domainerr.ErrForbidden, http.StatusForbidden, "forbidden"},
+	{domainerr.ErrNotFound, http.StatusNotFound, "not_found"},
+	{domainerr.ErrConflict, http.StatusConflict, "conflict"},
+	{domainerr.ErrTooLarge, http.StatusRequestEntityTooLarge, "too_large"},
+	{domainerr.ErrUnprocessable, http.StatusUnprocessableEntity, "unprocessable"},
+	{domainerr.ErrPrecondition, http.StatusPreconditionFailed, "precondition_failed"},
+	{domainerr.ErrPreconditionRequired, http.StatusPreconditionRequired, "precondition_required"},
+	{domainerr.ErrUpstream, http.StatusBadGateway, "upstream_error"},
+}
+
+// JSON writes v as the response body
+func JSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// Success writes data wrapped in a successful envelope
+func Success(w http.ResponseWriter, status int, data interface{}) {
+	JSON(w, status, Envelope{Success: true, Data: data})
+}
+
+// Error writes err as an error envelope. Errors outside the domainerr
+// categories are reported as a generic internal error so implementation
+// details never reach the client; every server-side failure is logged with
+// the request's context so it can be matched to the access log. The logger
+// is the one attached to the context with logging.WithLogger.
+func Error(w http.ResponseWriter, r *http.Request, err error) {
+	status, body := FromError(err)
+	if status >= http.StatusInternalServerError {
+		logging.FromContext(r.Context()).ErrorContext(r.Context(), "request failed",
+			"status", status,
+			"code", body.Code,
+			"error", err,
+		)
+	}
+	JSON(w, status, Envelope{Success: false, Error: body})
+}
+
+// FromError returns the HTTP status and error body for err. A request that
+// ran out of time reports the timeout even when the operation wrapped it in
+// a domain error, such as a failed search.
+func FromError(err error) (int, *ErrorBody) {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return StatusClientClosedRequest, &ErrorBody{Code: "request_canceled", Message: "request canceled"}
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, &ErrorBody{Code: "timeout", Message: "request timed out"}
+	}
+
+	status, body := http.StatusInternalServerError, &ErrorBody{
+		Code:    "internal_error",
+		Message: "internal server error",
+	}
+
+	for _, c := range categories {
+		if errors.Is(err, c.kind) {
+			status, body.Code, body.Message = c.status, c.code, c.kind.Error()
+			break
+		}
+	}
+
+	var derr *domainerr.Error
+	if errors.As(err, &derr) {
+		body.Code, body.Message = derr.Code, derr.Message
+	}
+
+	var verr *domainerr.ValidationError
+	if errors.As(err, &verr) {
+		body.Message = "request validation failed"
+		body.Fields = verr.Fields
+	}
+
+	var d detailer
+	if errors.As(err, &d) {
+		body.Details = d.Details()
+	}
+
+	return status, body
+}
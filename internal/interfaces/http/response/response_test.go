@@ -0,0 +1,100 @@
+package response
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/guisithos/save-my-read/internal/domain/auth"
+	"github.com/guisithos/save-my-read/internal/domain/book"
+	"github.com/guisithos/save-my-read/internal/domain/domainerr"
+	"github.com/guisithos/save-my-read/internal/domain/idempotency"
+	"github.com/guisithos/save-my-read/internal/logging"
+)
+
+func TestError_LogsToContextLogger(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r = r.WithContext(logging.WithLogger(r.Context(), logger))
+
+	Error(httptest.NewRecorder(), r, book.ErrNotFound)
+	if logs.Len() != 0 {
+		t.Errorf("expected client errors not to be logged, got %q", logs.String())
+	}
+	Error(httptest.NewRecorder(), r, errors.New("pq: connection refused"))
+	if !strings.Contains(logs.String(), "pq: connection refused") {
+		t.Errorf("expected the failure in the request's logger, got %q", logs.String())
+	}
+}
+
+func TestError(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+		expectedCode   string
+		expectedFields int
+	}{
+		{"not found", book.ErrNotFound, http.StatusNotFound, "book_not_found", 0},
+		{"wrapped not found", fmt.Errorf("loading: %w", book.ErrNotFound), http.StatusNotFound, "book_not_found", 0},
+		{"duplicate", &book.DuplicateError{ExistingID: "b1", Reason: book.DuplicateGoogleID}, http.StatusConflict, "book_duplicate", 0},
+		{"conflict", auth.ErrEmailAlreadyExists, http.StatusConflict, "email_already_exists", 0},
+		{"unauthorized", auth.ErrInvalidCredentials, http.StatusUnauthorized, "invalid_credentials", 0},
+		{"modified", book.ErrModified, http.StatusPreconditionFailed, "book_modified", 0},
+		{"precondition required", domainerr.PreconditionRequired("if_match_required", "If-Match header required"), http.StatusPreconditionRequired, "if_match_required", 0},
+		{"unprocessable", idempotency.ErrKeyReused, http.StatusUnprocessableEntity, "idempotency_key_reused", 0},
+		{"forbidden category", domainerr.ErrForbidden, http.StatusForbidden, "forbidden", 0},
+		{"validation", &domainerr.ValidationError{Fields: []domainerr.FieldError{{Field: "title", Message: "is required"}, {Field: "status", Message: "invalid status"}}}, http.StatusBadRequest, "validation_failed", 2},
+		{"unknown", errors.New("pq: connection refused"), http.StatusInternalServerError, "internal_error", 0},
+		{"timeout", fmt.Errorf("error finding book: %w", context.DeadlineExceeded), http.StatusGatewayTimeout, "timeout", 0},
+		{"timeout inside domain error", fmt.Errorf("%w: %w", domainerr.Upstream("search_failed", "search failed"), context.DeadlineExceeded), http.StatusGatewayTimeout, "timeout", 0},
+		{"client gone", fmt.Errorf("error querying books: %w", context.Canceled), StatusClientClosedRequest, "request_canceled", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			Error(w, httptest.NewRequest(http.MethodGet, "/", nil), tt.err)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("expected JSON content type, got %q", ct)
+			}
+
+			var env Envelope
+			if err := json.NewDecoder(w.Body).Decode(&env); err != nil {
+				t.Fatalf("failed to decode envelope: %v", err)
+			}
+			if env.Success || env.Error == nil {
+				t.Fatalf("expected failed envelope with error body, got %+v", env)
+			}
+			if env.Error.Code != tt.expectedCode {
+				t.Errorf("expected code %q, got %q", tt.expectedCode, env.Error.Code)
+			}
+			if len(env.Error.Fields) != tt.expectedFields {
+				t.Errorf("expected %d field errors, got %d", tt.expectedFields, len(env.Error.Fields))
+			}
+		})
+	}
+}
+
+func TestError_HidesInternalDetails(t *testing.T) {
+	w := httptest.NewRecorder()
+	Error(w, httptest.NewRequest(http.MethodGet, "/", nil), errors.New("pq: password authentication failed for user admin"))
+
+	var env Envelope
+	json.NewDecoder(w.Body).Decode(&env)
+	if env.Error.Message != "internal server error" {
+		t.Errorf("internal error message leaked: %q", env.Error.Message)
+	}
+}
@@ -0,0 +1,437 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/guisithos/save-my-read/internal/domain/audit"
+	"github.com/guisithos/save-my-read/internal/domain/auth"
+	"github.com/guisithos/save-my-read/internal/domain/book"
+	"github.com/guisithos/save-my-read/internal/domain/cover"
+	"github.com/guisithos/save-my-read/internal/domain/webhook"
+	"github.com/guisithos/save-my-read/internal/health"
+	"github.com/guisithos/save-my-read/internal/infrastructure/googlebooks"
+	"github.com/guisithos/save-my-read/internal/interfaces/http/handlers"
+	"github.com/guisithos/save-my-read/internal/interfaces/http/openapi"
+	"github.com/guisithos/save-my-read/internal/interfaces/http/response"
+)
+
+const apiVersion = "1.0.0"
+
+// bearerAuth requires a JWT from POST /api/auth/login
+var bearerAuth = []map[string][]string{{"bearerAuth": {}}}
+
+// apiSpec describes every API route registered in SetupRoutes
+func apiSpec() *openapi.Document {
+	doc := openapi.New("save-my-read API", apiVersion,
+		"Search Google Books and keep a personal reading list. Successful JSON responses are wrapped as {success, data}; failures as {success: false, error}.")
+	doc.Components.SecuritySchemes["bearerAuth"] = &openapi.SecurityScheme{Type: "http", Scheme: "bearer", BearerFormat: "JWT"}
+
+	statuses := []interface{}{book.StatusToRead, book.StatusReading, book.StatusCompleted, book.StatusDNF}
+	doc.Enum(book.Status(""), statuses...)
+	doc.Require(handlers.RegisterRequest{}, "email", "password", "name")
+	doc.Require(handlers.LoginRequest{}, "email", "password")
+	doc.Require(handlers.AddBookRequest{}, "google_book_id", "title", "authors", "status")
+	doc.Require(handlers.UpdateBookRequest{}, "status")
+	doc.Require(handlers.UpdateBookStatusRequest{}, "book_id", "status")
+	doc.Require(handlers.MergeBookRequest{}, "source_id")
+	doc.Require(handlers.MergeBooksRequest{}, "target_id", "source_id")
+	doc.Require(handlers.CreateWebhookRequest{}, "url", "events")
+
+	doc.Components.Schemas["ErrorResponse"] = &openapi.Schema{
+		Type: "object",
+		Properties: map[string]*openapi.Schema{
+			"success": {Type: "boolean"},
+			"error":   doc.Schema(response.ErrorBody{}),
+		},
+		Required: []string{"success", "error"},
+	}
+
+	bookSchema := doc.Schema(book.Book{})
+	idParam := openapi.Parameter{Name: "id", In: "path", Required: true, Description: "Book ID", Schema: &openapi.Schema{Type: "string", Format: "uuid"}}
+	ifNoneMatch := openapi.Parameter{Name: "If-None-Match", In: "header", Description: "ETag of a previous response; answered with 304 if nothing changed", Schema: &openapi.Schema{Type: "string"}}
+	ifMatch := openapi.Parameter{Name: "If-Match", In: "header", Required: true, Description: "The book's current ETag, as returned with it; the update fails with 412 if the book changed since", Schema: &openapi.Schema{Type: "string"}}
+
+	doc.Add(http.MethodPost, "/api/auth/register", &openapi.Operation{
+		Summary:     "Create an account",
+		Description: "Registers a user and logs them in.",
+		Tags:        []string{"auth"},
+		RequestBody: jsonRequest(doc.Schema(handlers.RegisterRequest{})),
+		Responses: withErrors(map[string]*openapi.Response{
+			"201": success("Account created", &openapi.Schema{
+				Type: "object",
+				Properties: map[string]*openapi.Schema{
+					"user":  doc.Schema(auth.LoginResponse{}),
+					"token": doc.Schema(auth.LoginResponse{}),
+				},
+			}),
+		}, http.StatusBadRequest, http.StatusConflict),
+	})
+	doc.Add(http.MethodPost, "/api/auth/login", &openapi.Operation{
+		Summary:     "Log in",
+		Tags:        []string{"auth"},
+		RequestBody: jsonRequest(doc.Schema(handlers.LoginRequest{})),
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": success("Logged in", doc.Schema(auth.LoginResponse{})),
+		}, http.StatusBadRequest, http.StatusUnauthorized),
+	})
+
+	doc.Add(http.MethodGet, "/api/books/search", &openapi.Operation{
+		Summary:     "Search Google Books",
+		Description: "Returns the Google Books volumes response as is, without the success envelope.",
+		Tags:        []string{"books"},
+		Parameters: []openapi.Parameter{
+			{Name: "q", In: "query", Required: true, Description: "Search terms, up to 256 characters", Schema: &openapi.Schema{Type: "string"}},
+		},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": {Description: "Search results", Content: jsonContent(doc.Schema(googlebooks.BookResponse{}))},
+		}, http.StatusBadRequest, http.StatusBadGateway),
+	})
+
+	doc.Add(http.MethodGet, "/api/books", &openapi.Operation{
+		Summary:  "List the user's books",
+		Tags:     []string{"books"},
+		Security: bearerAuth,
+		Parameters: []openapi.Parameter{
+			{Name: "status", In: "query", Description: "Only return books with this status", Schema: &openapi.Schema{Type: "string", Enum: statuses}},
+			ifNoneMatch,
+		},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": withETag(success("The user's books", &openapi.Schema{Type: "array", Items: bookSchema})),
+			"304": {Description: "Not modified"},
+		}, http.StatusBadRequest, http.StatusUnauthorized),
+	})
+	addBook := &openapi.Operation{
+		Summary:     "Add a book to the user's list",
+		Description: "Returns 409 with details.existing_id and details.reason when the book, or a likely duplicate, is already in the list. Set allow_similar to add a likely duplicate anyway.",
+		Tags:        []string{"books"},
+		Security:    bearerAuth,
+		RequestBody: jsonRequest(doc.Schema(handlers.AddBookRequest{})),
+		Responses: withErrors(map[string]*openapi.Response{
+			"201": withETag(success("Book added; Location points at it", bookSchema)),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusConflict),
+	}
+	doc.Add(http.MethodPost, "/api/books", idempotent(addBook))
+
+	doc.Add(http.MethodGet, "/api/books/trash", &openapi.Operation{
+		Summary:     "List the user's deleted books",
+		Description: "Deleted books stay in the trash, most recently deleted first, until they are restored or purged after the retention period.",
+		Tags:        []string{"books"},
+		Security:    bearerAuth,
+		Parameters:  []openapi.Parameter{ifNoneMatch},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": withETag(success("The user's trash", &openapi.Schema{Type: "array", Items: bookSchema})),
+			"304": {Description: "Not modified"},
+		}, http.StatusUnauthorized),
+	})
+	doc.Add(http.MethodGet, "/api/books/{id}", &openapi.Operation{
+		Summary:    "Get a book",
+		Tags:       []string{"books"},
+		Security:   bearerAuth,
+		Parameters: []openapi.Parameter{idParam, ifNoneMatch},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": withETag(success("The book", bookSchema)),
+			"304": {Description: "Not modified"},
+		}, http.StatusUnauthorized, http.StatusNotFound),
+	})
+	doc.Add(http.MethodPatch, "/api/books/{id}", idempotent(&openapi.Operation{
+		Summary:     "Update a book's status",
+		Tags:        []string{"books"},
+		Security:    bearerAuth,
+		Parameters:  []openapi.Parameter{idParam, ifMatch},
+		RequestBody: jsonRequest(doc.Schema(handlers.UpdateBookRequest{})),
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": withETag(success("Status updated", nil)),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusPreconditionFailed, http.StatusPreconditionRequired),
+	}))
+	doc.Add(http.MethodDelete, "/api/books/{id}", idempotent(&openapi.Operation{
+		Summary:     "Remove a book from the user's list",
+		Description: "Moves the book to the trash, from where it can be restored until it is purged.",
+		Tags:        []string{"books"},
+		Security:    bearerAuth,
+		Parameters:  []openapi.Parameter{idParam},
+		Responses: withErrors(map[string]*openapi.Response{
+			"204": {Description: "Book moved to the trash"},
+		}, http.StatusUnauthorized, http.StatusNotFound),
+	}))
+	doc.Add(http.MethodPost, "/api/books/{id}/merge", idempotent(&openapi.Operation{
+		Summary:     "Merge a duplicate into this book",
+		Description: "Folds the source book into the book in the path and moves the source to the trash.",
+		Tags:        []string{"books"},
+		Security:    bearerAuth,
+		Parameters:  []openapi.Parameter{idParam},
+		RequestBody: jsonRequest(doc.Schema(handlers.MergeBookRequest{})),
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": withETag(success("The merged book", bookSchema)),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound),
+	}))
+
+	doc.Add(http.MethodPost, "/api/books/{id}/restore", idempotent(&openapi.Operation{
+		Summary:     "Restore a book from the trash",
+		Description: "Returns 409 with details.existing_id when the same volume was added to the list again after the book was deleted.",
+		Tags:        []string{"books"},
+		Security:    bearerAuth,
+		Parameters:  []openapi.Parameter{idParam},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": withETag(success("The restored book", bookSchema)),
+		}, http.StatusUnauthorized, http.StatusNotFound, http.StatusConflict),
+	}))
+
+	doc.Add(http.MethodPost, "/api/books/add", idempotent(&openapi.Operation{
+		Summary:     addBook.Summary,
+		Description: "Deprecated: use POST /api/books.",
+		Tags:        addBook.Tags,
+		Deprecated:  true,
+		Security:    bearerAuth,
+		RequestBody: addBook.RequestBody,
+		Responses:   addBook.Responses,
+	}))
+	doc.Add(http.MethodPut, "/api/books/status", idempotent(&openapi.Operation{
+		Summary:     "Update a book's status",
+		Description: "Deprecated: use PATCH /api/books/{id}.",
+		Tags:        []string{"books"},
+		Deprecated:  true,
+		Security:    bearerAuth,
+		Parameters:  []openapi.Parameter{ifMatch},
+		RequestBody: jsonRequest(doc.Schema(handlers.UpdateBookStatusRequest{})),
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": withETag(success("Status updated", nil)),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusPreconditionFailed, http.StatusPreconditionRequired),
+	}))
+	doc.Add(http.MethodPost, "/api/books/merge", idempotent(&openapi.Operation{
+		Summary:     "Merge two books",
+		Description: "Deprecated: use POST /api/books/{id}/merge.",
+		Tags:        []string{"books"},
+		Deprecated:  true,
+		Security:    bearerAuth,
+		RequestBody: jsonRequest(doc.Schema(handlers.MergeBooksRequest{})),
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": success("The merged book", bookSchema),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound),
+	}))
+
+	actions := []interface{}{
+		audit.ActionRegister, audit.ActionLogin, audit.ActionLoginFailed, audit.ActionTokenIssued,
+		audit.ActionBookCreate, audit.ActionBookUpdate, audit.ActionBookDelete, audit.ActionBookRestore, audit.ActionBookMerge,
+		audit.ActionWebhookCreate, audit.ActionWebhookUpdate, audit.ActionWebhookDelete,
+	}
+	doc.Enum(audit.Action(""), actions...)
+	doc.Add(http.MethodGet, "/api/account/activity", &openapi.Operation{
+		Summary:     "List the user's account activity",
+		Description: "Returns audit log entries for the account, newest first: registration, logins including failed attempts, issued tokens and changes to books with their before and after values. To page back, pass the occurred_at of the oldest entry received as until.",
+		Tags:        []string{"account"},
+		Security:    bearerAuth,
+		Parameters: []openapi.Parameter{
+			{Name: "action", In: "query", Description: "Only return entries for this action", Schema: &openapi.Schema{Type: "string", Enum: actions}},
+			{Name: "since", In: "query", Description: "Only return entries at or after this time", Schema: &openapi.Schema{Type: "string", Format: "date-time"}},
+			{Name: "until", In: "query", Description: "Only return entries before this time", Schema: &openapi.Schema{Type: "string", Format: "date-time"}},
+			{Name: "limit", In: "query", Description: "Maximum number of entries, from 1 to 200; defaults to 50", Schema: &openapi.Schema{Type: "integer"}},
+		},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": success("Account activity", &openapi.Schema{Type: "array", Items: doc.Schema(audit.Event{})}),
+		}, http.StatusBadRequest, http.StatusUnauthorized),
+	})
+
+	doc.Add(http.MethodGet, "/api/events", &openapi.Operation{
+		Summary: "Stream library changes",
+		Description: "Server-Sent Events stream of changes to the user's books as they happen, so open clients can update live: book.added, book.status_changed, book.deleted, book.restored and book.merged, each with an id and the event as JSON data. " +
+			"The Authorization header is required, so browsers must read the stream with fetch rather than EventSource. To resume after a disconnect, send the id of the last event received as Last-Event-ID; the missed events are replayed, or a reset event is sent when they are no longer known and the client should reload. " +
+			"Comment lines are sent as keep-alives. Only changes made through the same server instance are streamed.",
+		Tags:     []string{"books"},
+		Security: bearerAuth,
+		Parameters: []openapi.Parameter{
+			{Name: "Last-Event-ID", In: "header", Description: "ID of the last event received, to resume after it", Schema: &openapi.Schema{Type: "string"}},
+		},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": {Description: "Event stream", Content: map[string]*openapi.MediaType{
+				"text/event-stream": {Schema: &openapi.Schema{Type: "string"}},
+			}},
+		}, http.StatusUnauthorized),
+	})
+
+	webhookEvents := make([]interface{}, len(webhook.Events))
+	for i, name := range webhook.Events {
+		webhookEvents[i] = name
+	}
+	createWebhook, updateWebhook := doc.Schema(handlers.CreateWebhookRequest{}), doc.Schema(handlers.UpdateWebhookRequest{})
+	for _, name := range []string{"CreateWebhookRequest", "UpdateWebhookRequest"} {
+		doc.Components.Schemas[name].Properties["events"].Items.Enum = webhookEvents
+	}
+	webhookSchema := doc.Schema(webhook.Webhook{})
+	webhookIDParam := openapi.Parameter{Name: "id", In: "path", Required: true, Description: "Webhook ID", Schema: &openapi.Schema{Type: "string", Format: "uuid"}}
+	doc.Add(http.MethodGet, "/api/webhooks", &openapi.Operation{
+		Summary:  "List the user's webhooks",
+		Tags:     []string{"webhooks"},
+		Security: bearerAuth,
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": success("The user's webhooks, oldest first", &openapi.Schema{Type: "array", Items: webhookSchema}),
+		}, http.StatusUnauthorized),
+	})
+	doc.Add(http.MethodPost, "/api/webhooks", idempotent(&openapi.Operation{
+		Summary: "Register a webhook",
+		Description: "Changes to the user's library are POSTed to the URL as JSON {id, event, occurred_at, data}. Each request carries Webhook-Id, Webhook-Event, Webhook-Timestamp (Unix seconds) and Webhook-Signature headers; the signature is \"sha256=\" and the hex HMAC-SHA256 of \"<timestamp>.<body>\" keyed with the webhook's secret. " +
+			"A delivery succeeds on a 2xx response; redirects are not followed. Failed deliveries are retried with exponential backoff and carry the same Webhook-Id. " +
+			"The secret is only returned here. A user can have at most 10 webhooks.",
+		Tags:        []string{"webhooks"},
+		Security:    bearerAuth,
+		RequestBody: jsonRequest(createWebhook),
+		Responses: withErrors(map[string]*openapi.Response{
+			"201": success("The webhook with its secret", doc.Schema(handlers.CreatedWebhook{})),
+		}, http.StatusBadRequest, http.StatusUnauthorized),
+	}))
+	doc.Add(http.MethodGet, "/api/webhooks/{id}", &openapi.Operation{
+		Summary:    "Get a webhook",
+		Tags:       []string{"webhooks"},
+		Security:   bearerAuth,
+		Parameters: []openapi.Parameter{webhookIDParam},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": success("The webhook", webhookSchema),
+		}, http.StatusUnauthorized, http.StatusNotFound),
+	})
+	doc.Add(http.MethodPatch, "/api/webhooks/{id}", idempotent(&openapi.Operation{
+		Summary:     "Update a webhook",
+		Description: "Changes the URL, the subscribed events or whether the webhook is active; omitted fields are left as they are. Inactive webhooks receive no events.",
+		Tags:        []string{"webhooks"},
+		Security:    bearerAuth,
+		Parameters:  []openapi.Parameter{webhookIDParam},
+		RequestBody: jsonRequest(updateWebhook),
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": success("The updated webhook", webhookSchema),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound),
+	}))
+	doc.Add(http.MethodDelete, "/api/webhooks/{id}", idempotent(&openapi.Operation{
+		Summary:    "Delete a webhook",
+		Tags:       []string{"webhooks"},
+		Security:   bearerAuth,
+		Parameters: []openapi.Parameter{webhookIDParam},
+		Responses: withErrors(map[string]*openapi.Response{
+			"204": {Description: "Webhook and its delivery log deleted"},
+		}, http.StatusUnauthorized, http.StatusNotFound),
+	}))
+	deliverySchema := doc.Schema(webhook.Delivery{})
+	doc.Add(http.MethodPost, "/api/webhooks/{id}/test", idempotent(&openapi.Operation{
+		Summary:     "Send a test event",
+		Description: "Delivers a webhook.test event right away, even to an inactive webhook, and returns the attempt. A receiver rejecting it is reported in the delivery, not as an error.",
+		Tags:        []string{"webhooks"},
+		Security:    bearerAuth,
+		Parameters:  []openapi.Parameter{webhookIDParam},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": success("The delivery attempt", deliverySchema),
+		}, http.StatusUnauthorized, http.StatusNotFound),
+	}))
+	doc.Add(http.MethodGet, "/api/webhooks/{id}/deliveries", &openapi.Operation{
+		Summary:  "List a webhook's deliveries",
+		Tags:     []string{"webhooks"},
+		Security: bearerAuth,
+		Parameters: []openapi.Parameter{
+			webhookIDParam,
+			{Name: "limit", In: "query", Description: "Maximum number of deliveries, from 1 to 100; defaults to 20", Schema: &openapi.Schema{Type: "integer"}},
+		},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": success("Delivery attempts with the receiver's response codes, newest first", &openapi.Schema{Type: "array", Items: deliverySchema}),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound),
+	})
+
+	sizes := []interface{}{cover.SizeOriginal, cover.SizeSmall, cover.SizeMedium, cover.SizeLarge}
+	doc.Add(http.MethodGet, "/covers/{id}", &openapi.Operation{
+		Summary:     "Get a book's cover",
+		Description: "Serves the cached cover, or an SVG placeholder with the title and authors when the book has none. Supports ETag revalidation.",
+		Tags:        []string{"covers"},
+		Parameters: []openapi.Parameter{
+			idParam,
+			{Name: "size", In: "query", Description: "Width variant; defaults to original", Schema: &openapi.Schema{Type: "string", Enum: sizes}},
+		},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": {Description: "Cover image", Content: map[string]*openapi.MediaType{
+				"image/*": {Schema: &openapi.Schema{Type: "string", Format: "binary"}},
+			}},
+			"304": {Description: "Not modified"},
+		}, http.StatusBadRequest, http.StatusNotFound),
+	})
+
+	doc.Enum(health.Status(""), health.StatusOK, health.StatusDegraded, health.StatusFailing)
+	healthReport := doc.Schema(health.Report{})
+	doc.Add(http.MethodGet, "/healthz", &openapi.Operation{
+		Summary: "Liveness probe",
+		Tags:    []string{"meta"},
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "The process is serving requests", Content: jsonContent(healthReport)},
+		},
+	})
+	doc.Add(http.MethodGet, "/readyz", &openapi.Operation{
+		Summary:     "Readiness probe",
+		Description: "Checks the database connection, that the schema is at the migration version this build expects, and the Google Books circuit breaker. Only critical checks make the service unready; a failing non-critical check reports degraded. Checks report their status only; the admin listener adds why they failed.",
+		Tags:        []string{"meta"},
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "Ready, possibly degraded", Content: jsonContent(healthReport)},
+			"503": {Description: "A critical dependency is failing", Content: jsonContent(healthReport)},
+		},
+	})
+
+	doc.Add(http.MethodGet, "/api/openapi.json", &openapi.Operation{
+		Summary: "This document",
+		Tags:    []string{"meta"},
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "OpenAPI document", Content: jsonContent(&openapi.Schema{Type: "object"})},
+		},
+	})
+
+	return doc
+}
+
+func jsonContent(schema *openapi.Schema) map[string]*openapi.MediaType {
+	return map[string]*openapi.MediaType{"application/json": {Schema: schema}}
+}
+
+func jsonRequest(schema *openapi.Schema) *openapi.RequestBody {
+	return &openapi.RequestBody{Required: true, Content: jsonContent(schema)}
+}
+
+// success describes a {success, data} envelope; data may be nil
+func success(description string, data *openapi.Schema) *openapi.Response {
+	envelope := &openapi.Schema{
+		Type:       "object",
+		Properties: map[string]*openapi.Schema{"success": {Type: "boolean"}},
+		Required:   []string{"success"},
+	}
+	if data != nil {
+		envelope.Properties["data"] = data
+		envelope.Required = append(envelope.Required, "data")
+	}
+	return &openapi.Response{Description: description, Content: jsonContent(envelope)}
+}
+
+// withETag documents the ETag header sent with a response
+func withETag(resp *openapi.Response) *openapi.Response {
+	resp.Headers = map[string]*openapi.Header{
+		"ETag": {Description: "Version of the returned data, for If-None-Match and If-Match", Schema: &openapi.Schema{Type: "string"}},
+	}
+	return resp
+}
+
+// idempotencyKey lets clients retry a write without repeating it
+var idempotencyKey = openapi.Parameter{
+	Name:        "Idempotency-Key",
+	In:          "header",
+	Description: "Unique key for this request, up to 255 characters. A retry with the same key and body within the retention window gets the first response back, marked with Idempotent-Replayed: true; reusing the key for a different request fails with 422, and a retry while the first attempt is still running fails with 409.",
+	Schema:      &openapi.Schema{Type: "string"},
+}
+
+// idempotent documents the Idempotency-Key header on a write operation
+func idempotent(op *openapi.Operation) *openapi.Operation {
+	op.Parameters = append(op.Parameters, idempotencyKey)
+	withErrors(op.Responses, http.StatusConflict, http.StatusUnprocessableEntity)
+	return op
+}
+
+// withErrors adds error envelope responses for the given statuses
+func withErrors(responses map[string]*openapi.Response, statuses ...int) map[string]*openapi.Response {
+	ref := &openapi.Schema{Ref: "#/components/schemas/ErrorResponse"}
+	for _, status := range append(statuses, http.StatusInternalServerError) {
+		responses[strconv.Itoa(status)] = &openapi.Response{
+			Description: http.StatusText(status),
+			Content:     jsonContent(ref),
+		}
+	}
+	return responses
+}
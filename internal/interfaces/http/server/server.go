@@ -1,90 +1,323 @@
 package server
 
 import (
-	"log"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
 	"net/http"
+	"time"
 
 	"github.com/guisithos/save-my-read/internal/domain/auth"
 	"github.com/guisithos/save-my-read/internal/interfaces/http/handlers"
 	"github.com/guisithos/save-my-read/internal/interfaces/http/middleware"
+	"github.com/guisithos/save-my-read/internal/interfaces/http/openapi"
+	"github.com/guisithos/save-my-read/internal/interfaces/http/response"
+	"github.com/guisithos/save-my-read/internal/metrics"
+	"github.com/guisithos/save-my-read/web"
 )
 
 // Server represents the HTTP server
 type Server struct {
-	authHandler  *handlers.AuthHandler
-	bookHandler  *handlers.BookHandler
-	tokenService auth.TokenService
-	port         string
+	authHandler    *handlers.AuthHandler
+	bookHandler    *handlers.BookHandler
+	coverHandler   *handlers.CoverHandler
+	healthHandler  *handlers.HealthHandler
+	auditHandler   *handlers.AuditHandler
+	webhookHandler *handlers.WebhookHandler
+	streamHandler  *handlers.StreamHandler
+	tokenService   auth.TokenService
+	opts           Options
+	spec           *openapi.Document
+
+	mux    *http.ServeMux
+	routes []string
+}
+
+// Options configures how the server listens and how long it waits on clients
+type Options struct {
+	// Addr is host:port or unix:/path/to.sock
+	Addr              string
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	// ShutdownTimeout bounds how long in-flight requests may take to drain
+	ShutdownTimeout time.Duration
+	// TLSCertFile and TLSKeyFile enable HTTPS when both are set
+	TLSCertFile string
+	TLSKeyFile  string
+	CORS        middleware.CORSOptions
+	// AuditSource sets the proxies trusted to report the client address
+	AuditSource middleware.AuditSourceOptions
+	// Logger receives access logs and panics; slog.Default() when nil
+	Logger *slog.Logger
+	// Metrics, when set, records every request and is served at /metrics
+	Metrics *metrics.Metrics
+	// AdminAddr, when set, serves /metrics and the health probes on a
+	// separate listener and keeps /metrics off the public one
+	AdminAddr string
+	// Idempotency, when its Store is set, lets clients retry book writes
+	// with an Idempotency-Key header
+	Idempotency middleware.IdempotencyOptions
 }
 
 // NewServer creates a new HTTP server
-func NewServer(authHandler *handlers.AuthHandler, bookHandler *handlers.BookHandler, tokenService auth.TokenService, port string) *Server {
+func NewServer(authHandler *handlers.AuthHandler, bookHandler *handlers.BookHandler, coverHandler *handlers.CoverHandler, healthHandler *handlers.HealthHandler, auditHandler *handlers.AuditHandler, webhookHandler *handlers.WebhookHandler, streamHandler *handlers.StreamHandler, tokenService auth.TokenService, opts Options) *Server {
+	if opts.Logger == nil {
+		opts.Logger = slog.Default()
+	}
 	return &Server{
-		authHandler:  authHandler,
-		bookHandler:  bookHandler,
-		tokenService: tokenService,
-		port:         port,
+		authHandler:    authHandler,
+		bookHandler:    bookHandler,
+		coverHandler:   coverHandler,
+		healthHandler:  healthHandler,
+		auditHandler:   auditHandler,
+		webhookHandler: webhookHandler,
+		streamHandler:  streamHandler,
+		tokenService:   tokenService,
+		opts:           opts,
+		spec:           apiSpec(),
 	}
 }
 
 // SetupRoutes sets up the routes for the HTTP server
 func (s *Server) SetupRoutes() http.Handler {
-	mux := http.NewServeMux()
+	s.mux = http.NewServeMux()
+	s.routes = nil
+	protected := middleware.NewAuthMiddleware(s.tokenService)
+	// Writes may be retried with an Idempotency-Key; keys are per user, so
+	// the check runs after authentication
+	write := protected
+	if s.opts.Idempotency.Store != nil {
+		idempotent := middleware.Idempotency(s.opts.Idempotency)
+		write = func(next http.Handler) http.Handler { return protected(idempotent(next)) }
+	}
+
+	// Public routes (no auth required). They take no Idempotency-Key, as
+	// keys are kept per user; see middleware.Idempotency.
+	s.handleFunc("POST /api/auth/register", s.authHandler.Register)
+	s.handleFunc("POST /api/auth/login", s.authHandler.Login)
+	s.handleFunc("GET /api/books/search", s.bookHandler.SearchBooks)
+	s.handleFunc("GET /covers/{id}", s.coverHandler.GetCover)
 
-	// Public routes (no auth required)
-	mux.HandleFunc("/api/auth/register", s.authHandler.Register)
-	mux.HandleFunc("/api/auth/login", s.authHandler.Login)
-	mux.HandleFunc("/api/books/search", s.bookHandler.SearchBooks)
+	// Book resource routes (auth required)
+	s.handle("GET /api/books", protected(http.HandlerFunc(s.bookHandler.GetBooks)))
+	s.handle("POST /api/books", write(http.HandlerFunc(s.bookHandler.AddBook)))
+	s.handle("GET /api/books/trash", protected(http.HandlerFunc(s.bookHandler.GetTrash)))
+	s.handle("GET /api/books/{id}", protected(http.HandlerFunc(s.bookHandler.GetBook)))
+	s.handle("PATCH /api/books/{id}", write(http.HandlerFunc(s.bookHandler.UpdateBook)))
+	s.handle("DELETE /api/books/{id}", write(http.HandlerFunc(s.bookHandler.DeleteBook)))
+	s.handle("POST /api/books/{id}/merge", write(http.HandlerFunc(s.bookHandler.MergeBook)))
+	s.handle("POST /api/books/{id}/restore", write(http.HandlerFunc(s.bookHandler.RestoreBook)))
 
-	// Protected routes (auth required)
-	protectedMux := http.NewServeMux()
-	protectedMux.HandleFunc("/api/books", s.bookHandler.GetBooks)
-	protectedMux.HandleFunc("/api/books/add", s.bookHandler.AddBook)
-	protectedMux.HandleFunc("/api/books/status", s.bookHandler.UpdateBookStatus)
+	// Deprecated aliases kept for existing clients
+	s.handle("POST /api/books/add", deprecated("/api/books", write(http.HandlerFunc(s.bookHandler.AddBook))))
+	s.handle("PUT /api/books/status", deprecated("/api/books/{id}", write(http.HandlerFunc(s.bookHandler.UpdateBookStatus))))
+	s.handle("POST /api/books/merge", deprecated("/api/books/{id}/merge", write(http.HandlerFunc(s.bookHandler.MergeBooks))))
 
-	// Apply auth middleware to protected routes
-	authMiddleware := middleware.NewAuthMiddleware(s.tokenService)
-	mux.Handle("/api/books/", authMiddleware(protectedMux))
+	// Account activity from the audit log
+	s.handle("GET /api/account/activity", protected(http.HandlerFunc(s.auditHandler.Activity)))
+
+	// Live library updates for open clients
+	s.handle("GET /api/events", protected(http.HandlerFunc(s.streamHandler.Stream)))
+
+	// Outgoing webhooks
+	s.handle("GET /api/webhooks", protected(http.HandlerFunc(s.webhookHandler.GetWebhooks)))
+	s.handle("POST /api/webhooks", write(http.HandlerFunc(s.webhookHandler.CreateWebhook)))
+	s.handle("GET /api/webhooks/{id}", protected(http.HandlerFunc(s.webhookHandler.GetWebhook)))
+	s.handle("PATCH /api/webhooks/{id}", write(http.HandlerFunc(s.webhookHandler.UpdateWebhook)))
+	s.handle("DELETE /api/webhooks/{id}", write(http.HandlerFunc(s.webhookHandler.DeleteWebhook)))
+	s.handle("POST /api/webhooks/{id}/test", write(http.HandlerFunc(s.webhookHandler.TestWebhook)))
+	s.handle("GET /api/webhooks/{id}/deliveries", protected(http.HandlerFunc(s.webhookHandler.GetDeliveries)))
+
+	// Probes for load balancers and orchestrators
+	s.handleFunc("GET /healthz", s.healthHandler.Live)
+	s.handleFunc("GET /readyz", s.healthHandler.Ready)
+	if s.opts.Metrics != nil && s.opts.AdminAddr == "" {
+		s.handle("GET /metrics", s.opts.Metrics.Handler())
+	}
+
+	// API documentation
+	s.handleFunc("GET /api/openapi.json", s.serveSpec)
+	s.handleFunc("GET /docs", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFileFS(w, r, web.Docs, "index.html")
+	})
 
 	// Serve static files and templates
-	fs := http.FileServer(http.Dir("web/templates"))
-	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+	s.handle("GET /assets/", http.StripPrefix("/assets/", http.FileServerFS(web.Assets)))
+	fs := http.FileServerFS(web.Templates)
+	s.handleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path == "/" {
-			http.ServeFile(w, r, "web/templates/index.html")
+			http.ServeFileFS(w, r, web.Templates, "index.html")
 			return
 		}
 		fs.ServeHTTP(w, r)
 	})
 
-	return mux
+	chain := []middleware.Middleware{
+		middleware.RequestID,
+		middleware.AuditSource(s.opts.AuditSource),
+		middleware.Tracing,
+		middleware.AccessLog(s.opts.Logger),
+	}
+	if s.opts.Metrics != nil {
+		chain = append(chain, middleware.Metrics(s.opts.Metrics))
+	}
+	chain = append(chain,
+		middleware.Recover(s.opts.Logger),
+		middleware.SecurityHeaders,
+		middleware.CORS(s.opts.CORS),
+		middleware.Route,
+	)
+	return middleware.Chain(chain...)(s.mux)
+}
+
+// AdminHandler serves metrics, the health probes and the audit log for the
+// admin listener, which has no authentication of its own and must not be
+// reachable from outside
+func (s *Server) AdminHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", s.healthHandler.Live)
+	mux.HandleFunc("GET /readyz", s.healthHandler.ReadyDetail)
+	mux.HandleFunc("GET /admin/audit", s.auditHandler.Events)
+	if s.opts.Metrics != nil {
+		mux.Handle("GET /metrics", s.opts.Metrics.Handler())
+	}
+	return middleware.Recover(s.opts.Logger)(mux)
 }
 
-// Start starts the HTTP server
-func (s *Server) Start() error {
-	mux := s.SetupRoutes()
+// Routes returns the patterns registered by SetupRoutes, in registration order
+func (s *Server) Routes() []string {
+	return append([]string(nil), s.routes...)
+}
 
-	log.Printf("Server starting on port %s", s.port)
-	return http.ListenAndServe(":"+s.port, mux)
+func (s *Server) handle(pattern string, h http.Handler) {
+	s.routes = append(s.routes, pattern)
+	s.mux.Handle(pattern, h)
 }
 
-func addMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Add CORS headers
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With")
-		w.Header().Set("Access-Control-Max-Age", "3600")
-
-		// Handle preflight requests
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
+func (s *Server) handleFunc(pattern string, h http.HandlerFunc) {
+	s.handle(pattern, h)
+}
 
-		// Log request
-		log.Printf("%s %s", r.Method, r.URL.Path)
+// serveSpec writes the OpenAPI document for the registered routes
+func (s *Server) serveSpec(w http.ResponseWriter, r *http.Request) {
+	response.JSON(w, http.StatusOK, s.spec)
+}
 
-		// Call the next handler
+// deprecated marks responses from a legacy route and points clients at its replacement
+func deprecated(successor string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", "<"+successor+">; rel=\"successor-version\"")
 		next.ServeHTTP(w, r)
 	})
 }
+
+// Run serves until ctx is cancelled, then stops accepting connections and
+// waits up to ShutdownTimeout for in-flight requests to finish
+func (s *Server) Run(ctx context.Context) error {
+	handler := s.SetupRoutes()
+
+	var reloader *certReloader
+	if s.opts.TLSCertFile != "" {
+		var err error
+		reloader, err = newCertReloader(s.opts.TLSCertFile, s.opts.TLSKeyFile, s.opts.Logger)
+		if err != nil {
+			return err
+		}
+	}
+
+	ln, err := listen(s.opts.Addr)
+	if err != nil {
+		return fmt.Errorf("error listening on %s: %w", s.opts.Addr, err)
+	}
+
+	httpServer := &http.Server{
+		Handler:           handler,
+		ErrorLog:          slog.NewLogLogger(s.opts.Logger.Handler(), slog.LevelWarn),
+		ReadTimeout:       s.opts.ReadTimeout,
+		ReadHeaderTimeout: s.opts.ReadHeaderTimeout,
+		WriteTimeout:      s.opts.WriteTimeout,
+		IdleTimeout:       s.opts.IdleTimeout,
+	}
+	if reloader != nil {
+		httpServer.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: reloader.GetCertificate,
+		}
+	}
+
+	servers := []*http.Server{httpServer}
+	listeners := []net.Listener{ln}
+	s.opts.Logger.Info("server listening", "addr", s.opts.Addr, "tls", reloader != nil)
+
+	if s.opts.AdminAddr != "" {
+		adminLn, err := listen(s.opts.AdminAddr)
+		if err != nil {
+			ln.Close()
+			return fmt.Errorf("error listening on admin address %s: %w", s.opts.AdminAddr, err)
+		}
+		servers = append(servers, &http.Server{
+			Handler:           s.AdminHandler(),
+			ReadHeaderTimeout: s.opts.ReadHeaderTimeout,
+			ErrorLog:          httpServer.ErrorLog,
+		})
+		listeners = append(listeners, adminLn)
+		s.opts.Logger.Info("admin server listening", "addr", s.opts.AdminAddr)
+	}
+
+	// Stop every server as soon as one of them fails
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errc := make(chan error, len(servers))
+	for i := range servers {
+		go func() {
+			errc <- serve(ctx, servers[i], listeners[i], s.opts.ShutdownTimeout, s.opts.Logger)
+		}()
+	}
+
+	var firstErr error
+	for range servers {
+		if err := <-errc; err != nil && firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+	return firstErr
+}
+
+// serve runs httpServer on ln until ctx is done and then shuts it down gracefully
+func serve(ctx context.Context, httpServer *http.Server, ln net.Listener, shutdownTimeout time.Duration, logger *slog.Logger) error {
+	errc := make(chan error, 1)
+	go func() {
+		if httpServer.TLSConfig != nil {
+			errc <- httpServer.ServeTLS(ln, "", "")
+		} else {
+			errc <- httpServer.Serve(ln)
+		}
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	logger.Info("shutting down, draining in-flight requests", "timeout", shutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		httpServer.Close()
+		return fmt.Errorf("error shutting down server: %w", err)
+	}
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
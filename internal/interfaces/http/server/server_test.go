@@ -0,0 +1,352 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/guisithos/save-my-read/internal/domain/auth"
+	"github.com/guisithos/save-my-read/internal/health"
+	"github.com/guisithos/save-my-read/internal/interfaces/http/handlers"
+	"github.com/guisithos/save-my-read/internal/logging"
+	"github.com/guisithos/save-my-read/internal/metrics"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// newTestServer creates a server with zero-value handlers that logs nowhere
+func newTestServer(opts Options) *Server {
+	if opts.Logger == nil {
+		opts.Logger = logging.Discard()
+	}
+	return NewServer(&handlers.AuthHandler{}, &handlers.BookHandler{}, &handlers.CoverHandler{}, &handlers.HealthHandler{}, &handlers.AuditHandler{}, &handlers.WebhookHandler{}, &handlers.StreamHandler{}, auth.NewJWTService("secret", 0), opts)
+}
+
+func TestSetupRoutes(t *testing.T) {
+	srv := newTestServer(Options{})
+	routes := srv.SetupRoutes()
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		expectedStatus int
+		deprecated     bool
+	}{
+		{"list requires auth", http.MethodGet, "/api/books", http.StatusUnauthorized, false},
+		{"create requires auth", http.MethodPost, "/api/books", http.StatusUnauthorized, false},
+		{"get requires auth", http.MethodGet, "/api/books/123", http.StatusUnauthorized, false},
+		{"patch requires auth", http.MethodPatch, "/api/books/123", http.StatusUnauthorized, false},
+		{"delete requires auth", http.MethodDelete, "/api/books/123", http.StatusUnauthorized, false},
+		{"unsupported method", http.MethodPut, "/api/books/123", http.StatusMethodNotAllowed, false},
+		{"collection delete not allowed", http.MethodDelete, "/api/books", http.StatusMethodNotAllowed, false},
+		{"legacy add", http.MethodPost, "/api/books/add", http.StatusUnauthorized, true},
+		{"legacy status", http.MethodPut, "/api/books/status", http.StatusUnauthorized, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			w := httptest.NewRecorder()
+
+			routes.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if got := w.Header().Get("Deprecation") != ""; got != tt.deprecated {
+				t.Errorf("expected deprecated=%v, got %v", tt.deprecated, got)
+			}
+		})
+	}
+}
+
+func TestAPISpecCoversRoutes(t *testing.T) {
+	srv := newTestServer(Options{})
+	srv.SetupRoutes()
+
+	registered := make(map[string]bool)
+	for _, pattern := range srv.Routes() {
+		method, path, ok := strings.Cut(pattern, " ")
+		if !ok {
+			t.Fatalf("route %q has no method", pattern)
+		}
+		registered[strings.ToLower(method)+" "+path] = true
+		// The frontend and its assets are not part of the API
+		if !strings.HasPrefix(path, "/api/") && !strings.HasPrefix(path, "/covers/") {
+			continue
+		}
+		if !srv.spec.Has(method, path) {
+			t.Errorf("route %q is missing from the OpenAPI spec", pattern)
+		}
+	}
+
+	for path, ops := range srv.spec.Paths {
+		for method := range ops {
+			if !registered[method+" "+path] {
+				t.Errorf("spec documents %s %s, which is not a registered route", strings.ToUpper(method), path)
+			}
+		}
+	}
+}
+
+func TestServeSpecAndDocs(t *testing.T) {
+	srv := newTestServer(Options{})
+	routes := srv.SetupRoutes()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil)
+	w := httptest.NewRecorder()
+	routes.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	if w.Header().Get("X-Request-ID") == "" || w.Header().Get("X-Content-Type-Options") != "nosniff" {
+		t.Errorf("expected the middleware chain to set request ID and security headers, got %v", w.Header())
+	}
+	var doc struct {
+		OpenAPI string                    `json:"openapi"`
+		Paths   map[string]map[string]any `json:"paths"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&doc); err != nil {
+		t.Fatalf("spec is not valid JSON: %v", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		t.Errorf("expected an OpenAPI 3 document, got version %q", doc.OpenAPI)
+	}
+	if _, ok := doc.Paths["/api/books/{id}"]["patch"]; !ok {
+		t.Error("expected PATCH /api/books/{id} in the served spec")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/docs", nil)
+	w = httptest.NewRecorder()
+	routes.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected docs status 200, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "/api/openapi.json") {
+		t.Error("expected the docs page to load the spec")
+	}
+}
+
+func TestServe_DrainsInFlightRequests(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	started := make(chan struct{})
+	httpServer := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte("done"))
+	})}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- serve(ctx, httpServer, ln, 5*time.Second, logging.Discard()) }()
+
+	type result struct {
+		body string
+		err  error
+	}
+	got := make(chan result, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String())
+		if err != nil {
+			got <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		got <- result{string(body), err}
+	}()
+
+	<-started
+	cancel()
+
+	res := <-got
+	if res.err != nil || res.body != "done" {
+		t.Errorf("expected in-flight request to complete, got %q, %v", res.body, res.err)
+	}
+	if err := <-served; err != nil {
+		t.Errorf("expected clean shutdown, got %v", err)
+	}
+}
+
+func TestRun_UnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api.sock")
+	srv := newTestServer(Options{
+		Addr:            "unix:" + path,
+		ShutdownTimeout: time.Second,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- srv.Run(ctx) }()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}}
+
+	var resp *http.Response
+	var err error
+	for i := 0; i < 50; i++ {
+		if resp, err = client.Get("http://unix/api/openapi.json"); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("request over unix socket failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("expected clean shutdown, got %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected socket to be removed on shutdown, got %v", err)
+	}
+}
+
+func TestProbesAndMetrics(t *testing.T) {
+	get := func(h http.Handler, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	t.Run("metrics on the main listener", func(t *testing.T) {
+		srv := newTestServer(Options{Metrics: metrics.New()})
+		routes := srv.SetupRoutes()
+
+		for _, path := range []string{"/healthz", "/readyz"} {
+			if w := get(routes, path); w.Code != http.StatusOK {
+				t.Errorf("%s: expected status 200, got %d", path, w.Code)
+			}
+		}
+
+		get(routes, "/api/books/123")
+		w := get(routes, "/metrics")
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected metrics status 200, got %d", w.Code)
+		}
+		want := `save_my_read_http_requests_total{code="401",method="GET",route="/api/books/{id}"} 1`
+		if !strings.Contains(w.Body.String(), want) {
+			t.Errorf("expected %s in metrics output", want)
+		}
+	})
+
+	t.Run("metrics on the admin listener", func(t *testing.T) {
+		srv := newTestServer(Options{Metrics: metrics.New(), AdminAddr: "127.0.0.1:0"})
+		routes := srv.SetupRoutes()
+
+		if w := get(routes, "/metrics"); strings.Contains(w.Body.String(), "save_my_read_") {
+			t.Error("expected metrics to be kept off the main listener")
+		}
+		if w := get(srv.AdminHandler(), "/metrics"); w.Code != http.StatusOK {
+			t.Errorf("expected admin metrics status 200, got %d", w.Code)
+		}
+		if w := get(srv.AdminHandler(), "/readyz"); w.Code != http.StatusOK {
+			t.Errorf("expected admin readyz status 200, got %d", w.Code)
+		}
+	})
+
+	t.Run("check errors only on the admin listener", func(t *testing.T) {
+		checker := health.NewChecker(time.Second)
+		checker.Add("database", true, func(context.Context) error {
+			return errors.New("dial tcp 10.0.0.5:5432: connection refused")
+		})
+		var logs bytes.Buffer
+		logger := slog.New(slog.NewJSONHandler(&logs, nil))
+		srv := NewServer(&handlers.AuthHandler{}, &handlers.BookHandler{}, &handlers.CoverHandler{}, handlers.NewHealthHandler(checker, logger), &handlers.AuditHandler{}, &handlers.WebhookHandler{}, &handlers.StreamHandler{}, auth.NewJWTService("secret", 0), Options{Logger: logging.Discard(), AdminAddr: "127.0.0.1:0"})
+
+		w := get(srv.SetupRoutes(), "/readyz")
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("expected readyz status 503, got %d", w.Code)
+		}
+		if strings.Contains(w.Body.String(), "10.0.0.5") {
+			t.Errorf("expected the public probe to hide check errors, got %s", w.Body)
+		}
+		if !strings.Contains(logs.String(), "10.0.0.5") {
+			t.Errorf("expected the check error to be logged, got %q", logs.String())
+		}
+		if w := get(srv.AdminHandler(), "/readyz"); !strings.Contains(w.Body.String(), "10.0.0.5") {
+			t.Errorf("expected the admin probe to report check errors, got %s", w.Body)
+		}
+	})
+}
+
+func TestSetupRoutes_ReportsRoute(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, nil))
+	srv := newTestServer(Options{Logger: logger, Metrics: metrics.New()})
+	routes := srv.SetupRoutes()
+
+	routes.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/books/123", nil))
+
+	var entry struct {
+		Route  string `json:"route"`
+		Status int    `json:"status"`
+	}
+	if err := json.Unmarshal(logs.Bytes(), &entry); err != nil {
+		t.Fatalf("expected one access log line, got %q", logs.String())
+	}
+	if entry.Route != "GET /api/books/{id}" || entry.Status != http.StatusUnauthorized {
+		t.Errorf("expected the route in the access log, got %+v", entry)
+	}
+
+	w := httptest.NewRecorder()
+	srv.opts.Metrics.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	want := `save_my_read_http_requests_total{code="401",method="GET",route="/api/books/{id}"} 1`
+	if !strings.Contains(w.Body.String(), want) {
+		t.Errorf("expected %s in metrics output", want)
+	}
+}
+
+func TestSetupRoutes_TracesRoute(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	routes := newTestServer(Options{Metrics: metrics.New()}).SetupRoutes()
+	routes.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/books/123", nil))
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	if spans[0].Name != "GET /api/books/{id}" {
+		t.Errorf("expected the span named after the route, got %q", spans[0].Name)
+	}
+	var route string
+	for _, kv := range spans[0].Attributes {
+		if kv.Key == "http.route" {
+			route = kv.Value.AsString()
+		}
+	}
+	if route != "/api/books/{id}" {
+		t.Errorf("expected the http.route attribute, got %q", route)
+	}
+}
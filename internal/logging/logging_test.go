@@ -0,0 +1,101 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/guisithos/save-my-read/internal/domain/user"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		level, format string
+		wantErr       bool
+	}{
+		{"info", "json", false},
+		{"DEBUG", "text", false},
+		{"warn", "JSON", false},
+		{"verbose", "json", true},
+		{"info", "xml", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.level+"/"+tt.format, func(t *testing.T) {
+			_, err := New(&bytes.Buffer{}, tt.level, tt.format)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestContextAttributes(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, "info", FormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := With(context.Background(), slog.String("request_id", "req-1"))
+	ctx = With(ctx, slog.String("user_id", "user-1"))
+	logger.With("component", "test").InfoContext(ctx, "hello")
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("expected JSON output, got %q", buf.String())
+	}
+	for k, v := range map[string]string{"request_id": "req-1", "user_id": "user-1", "component": "test"} {
+		if entry[k] != v {
+			t.Errorf("expected %s=%s, got %v", k, v, entry[k])
+		}
+	}
+}
+
+func TestRedaction(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, "debug", FormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u := &user.User{ID: "u1", Email: "reader@example.com", Password: "$2a$10$hashhashhash"}
+	logger.Info("sensitive",
+		"password", "hunter2",
+		"password_hash", "$2a$10$abc",
+		slog.Group("request", slog.String("Authorization", "Bearer abc.def.ghi")),
+		"jwt_secret", "signing-key",
+		"api_key", "google-key",
+		"note", Secret("opaque"),
+		"user", u,
+	)
+
+	out := buf.String()
+	for _, leaked := range []string{"hunter2", "$2a$10$abc", "abc.def.ghi", "signing-key", "google-key", "opaque", "hashhashhash"} {
+		if strings.Contains(out, leaked) {
+			t.Errorf("%q leaked into %s", leaked, out)
+		}
+	}
+	if !strings.Contains(out, "reader@example.com") {
+		t.Errorf("expected non-secret user fields to be logged, got %s", out)
+	}
+}
+
+func TestRedactURL(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"postgres://app:hunter2@db:5432/books?sslmode=disable", "postgres://app:xxxxx@db:5432/books?sslmode=disable"},
+		{"https://www.googleapis.com/books/v1/volumes?key=abc&q=go", "https://www.googleapis.com/books/v1/volumes?key=xxxxx&q=go"},
+		{"postgres://db/books", "postgres://db/books"},
+	}
+
+	for _, tt := range tests {
+		if got := RedactURL(tt.in); got != tt.want {
+			t.Errorf("RedactURL(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package logging
+
+import (
+	"log/slog"
+	"net/url"
+	"strings"
+)
+
+// Redacted replaces sensitive values in log output
+const Redacted = "[REDACTED]"
+
+// sensitiveKeys are attribute key fragments whose values are never logged,
+// whatever their type or nesting
+var sensitiveKeys = []string{
+	"password",
+	"passwd",
+	"secret",
+	"token",
+	"authorization",
+	"cookie",
+	"api_key",
+	"apikey",
+	"hash",
+	"private_key",
+}
+
+// IsSensitiveKey reports whether an attribute named key must be redacted
+func IsSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, s := range sensitiveKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReplaceAttr redacts attributes with sensitive keys. It is installed on
+// every handler built by New and can be reused in custom handlers.
+func ReplaceAttr(_ []string, a slog.Attr) slog.Attr {
+	if IsSensitiveKey(a.Key) {
+		return slog.String(a.Key, Redacted)
+	}
+	return a
+}
+
+// Secret is a string that never appears in logs, whatever key it is logged under
+type Secret string
+
+// LogValue implements slog.LogValuer
+func (Secret) LogValue() slog.Value {
+	return slog.StringValue(Redacted)
+}
+
+// String keeps the value out of fmt output as well
+func (Secret) String() string {
+	return Redacted
+}
+
+// RedactURL returns rawURL with any password and sensitive query parameters
+// replaced, for logging connection strings and outbound requests
+func RedactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return Redacted
+	}
+	if _, hasPassword := u.User.Password(); hasPassword {
+		u.User = url.UserPassword(u.User.Username(), "xxxxx")
+	}
+	if q := u.Query(); len(q) > 0 {
+		for key := range q {
+			if IsSensitiveKey(key) || strings.EqualFold(key, "key") {
+				q.Set(key, "xxxxx")
+			}
+		}
+		u.RawQuery = q.Encode()
+	}
+	return u.String()
+}
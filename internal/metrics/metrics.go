@@ -0,0 +1,174 @@
+// Package metrics collects Prometheus metrics for HTTP traffic, the database
+// pool, upstream APIs, caches and domain events, and serves them in the text format.
+package metrics
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/guisithos/save-my-read/internal/domain/cover"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "save_my_read"
+
+// Metrics owns a registry and the collectors the application reports to
+type Metrics struct {
+	registry *prometheus.Registry
+
+	requests         *prometheus.CounterVec
+	requestDuration  *prometheus.HistogramVec
+	upstreamDuration *prometheus.HistogramVec
+	upstreamErrors   *prometheus.CounterVec
+	cacheLookups     *prometheus.CounterVec
+	events           *prometheus.CounterVec
+}
+
+// New creates Metrics with Go runtime and process collectors registered
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by route, method and status code.",
+		}, []string{"route", "method", "code"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by route and method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method"}),
+		upstreamDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "upstream_request_duration_seconds",
+			Help:      "Latency of calls to external APIs by service and outcome.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"service", "outcome"}),
+		upstreamErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "upstream_errors_total",
+			Help:      "Failed calls to external APIs by service: transport errors and 5xx or 429 responses.",
+		}, []string{"service"}),
+		cacheLookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cache_lookups_total",
+			Help:      "Cache lookups by cache and result (hit or miss).",
+		}, []string{"cache", "result"}),
+		events: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "domain_events_total",
+			Help:      "Domain events published by name.",
+		}, []string{"event"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.requestDuration,
+		m.upstreamDuration,
+		m.upstreamErrors,
+		m.cacheLookups,
+		m.events,
+	)
+	return m
+}
+
+// Handler serves the metrics in the Prometheus text format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// ObserveRequest records a served HTTP request. pattern is the ServeMux
+// pattern that matched, so labels stay bounded whatever paths clients send.
+func (m *Metrics) ObserveRequest(pattern, method string, status int, elapsed time.Duration) {
+	route := "unmatched"
+	if pattern != "" {
+		// Patterns carry their own method, e.g. "GET /api/books/{id}"
+		_, path, ok := strings.Cut(pattern, " ")
+		if !ok {
+			path = pattern
+		}
+		route = path
+	}
+
+	m.requests.WithLabelValues(route, method, strconv.Itoa(status)).Inc()
+	m.requestDuration.WithLabelValues(route, method).Observe(elapsed.Seconds())
+}
+
+// ObserveEvent counts a published domain event
+func (m *Metrics) ObserveEvent(name string) {
+	m.events.WithLabelValues(name).Inc()
+}
+
+// RegisterDB exports connection pool statistics from sql.DB.Stats
+func (m *Metrics) RegisterDB(db *sql.DB, name string) {
+	m.registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// RegisterCircuit exports the state of a circuit breaker, where 0 is
+// closed, 1 is open and 2 is half-open
+func (m *Metrics) RegisterCircuit(service string, state func() float64) {
+	m.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace:   namespace,
+		Name:        "circuit_breaker_state",
+		Help:        "Circuit breaker state: 0 closed, 1 open, 2 half-open.",
+		ConstLabels: prometheus.Labels{"service": service},
+	}, state))
+}
+
+// InstrumentTransport wraps rt so calls to service are timed and failures counted
+func (m *Metrics) InstrumentTransport(service string, rt http.RoundTripper) http.RoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		start := time.Now()
+		resp, err := rt.RoundTrip(req)
+
+		outcome := "error"
+		if err == nil {
+			outcome = strconv.Itoa(resp.StatusCode)
+		}
+		m.upstreamDuration.WithLabelValues(service, outcome).Observe(time.Since(start).Seconds())
+		if err != nil || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError {
+			m.upstreamErrors.WithLabelValues(service).Inc()
+		}
+		return resp, err
+	})
+}
+
+// InstrumentCoverStore wraps store so its lookups count as cache hits and misses
+func (m *Metrics) InstrumentCoverStore(store cover.Store) cover.Store {
+	return &coverStore{Store: store, lookups: m.cacheLookups}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// coverStore counts cover lookups; errors other than a miss are not counted
+type coverStore struct {
+	cover.Store
+	lookups *prometheus.CounterVec
+}
+
+func (s *coverStore) Get(key string) (*cover.Image, error) {
+	img, err := s.Store.Get(key)
+	switch {
+	case err == nil:
+		s.lookups.WithLabelValues("covers", "hit").Inc()
+	case errors.Is(err, cover.ErrNotFound):
+		s.lookups.WithLabelValues("covers", "miss").Inc()
+	}
+	return img, err
+}
@@ -0,0 +1,80 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/guisithos/save-my-read/internal/domain/cover"
+)
+
+type memStore map[string]*cover.Image
+
+func (s memStore) Put(key string, data []byte, contentType string) error {
+	s[key] = &cover.Image{Data: data, ContentType: contentType}
+	return nil
+}
+
+func (s memStore) Get(key string) (*cover.Image, error) {
+	if img, ok := s[key]; ok {
+		return img, nil
+	}
+	return nil, cover.ErrNotFound
+}
+
+func (s memStore) Delete(key string) error {
+	delete(s, key)
+	return nil
+}
+
+func scrape(t *testing.T, m *Metrics) string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	return w.Body.String()
+}
+
+func TestMetrics(t *testing.T) {
+	m := New()
+
+	m.ObserveRequest("GET /api/books/{id}", http.MethodGet, http.StatusOK, 10*time.Millisecond)
+	m.ObserveRequest("", http.MethodGet, http.StatusNotFound, time.Millisecond)
+
+	store := m.InstrumentCoverStore(memStore{})
+	store.Put("a", []byte("img"), "image/png")
+	store.Get("a")
+	store.Get("b")
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer upstream.Close()
+	client := &http.Client{Transport: m.InstrumentTransport("google_books", nil)}
+	resp, err := client.Get(upstream.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	m.RegisterCircuit("google_books", func() float64 { return 1 })
+	m.ObserveEvent("book.added")
+	m.ObserveEvent("book.added")
+
+	out := scrape(t, m)
+	for _, want := range []string{
+		`save_my_read_http_requests_total{code="200",method="GET",route="/api/books/{id}"} 1`,
+		`save_my_read_http_requests_total{code="404",method="GET",route="unmatched"} 1`,
+		`save_my_read_cache_lookups_total{cache="covers",result="hit"} 1`,
+		`save_my_read_cache_lookups_total{cache="covers",result="miss"} 1`,
+		`save_my_read_upstream_request_duration_seconds_count{outcome="503",service="google_books"} 1`,
+		`save_my_read_upstream_errors_total{service="google_books"} 1`,
+		`save_my_read_circuit_breaker_state{service="google_books"} 1`,
+		`save_my_read_domain_events_total{event="book.added"} 2`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %s in output", want)
+		}
+	}
+}
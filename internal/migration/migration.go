@@ -0,0 +1,181 @@
+// Package migration applies the embedded schema migrations with
+// golang-migrate, choosing the PostgreSQL or SQLite set from the database
+// URL.
+package migration
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
+	migratepg "github.com/golang-migrate/migrate/v4/database/postgres"
+	migratesqlite "github.com/golang-migrate/migrate/v4/database/sqlite"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/guisithos/save-my-read/internal/infrastructure/sqlite"
+	"github.com/guisithos/save-my-read/internal/logging"
+	"github.com/guisithos/save-my-read/migrations"
+)
+
+// Files returns the migrations for the database databaseURL names
+func Files(databaseURL string) fs.FS {
+	if sqlite.IsURL(databaseURL) {
+		return migrations.SQLite()
+	}
+	return migrations.Postgres()
+}
+
+// Latest returns the highest version among the NNNNNN_name.up.sql files in fsys
+func Latest(fsys fs.FS) (uint, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return 0, fmt.Errorf("error reading migrations: %w", err)
+	}
+
+	var latest uint
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".up.sql") {
+			continue
+		}
+		prefix, _, ok := strings.Cut(name, "_")
+		if !ok {
+			continue
+		}
+		v, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			continue
+		}
+		latest = max(latest, uint(v))
+	}
+
+	if latest == 0 {
+		return 0, errors.New("no migrations found")
+	}
+	return latest, nil
+}
+
+// Migrator applies the embedded migrations to one database
+type Migrator struct {
+	m      *migrate.Migrate
+	latest uint
+}
+
+// Open connects to the database named by databaseURL with a connection of
+// its own, which Close releases. On PostgreSQL every change runs under an
+// advisory lock, so replicas migrating at once apply each version once.
+func Open(databaseURL string, logger *slog.Logger) (*Migrator, error) {
+	files := Files(databaseURL)
+	latest, err := Latest(files)
+	if err != nil {
+		return nil, err
+	}
+	source, err := iofs.New(files, ".")
+	if err != nil {
+		return nil, fmt.Errorf("error reading migrations: %w", err)
+	}
+
+	var db *sql.DB
+	var driver database.Driver
+	var name string
+	if sqlite.IsURL(databaseURL) {
+		name = "sqlite"
+		if db, err = sqlite.Open(databaseURL); err == nil {
+			driver, err = migratesqlite.WithInstance(db, &migratesqlite.Config{})
+		}
+	} else {
+		name = "postgres"
+		if db, err = sql.Open("postgres", databaseURL); err == nil {
+			driver, err = migratepg.WithInstance(db, &migratepg.Config{})
+		}
+	}
+	if err != nil {
+		if db != nil {
+			db.Close()
+		}
+		source.Close()
+		return nil, fmt.Errorf("error connecting to database: %w", err)
+	}
+
+	m, err := migrate.NewWithInstance("iofs", source, name, driver)
+	if err != nil {
+		// The driver owns db now
+		driver.Close()
+		source.Close()
+		return nil, fmt.Errorf("error preparing migrations: %w", err)
+	}
+	m.Log = migrateLogger{logging.OrDefault(logger)}
+
+	return &Migrator{m: m, latest: latest}, nil
+}
+
+// Latest returns the newest version this binary knows
+func (m *Migrator) Latest() uint {
+	return m.latest
+}
+
+// Version returns the schema version and whether the last migration failed
+// part way; a database that was never migrated is at version 0
+func (m *Migrator) Version() (uint, bool, error) {
+	version, dirty, err := m.m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("error reading schema version: %w", err)
+	}
+	return version, dirty, nil
+}
+
+// Up applies every pending migration
+func (m *Migrator) Up() error {
+	if err := m.m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("error applying migrations: %w", err)
+	}
+	return nil
+}
+
+// Down rolls back the last steps migrations
+func (m *Migrator) Down(steps int) error {
+	if steps <= 0 {
+		return errors.New("number of migrations to roll back must be positive")
+	}
+	if err := m.m.Steps(-steps); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("error rolling back migrations: %w", err)
+	}
+	return nil
+}
+
+// Force records version as applied and clean without running anything, to
+// recover after fixing a migration that failed part way. A version of -1
+// means no migration has been applied.
+func (m *Migrator) Force(version int) error {
+	if err := m.m.Force(version); err != nil {
+		return fmt.Errorf("error forcing schema version: %w", err)
+	}
+	return nil
+}
+
+// Close releases the migrator's database connection
+func (m *Migrator) Close() error {
+	srcErr, dbErr := m.m.Close()
+	return errors.Join(srcErr, dbErr)
+}
+
+// migrateLogger sends golang-migrate's progress messages to slog at debug level
+type migrateLogger struct {
+	logger *slog.Logger
+}
+
+func (l migrateLogger) Printf(format string, v ...interface{}) {
+	l.logger.Debug(strings.TrimSpace(fmt.Sprintf(format, v...)), "component", "migrate")
+}
+
+func (l migrateLogger) Verbose() bool {
+	return true
+}
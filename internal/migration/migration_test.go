@@ -0,0 +1,231 @@
+package migration
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	neturl "net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/uuid"
+	"github.com/guisithos/save-my-read/internal/infrastructure/sqlite"
+	"github.com/guisithos/save-my-read/internal/logging"
+)
+
+func TestLatest(t *testing.T) {
+	files := fstest.MapFS{
+		"000001_users.up.sql":    {},
+		"000001_users.down.sql":  {},
+		"000012_books.up.sql":    {},
+		"README.md":              {},
+		"sqlite/000020_x.up.sql": {},
+	}
+	if got, err := Latest(files); err != nil || got != 12 {
+		t.Errorf("Latest() = %d, %v; want 12", got, err)
+	}
+	if _, err := Latest(fstest.MapFS{"README.md": {}}); err == nil {
+		t.Error("expected error without migrations")
+	}
+}
+
+func TestFiles_SameVersionsForEveryDatabase(t *testing.T) {
+	pg, err := Latest(Files("postgres://localhost/app"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lite, err := Latest(Files("sqlite://app.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pg != lite {
+		t.Errorf("PostgreSQL migrations end at %d but SQLite ones at %d", pg, lite)
+	}
+}
+
+func TestMigrator_SQLite(t *testing.T) {
+	m, err := Open("sqlite://"+filepath.Join(t.TempDir(), "app.db"), logging.Discard())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	assertVersion := func(want uint, wantDirty bool) {
+		t.Helper()
+		version, dirty, err := m.Version()
+		if err != nil {
+			t.Fatalf("Version() error = %v", err)
+		}
+		if version != want || dirty != wantDirty {
+			t.Errorf("Version() = %d, %v; want %d, %v", version, dirty, want, wantDirty)
+		}
+	}
+
+	assertVersion(0, false)
+
+	if err := m.Up(); err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+	assertVersion(m.Latest(), false)
+
+	// Nothing left to apply is not an error
+	if err := m.Up(); err != nil {
+		t.Fatalf("second Up() error = %v", err)
+	}
+
+	if err := m.Down(1); err != nil {
+		t.Fatalf("Down(1) error = %v", err)
+	}
+	assertVersion(m.Latest()-1, false)
+
+	if err := m.Down(0); err == nil {
+		t.Error("expected error rolling back zero migrations")
+	}
+
+	if err := m.Force(int(m.Latest())); err != nil {
+		t.Fatalf("Force() error = %v", err)
+	}
+	assertVersion(m.Latest(), false)
+}
+
+// dialect writes the array columns of one database in SQL
+type dialect struct {
+	// array is a literal of a string array column
+	array func(values ...string) string
+	// joined renders an array column as its values separated by |
+	joined func(column string) string
+}
+
+var (
+	sqliteDialect = dialect{
+		array: func(values ...string) string {
+			b, _ := json.Marshal(values)
+			return "'" + string(b) + "'"
+		},
+		joined: func(column string) string {
+			return "(SELECT group_concat(value, '|') FROM json_each(" + column + "))"
+		},
+	}
+	postgresDialect = dialect{
+		array: func(values ...string) string {
+			return "ARRAY['" + strings.Join(values, "','") + "']::TEXT[]"
+		},
+		joined: func(column string) string {
+			return "array_to_string(" + column + ", '|')"
+		},
+	}
+)
+
+func TestMigration_MergesDuplicateBooks(t *testing.T) {
+	t.Run("sqlite", func(t *testing.T) {
+		url := "sqlite://" + filepath.Join(t.TempDir(), "app.db")
+		db, err := sqlite.Open(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+		testMergesDuplicateBooks(t, url, db, sqliteDialect)
+	})
+	t.Run("postgres", func(t *testing.T) {
+		url := os.Getenv("DATABASE_URL")
+		if url == "" || sqlite.IsURL(url) {
+			t.Skip("DATABASE_URL does not name a PostgreSQL database")
+		}
+		if u, err := neturl.Parse(url); err != nil || (u.Hostname() != "localhost" && u.Hostname() != "127.0.0.1") {
+			t.Skip("DATABASE_URL does not point at a local database")
+		}
+		db, err := sql.Open("postgres", url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+		testMergesDuplicateBooks(t, url, db, postgresDialect)
+	})
+}
+
+// testMergesDuplicateBooks seeds copies of one volume at version 2 and
+// checks that the uniqueness migration folds them into the oldest
+func testMergesDuplicateBooks(t *testing.T, url string, db *sql.DB, d dialect) {
+	m, err := Open(url, logging.Discard())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	if err := m.Up(); err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+	if err := m.Down(int(m.Latest()) - 2); err != nil {
+		t.Fatalf("Down() error = %v", err)
+	}
+
+	alice, bob := uuid.NewString(), uuid.NewString()
+	oldest, newest, middle := uuid.NewString(), uuid.NewString(), uuid.NewString()
+	other, bobs := uuid.NewString(), uuid.NewString()
+	exec := func(query string) {
+		t.Helper()
+		if _, err := db.Exec(query); err != nil {
+			t.Fatalf("%s: %v", query, err)
+		}
+	}
+	for _, id := range []string{alice, bob} {
+		exec(fmt.Sprintf(`INSERT INTO users (id, email, password_hash, name) VALUES ('%s', '%s@example.com', 'x', 'Reader')`, id, id))
+	}
+	book := func(id, userID, googleID, title, description, imageURL, status, created, updated string, authors, categories []string) {
+		exec(fmt.Sprintf(`INSERT INTO books (id, google_id, title, authors, description, categories, image_url, status, user_id, created_at, updated_at)
+			VALUES ('%s', '%s', '%s', %s, '%s', %s, '%s', '%s', '%s', '%s', '%s')`,
+			id, googleID, title, d.array(authors...), description, d.array(categories...), imageURL, status, userID, created, updated))
+	}
+	book(oldest, alice, "g1", "Dune", "", "", "TO_READ", "2024-01-01 10:00:00", "2024-01-01 10:00:00",
+		[]string{"Frank Herbert"}, []string{"Fiction"})
+	book(newest, alice, "g1", "Dune (Deluxe)", "Desert planet", "https://example.com/b.jpg", "COMPLETED", "2024-01-02 10:00:00", "2024-03-01 10:00:00",
+		[]string{"Frank Herbert", "Brian Herbert"}, []string{"Sci-Fi"})
+	book(middle, alice, "g1", "Dune old", "Older description", "https://example.com/c.jpg", "READING", "2024-01-03 10:00:00", "2024-04-01 10:00:00",
+		[]string{"Frank Herbert"}, []string{"Fiction"})
+	book(other, alice, "g2", "Emma", "", "", "READING", "2024-01-01 10:00:00", "2024-01-01 10:00:00", []string{"Jane Austen"}, []string{})
+	book(bobs, bob, "g1", "Dune", "", "", "TO_READ", "2024-01-01 09:00:00", "2024-01-01 09:00:00", []string{"Frank Herbert"}, []string{})
+
+	if err := m.Up(); err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+
+	var ids []string
+	rows, err := db.Query(fmt.Sprintf(`SELECT id FROM books WHERE user_id IN ('%s', '%s') ORDER BY id`, alice, bob))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rows.Next() {
+		var id string
+		rows.Scan(&id)
+		ids = append(ids, id)
+	}
+	rows.Close()
+	want := []string{oldest, other, bobs}
+	slices.Sort(want)
+	if !slices.Equal(ids, want) {
+		t.Fatalf("expected the oldest copy and the unrelated books to remain, got %v", ids)
+	}
+
+	var status, title, description, imageURL, authors, categories string
+	var updated int
+	err = db.QueryRow(fmt.Sprintf(`SELECT status, title, description, image_url, %s, %s,
+		(SELECT count(*) FROM books WHERE id = '%s' AND updated_at = '2024-04-01 10:00:00')
+		FROM books WHERE id = '%s'`, d.joined("authors"), d.joined("categories"), oldest, oldest)).
+		Scan(&status, &title, &description, &imageURL, &authors, &categories, &updated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// As Book.Merge: the oldest title, and the description and cover of the
+	// oldest copy that has them, not of the one updated last
+	if status != "COMPLETED" || title != "Dune" || description != "Desert planet" ||
+		imageURL != "https://example.com/b.jpg" || updated != 1 {
+		t.Errorf("expected the furthest status and merged metadata, got %s, %q, %q, %q, updated %d",
+			status, title, description, imageURL, updated)
+	}
+	if authors != "Frank Herbert|Brian Herbert" || categories != "Fiction|Sci-Fi" {
+		t.Errorf("expected every author and category kept, got %q and %q", authors, categories)
+	}
+}
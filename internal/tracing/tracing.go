@@ -0,0 +1,133 @@
+// Package tracing configures OpenTelemetry tracing and holds the helpers the
+// application layers use to report spans. Until Setup installs an exporter
+// the global tracer provider is OpenTelemetry's no-op, so instrumented code
+// costs next to nothing when tracing is off.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/guisithos/save-my-read/internal/domain/domainerr"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Supported exporters
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+)
+
+// Config selects where spans are sent
+type Config struct {
+	// Exporter is none, otlp or stdout; empty means none
+	Exporter string
+	// Endpoint is the OTLP/HTTP collector URL; when empty the exporter
+	// falls back to OTEL_EXPORTER_OTLP_ENDPOINT or localhost:4318
+	Endpoint    string
+	ServiceName string
+	// SampleRatio is the fraction of new traces recorded; requests that
+	// arrive with a sampled parent are always recorded
+	SampleRatio float64
+	// Output receives stdout spans; os.Stdout when nil
+	Output io.Writer
+}
+
+// Setup installs the global tracer provider and W3C propagators for cfg and
+// returns a function that flushes pending spans. With no exporter it
+// changes nothing and the returned function does nothing.
+func Setup(ctx context.Context, cfg Config) (shutdown func(context.Context) error, err error) {
+	var exporter sdktrace.SpanExporter
+	switch cfg.Exporter {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		var opts []otlptracehttp.Option
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+	case ExporterStdout:
+		out := cfg.Output
+		if out == nil {
+			out = os.Stdout
+		}
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(out))
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error creating %s trace exporter: %w", cfg.Exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		attribute.String("service.name", cfg.ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("error building trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	return provider.Shutdown, nil
+}
+
+// Tracer returns a tracer from the global provider for an instrumented package
+func Tracer(name string) trace.Tracer {
+	return otel.Tracer(name)
+}
+
+// End records err on span and ends it. Domain errors such as not found or
+// validation failures, and requests the client abandoned, are recorded as
+// events without marking the span as failed.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		if !IsExpected(err) {
+			span.SetStatus(codes.Error, err.Error())
+		}
+	}
+	span.End()
+}
+
+// IsExpected reports whether err is a domain outcome rather than a failure
+func IsExpected(err error) bool {
+	for _, kind := range []error{
+		context.Canceled,
+		domainerr.ErrNotFound,
+		domainerr.ErrConflict,
+		domainerr.ErrValidation,
+		domainerr.ErrUnauthorized,
+		domainerr.ErrForbidden,
+		domainerr.ErrTooLarge,
+		domainerr.ErrUnprocessable,
+		domainerr.ErrPrecondition,
+		domainerr.ErrPreconditionRequired,
+	} {
+		if errors.Is(err, kind) {
+			return true
+		}
+	}
+	return false
+}
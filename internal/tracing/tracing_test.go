@@ -0,0 +1,96 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/guisithos/save-my-read/internal/domain/book"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func restoreGlobals(t *testing.T) {
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+}
+
+func TestSetup(t *testing.T) {
+	restoreGlobals(t)
+
+	before := otel.GetTracerProvider()
+	shutdown, err := Setup(context.Background(), Config{})
+	if err != nil {
+		t.Fatalf("Setup() with no exporter error = %v", err)
+	}
+	if otel.GetTracerProvider() != before {
+		t.Error("expected no exporter to leave the no-op provider in place")
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("no-op shutdown error = %v", err)
+	}
+
+	if _, err := Setup(context.Background(), Config{Exporter: "zipkin"}); err == nil {
+		t.Error("expected error for unknown exporter")
+	}
+
+	var out bytes.Buffer
+	shutdown, err = Setup(context.Background(), Config{Exporter: ExporterStdout, ServiceName: "test", SampleRatio: 1, Output: &out})
+	if err != nil {
+		t.Fatalf("Setup() stdout error = %v", err)
+	}
+	_, span := Tracer("test").Start(context.Background(), "work")
+	span.End()
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown error = %v", err)
+	}
+	if !strings.Contains(out.String(), `"Name":"work"`) {
+		t.Errorf("expected span on stdout, got %s", out.String())
+	}
+}
+
+func TestEnd(t *testing.T) {
+	restoreGlobals(t)
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	tests := []struct {
+		name   string
+		err    error
+		status codes.Code
+		events int
+	}{
+		{"success", nil, codes.Unset, 0},
+		{"domain outcome", fmt.Errorf("loading: %w", book.ErrNotFound), codes.Unset, 1},
+		{"failure", errors.New("connection refused"), codes.Error, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter.Reset()
+			_, span := Tracer("test").Start(context.Background(), tt.name)
+			End(span, tt.err)
+
+			spans := exporter.GetSpans()
+			if len(spans) != 1 {
+				t.Fatalf("expected 1 span, got %d", len(spans))
+			}
+			if spans[0].Status.Code != tt.status {
+				t.Errorf("expected status %v, got %v", tt.status, spans[0].Status.Code)
+			}
+			if len(spans[0].Events) != tt.events {
+				t.Errorf("expected %d events, got %d", tt.events, len(spans[0].Events))
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+// Package validation checks request input and collects every field error
+// so callers can report them all at once.
+//
+// Checks other than Required skip empty values, so optional fields are
+// only validated when present. After a field fails one check, further
+// checks on the same field are skipped to keep the report readable.
+package validation
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/guisithos/save-my-read/internal/domain/domainerr"
+)
+
+// Validator accumulates field errors
+type Validator struct {
+	errs   domainerr.ValidationError
+	failed map[string]bool
+}
+
+// New creates an empty Validator
+func New() *Validator {
+	return &Validator{failed: make(map[string]bool)}
+}
+
+// Err returns a *domainerr.ValidationError listing every failed field, or
+// nil if all checks passed
+func (v *Validator) Err() error {
+	return v.errs.Err()
+}
+
+// Fail records a custom error for a field
+func (v *Validator) Fail(field, message string) {
+	if v.failed[field] {
+		return
+	}
+	v.failed[field] = true
+	v.errs.Add(field, message)
+}
+
+// Check records message for field when ok is false
+func (v *Validator) Check(ok bool, field, message string) {
+	if !ok {
+		v.Fail(field, message)
+	}
+}
+
+// Required checks that a string is not blank
+func (v *Validator) Required(field, value string) {
+	v.Check(strings.TrimSpace(value) != "", field, "is required")
+}
+
+// Length checks that a string has between min and max characters
+func (v *Validator) Length(field, value string, min, max int) {
+	if value == "" || v.failed[field] {
+		return
+	}
+	n := utf8.RuneCountInString(value)
+	switch {
+	case n < min:
+		v.Fail(field, fmt.Sprintf("must be at least %d characters", min))
+	case n > max:
+		v.Fail(field, fmt.Sprintf("must be at most %d characters", max))
+	}
+}
+
+// Email checks that a string is a bare email address
+func (v *Validator) Email(field, value string) {
+	if value == "" || v.failed[field] {
+		return
+	}
+	addr, err := mail.ParseAddress(value)
+	v.Check(err == nil && addr.Address == value, field, "must be a valid email address")
+}
+
+// OneOf checks that a string is one of the allowed values
+func (v *Validator) OneOf(field, value string, allowed ...string) {
+	if value == "" || v.failed[field] {
+		return
+	}
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	v.Fail(field, "must be one of "+strings.Join(allowed, ", "))
+}
+
+// URL checks that a string is an absolute http or https URL
+func (v *Validator) URL(field, value string) {
+	if value == "" || v.failed[field] {
+		return
+	}
+	u, err := url.Parse(value)
+	v.Check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "",
+		field, "must be an absolute http or https URL")
+}
+
+// Items checks that a list has between min and max entries
+func (v *Validator) Items(field string, n, min, max int) {
+	if v.failed[field] {
+		return
+	}
+	switch {
+	case n < min:
+		v.Fail(field, fmt.Sprintf("must have at least %d items", min))
+	case n > max:
+		v.Fail(field, fmt.Sprintf("must have at most %d items", max))
+	}
+}
+
+// EachLength checks that every entry of a list is non-blank and at most max
+// characters, reporting entries as field[i]
+func (v *Validator) EachLength(field string, values []string, max int) {
+	for i, value := range values {
+		name := fmt.Sprintf("%s[%d]", field, i)
+		v.Required(name, value)
+		v.Length(name, value, 1, max)
+	}
+}
@@ -0,0 +1,56 @@
+package validation
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/guisithos/save-my-read/internal/domain/domainerr"
+)
+
+func TestValidator_CollectsAllFields(t *testing.T) {
+	v := New()
+	v.Required("email", "")
+	v.Email("email", "")
+	v.Length("name", "A", 2, 10)
+	v.OneOf("status", "LOST", "TO_READ", "READING")
+	v.URL("image_url", "javascript:alert(1)")
+	v.Items("authors", 0, 1, 5)
+	v.EachLength("genres", []string{"ok", " "}, 10)
+
+	var verr *domainerr.ValidationError
+	if !errors.As(v.Err(), &verr) {
+		t.Fatalf("expected ValidationError, got %v", v.Err())
+	}
+
+	want := []string{"email", "name", "status", "image_url", "authors", "genres[1]"}
+	if len(verr.Fields) != len(want) {
+		t.Fatalf("expected %d field errors, got %v", len(want), verr.Fields)
+	}
+	for i, f := range verr.Fields {
+		if f.Field != want[i] {
+			t.Errorf("field %d: expected %q, got %q", i, want[i], f.Field)
+		}
+	}
+}
+
+func TestValidator_OptionalFieldsAndValidInput(t *testing.T) {
+	v := New()
+	v.Email("email", "reader@example.com")
+	v.Length("name", "Ana", 2, 10)
+	v.URL("image_url", "")
+	v.OneOf("status", "", "TO_READ")
+	v.Items("authors", 2, 1, 5)
+
+	if err := v.Err(); err != nil {
+		t.Errorf("expected no errors, got %v", err)
+	}
+}
+
+func TestValidator_RejectsDisplayNameEmail(t *testing.T) {
+	v := New()
+	v.Email("email", "Reader <reader@example.com>")
+
+	if v.Err() == nil {
+		t.Error("expected error for address with display name")
+	}
+}
@@ -0,0 +1,64 @@
+ALTER TABLE books ADD COLUMN isbn VARCHAR(20) NOT NULL DEFAULT '';
+
+-- Fold entries added more than once for the same volume into the oldest
+-- one before the unique index below can be created, by the rules of
+-- Book.Merge applied to each newer copy in turn: the oldest entry keeps its
+-- title and creation time, fills an empty description or cover from the
+-- oldest copy that has one, and takes the furthest status any copy reached
+-- and every author and category. It is marked updated when any copy last
+-- was. ISBNs are added empty by this migration, so there are none to carry.
+UPDATE books b SET
+    status = (
+        SELECT d.status FROM books d
+        WHERE d.user_id = b.user_id AND d.google_id = b.google_id
+        ORDER BY CASE d.status
+            WHEN 'COMPLETED' THEN 3 WHEN 'DNF' THEN 2 WHEN 'READING' THEN 1 ELSE 0
+        END DESC, d.created_at, d.id
+        LIMIT 1
+    ),
+    description = COALESCE((
+        SELECT d.description FROM books d
+        WHERE d.user_id = b.user_id AND d.google_id = b.google_id AND d.description <> ''
+        ORDER BY d.created_at, d.id
+        LIMIT 1
+    ), b.description),
+    image_url = COALESCE((
+        SELECT d.image_url FROM books d
+        WHERE d.user_id = b.user_id AND d.google_id = b.google_id AND d.image_url <> ''
+        ORDER BY d.created_at, d.id
+        LIMIT 1
+    ), b.image_url),
+    authors = ARRAY(
+        SELECT u.value FROM books d, unnest(d.authors) WITH ORDINALITY AS u(value, n)
+        WHERE d.user_id = b.user_id AND d.google_id = b.google_id
+        GROUP BY u.value
+        ORDER BY min(d.created_at), min(u.n)
+    ),
+    categories = ARRAY(
+        SELECT u.value FROM books d, unnest(d.categories) WITH ORDINALITY AS u(value, n)
+        WHERE d.user_id = b.user_id AND d.google_id = b.google_id
+        GROUP BY u.value
+        ORDER BY min(d.created_at), min(u.n)
+    ),
+    updated_at = (
+        SELECT max(d.updated_at) FROM books d
+        WHERE d.user_id = b.user_id AND d.google_id = b.google_id
+    )
+WHERE EXISTS (
+    SELECT 1 FROM books d
+    WHERE d.user_id = b.user_id AND d.google_id = b.google_id AND d.id <> b.id
+) AND NOT EXISTS (
+    SELECT 1 FROM books older
+    WHERE older.user_id = b.user_id AND older.google_id = b.google_id
+      AND (older.created_at, older.id) < (b.created_at, b.id)
+);
+
+-- The newer copies are now part of the oldest entry
+DELETE FROM books b
+USING books older
+WHERE b.user_id = older.user_id
+  AND b.google_id = older.google_id
+  AND (b.created_at, b.id) > (older.created_at, older.id);
+
+CREATE UNIQUE INDEX idx_books_user_google_id ON books(user_id, google_id);
+CREATE INDEX idx_books_user_isbn ON books(user_id, isbn) WHERE isbn <> '';
@@ -0,0 +1,7 @@
+-- Trashed entries may duplicate live ones, which the full index forbids
+DELETE FROM books WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_books_deleted_at;
+DROP INDEX IF EXISTS idx_books_user_google_id;
+CREATE UNIQUE INDEX idx_books_user_google_id ON books(user_id, google_id);
+ALTER TABLE books DROP COLUMN IF EXISTS deleted_at;
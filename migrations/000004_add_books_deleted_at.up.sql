@@ -0,0 +1,8 @@
+ALTER TABLE books ADD COLUMN deleted_at TIMESTAMP;
+
+-- A book in the trash no longer blocks adding the same volume again
+DROP INDEX idx_books_user_google_id;
+CREATE UNIQUE INDEX idx_books_user_google_id ON books(user_id, google_id) WHERE deleted_at IS NULL;
+
+-- Lets the purge job find expired entries without scanning every book
+CREATE INDEX idx_books_deleted_at ON books(deleted_at) WHERE deleted_at IS NOT NULL;
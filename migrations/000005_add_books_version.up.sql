@@ -0,0 +1,2 @@
+-- Incremented on every update so concurrent edits can be detected
+ALTER TABLE books ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,29 @@
+-- Audit log; user_id and actor_id have no foreign keys so entries outlive the data they describe
+CREATE TABLE audit_events (
+    id UUID PRIMARY KEY,
+    occurred_at TIMESTAMP NOT NULL,
+    action VARCHAR(64) NOT NULL,
+    user_id UUID,
+    actor_id UUID,
+    target_type VARCHAR(32) NOT NULL DEFAULT '',
+    target_id VARCHAR(255) NOT NULL DEFAULT '',
+    ip VARCHAR(64) NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    request_id VARCHAR(128) NOT NULL DEFAULT '',
+    changes JSONB NOT NULL DEFAULT '{}',
+    details JSONB NOT NULL DEFAULT '{}'
+);
+
+CREATE INDEX idx_audit_events_user_id ON audit_events(user_id, occurred_at DESC);
+CREATE INDEX idx_audit_events_occurred_at ON audit_events(occurred_at DESC);
+
+-- The log is append-only
+CREATE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_events_append_only
+    BEFORE UPDATE OR DELETE ON audit_events
+    FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
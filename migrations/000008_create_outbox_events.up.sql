@@ -0,0 +1,17 @@
+-- Transactional outbox: events are written with the change that raised
+-- them and delivered by the dispatcher once committed
+CREATE TABLE outbox_events (
+    id UUID PRIMARY KEY,
+    name VARCHAR(64) NOT NULL,
+    payload JSONB NOT NULL,
+    occurred_at TIMESTAMP NOT NULL,
+    -- pending, dispatched or dead
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL,
+    last_error TEXT NOT NULL DEFAULT '',
+    dispatched_at TIMESTAMP
+);
+
+CREATE INDEX idx_outbox_events_pending ON outbox_events(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_outbox_events_dispatched_at ON outbox_events(dispatched_at) WHERE status = 'dispatched';
@@ -0,0 +1,30 @@
+// Package migrations embeds the SQL migrations so the server binary can
+// check and apply them itself. The PostgreSQL migrations sit at the top
+// level and the SQLite dialect of the same versions under sqlite/.
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed *.sql
+var postgres embed.FS
+
+//go:embed sqlite/*.sql
+var sqlite embed.FS
+
+// Postgres returns the PostgreSQL migrations
+func Postgres() fs.FS {
+	return postgres
+}
+
+// SQLite returns the SQLite migrations
+func SQLite() fs.FS {
+	sub, err := fs.Sub(sqlite, "sqlite")
+	if err != nil {
+		// The directory is fixed by the embed pattern above
+		panic(err)
+	}
+	return sub
+}
@@ -0,0 +1,19 @@
+CREATE TABLE books (
+    id TEXT PRIMARY KEY,
+    google_id TEXT NOT NULL,
+    title TEXT NOT NULL,
+    -- JSON arrays of strings
+    authors TEXT NOT NULL,
+    description TEXT,
+    categories TEXT NOT NULL DEFAULT '[]',
+    image_url TEXT,
+    status TEXT NOT NULL,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Add indexes for common queries
+CREATE INDEX idx_books_user_id ON books(user_id);
+CREATE INDEX idx_books_google_id ON books(google_id);
+CREATE INDEX idx_books_status ON books(status);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_books_user_isbn;
+DROP INDEX IF EXISTS idx_books_user_google_id;
+ALTER TABLE books DROP COLUMN isbn;
@@ -0,0 +1,70 @@
+ALTER TABLE books ADD COLUMN isbn TEXT NOT NULL DEFAULT '';
+
+-- Fold entries added more than once for the same volume into the oldest
+-- one before the unique index below can be created, by the rules of
+-- Book.Merge applied to each newer copy in turn: the oldest entry keeps its
+-- title and creation time, fills an empty description or cover from the
+-- oldest copy that has one, and takes the furthest status any copy reached
+-- and every author and category. It is marked updated when any copy last
+-- was. ISBNs are added empty by this migration, so there are none to carry.
+UPDATE books SET
+    status = (
+        SELECT d.status FROM books d
+        WHERE d.user_id = books.user_id AND d.google_id = books.google_id
+        ORDER BY CASE d.status
+            WHEN 'COMPLETED' THEN 3 WHEN 'DNF' THEN 2 WHEN 'READING' THEN 1 ELSE 0
+        END DESC, d.created_at, d.id
+        LIMIT 1
+    ),
+    description = COALESCE((
+        SELECT d.description FROM books d
+        WHERE d.user_id = books.user_id AND d.google_id = books.google_id AND d.description <> ''
+        ORDER BY d.created_at, d.id
+        LIMIT 1
+    ), books.description),
+    image_url = COALESCE((
+        SELECT d.image_url FROM books d
+        WHERE d.user_id = books.user_id AND d.google_id = books.google_id AND d.image_url <> ''
+        ORDER BY d.created_at, d.id
+        LIMIT 1
+    ), books.image_url),
+    authors = (
+        SELECT json_group_array(value) FROM (
+            SELECT j.value FROM books d, json_each(d.authors) j
+            WHERE d.user_id = books.user_id AND d.google_id = books.google_id
+            GROUP BY j.value
+            ORDER BY min(d.created_at), min(j.key)
+        )
+    ),
+    categories = (
+        SELECT json_group_array(value) FROM (
+            SELECT j.value FROM books d, json_each(d.categories) j
+            WHERE d.user_id = books.user_id AND d.google_id = books.google_id
+            GROUP BY j.value
+            ORDER BY min(d.created_at), min(j.key)
+        )
+    ),
+    updated_at = (
+        SELECT max(d.updated_at) FROM books d
+        WHERE d.user_id = books.user_id AND d.google_id = books.google_id
+    )
+WHERE EXISTS (
+    SELECT 1 FROM books d
+    WHERE d.user_id = books.user_id AND d.google_id = books.google_id AND d.id <> books.id
+) AND NOT EXISTS (
+    SELECT 1 FROM books older
+    WHERE older.user_id = books.user_id AND older.google_id = books.google_id
+      AND (older.created_at, older.id) < (books.created_at, books.id)
+);
+
+-- The newer copies are now part of the oldest entry
+DELETE FROM books
+WHERE EXISTS (
+    SELECT 1 FROM books older
+    WHERE older.user_id = books.user_id
+      AND older.google_id = books.google_id
+      AND (older.created_at, older.id) < (books.created_at, books.id)
+);
+
+CREATE UNIQUE INDEX idx_books_user_google_id ON books(user_id, google_id);
+CREATE INDEX idx_books_user_isbn ON books(user_id, isbn) WHERE isbn <> '';
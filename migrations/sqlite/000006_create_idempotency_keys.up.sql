@@ -0,0 +1,15 @@
+CREATE TABLE idempotency_keys (
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    key TEXT NOT NULL,
+    fingerprint TEXT NOT NULL,
+    -- 0 while the first request is still being handled
+    status INTEGER NOT NULL DEFAULT 0,
+    -- JSON object of header names to values
+    headers TEXT NOT NULL DEFAULT '{}',
+    body BLOB,
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, key)
+);
+
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
@@ -0,0 +1,30 @@
+-- Audit log; user_id and actor_id have no foreign keys so entries outlive the data they describe
+CREATE TABLE audit_events (
+    id TEXT PRIMARY KEY,
+    occurred_at TIMESTAMP NOT NULL,
+    action TEXT NOT NULL,
+    user_id TEXT,
+    actor_id TEXT,
+    target_type TEXT NOT NULL DEFAULT '',
+    target_id TEXT NOT NULL DEFAULT '',
+    ip TEXT NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    request_id TEXT NOT NULL DEFAULT '',
+    -- JSON objects
+    changes TEXT NOT NULL DEFAULT '{}',
+    details TEXT NOT NULL DEFAULT '{}'
+);
+
+CREATE INDEX idx_audit_events_user_id ON audit_events(user_id, occurred_at DESC);
+CREATE INDEX idx_audit_events_occurred_at ON audit_events(occurred_at DESC);
+
+-- The log is append-only
+CREATE TRIGGER audit_events_no_update BEFORE UPDATE ON audit_events
+BEGIN
+    SELECT RAISE(ABORT, 'audit_events is append-only');
+END;
+
+CREATE TRIGGER audit_events_no_delete BEFORE DELETE ON audit_events
+BEGIN
+    SELECT RAISE(ABORT, 'audit_events is append-only');
+END;
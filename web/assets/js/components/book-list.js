@@ -16,13 +16,57 @@ function bookList() {
         ],
 
         async init() {
+            window.addEventListener('library-event', (e) => this.applyEvent(e.detail));
+            try {
+                await this.loadBooks();
+            } finally {
+                this.isLoading = false;
+            }
+        },
+
+        // applyEvent brings the list up to date with a change streamed from
+        // the server, which may have been made in another tab or device
+        async applyEvent({ name, data }) {
+            switch (name) {
+                case 'book.added':
+                case 'book.status_changed':
+                case 'book.restored':
+                    await this.refreshBook(data.book_id);
+                    break;
+                case 'book.merged':
+                    this.books = this.books.filter(book => book.id !== data.source_id);
+                    await this.refreshBook(data.book_id);
+                    break;
+                case 'book.deleted':
+                    this.books = this.books.filter(book => book.id !== data.book_id);
+                    break;
+                case 'reset':
+                    await this.loadBooks();
+                    break;
+            }
+        },
+
+        // refreshBook fetches a book's current state, with its version, and
+        // adds or replaces it in the list
+        async refreshBook(bookId) {
+            try {
+                const response = await api.getBook(bookId);
+                const updated = response.data;
+                const exists = this.books.some(book => book.id === updated.id);
+                this.books = exists
+                    ? this.books.map(book => book.id === updated.id ? updated : book)
+                    : [updated, ...this.books];
+            } catch (error) {
+                console.error('Failed to refresh book:', error);
+            }
+        },
+
+        async loadBooks() {
             try {
                 const response = await api.getBooks();
                 this.books = response.data;
             } catch (error) {
                 console.error('Failed to fetch books:', error);
-            } finally {
-                this.isLoading = false;
             }
         },
 
@@ -55,16 +99,20 @@ function bookList() {
         },
 
         async updateBookStatus(bookId, newStatus) {
+            const current = this.books.find(book => book.id === bookId);
             try {
-                await api.updateBookStatus(bookId, newStatus);
+                await api.updateBookStatus(bookId, newStatus, current.version);
                 this.books = this.books.map(book => 
                     book.id === bookId 
-                        ? { ...book, status: newStatus }
+                        ? { ...book, status: newStatus, version: book.version + 1 }
                         : book
                 );
                 this.closeStatusModal();
             } catch (error) {
                 console.error('Failed to update book status:', error);
+                // The book may have been changed elsewhere; show its current state
+                await this.loadBooks();
+                this.closeStatusModal();
             }
         },
 
@@ -72,20 +72,14 @@ function search() {
 
         async addBook(book) {
             try {
-                await fetch('/api/books', {
-                    method: 'POST',
-                    headers: {
-                        'Content-Type': 'application/json'
-                    },
-                    body: JSON.stringify({
-                        googleBookId: book.id,
-                        title: book.title,
-                        authors: book.authors,
-                        description: book.description,
-                        categories: book.categories,
-                        imageURL: book.imageURL,
-                        status: 'TO_READ'
-                    })
+                await api.addBook({
+                    google_book_id: book.id,
+                    title: book.title,
+                    authors: book.authors,
+                    description: book.description,
+                    categories: book.categories,
+                    image_url: book.imageURL,
+                    status: 'TO_READ'
                 });
 
                 this.addedBooks.add(book.id);
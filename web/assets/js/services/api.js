@@ -13,10 +13,14 @@ const api = {
                 headers,
             });
 
+            if (response.status === 204) {
+                return null;
+            }
+
             const data = await response.json();
 
             if (!response.ok) {
-                throw new Error(data.error || 'Something went wrong');
+                throw new Error(data.error?.message || 'Something went wrong');
             }
 
             return data;
@@ -40,5 +44,60 @@ const api = {
         });
     },
 
-    // Other API methods...
+    async getBooks(status) {
+        const query = status ? `?status=${encodeURIComponent(status)}` : '';
+        return this.request(`/api/books${query}`);
+    },
+
+    async getBook(bookId) {
+        return this.request(`/api/books/${encodeURIComponent(bookId)}`);
+    },
+
+    async addBook(book) {
+        return this.request('/api/books', {
+            method: 'POST',
+            body: JSON.stringify(book),
+        });
+    },
+
+    async updateBookStatus(bookId, status, version) {
+        return this.request(`/api/books/${encodeURIComponent(bookId)}`, {
+            method: 'PATCH',
+            headers: { 'If-Match': `"${version}"` },
+            body: JSON.stringify({ status }),
+        });
+    },
+
+    async deleteBook(bookId) {
+        return this.request(`/api/books/${encodeURIComponent(bookId)}`, {
+            method: 'DELETE',
+        });
+    },
+
+    async mergeBooks(targetId, sourceId) {
+        return this.request(`/api/books/${encodeURIComponent(targetId)}/merge`, {
+            method: 'POST',
+            body: JSON.stringify({ source_id: sourceId }),
+        });
+    },
+
+    // openEventStream starts the library's Server-Sent Events stream. It
+    // uses fetch rather than EventSource, which cannot send the token.
+    async openEventStream(lastEventId, signal) {
+        const token = localStorage.getItem('token');
+        const response = await fetch('/api/events', {
+            headers: {
+                'Accept': 'text/event-stream',
+                ...(token && { 'Authorization': `Bearer ${token}` }),
+                ...(lastEventId && { 'Last-Event-ID': lastEventId }),
+            },
+            signal,
+        });
+        if (!response.ok) {
+            const error = new Error(`Event stream failed with ${response.status}`);
+            error.status = response.status;
+            throw error;
+        }
+        return response.body;
+    },
 } 
\ No newline at end of file
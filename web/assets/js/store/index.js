@@ -8,10 +8,88 @@ document.addEventListener('alpine:init', () => {
         user: null,
         isAuthenticated: false,
 
+        // Live updates: the last event received, to resume after it, and
+        // the controller that stops the stream
+        lastEventId: null,
+        live: null,
+
         init() {
             console.log('Store init called');
             this.user = JSON.parse(localStorage.getItem('user') || 'null');
             this.isAuthenticated = !!localStorage.getItem('token');
+            if (this.isAuthenticated) {
+                this.connectLive();
+            }
+        },
+
+        // connectLive follows changes made to the library elsewhere, such as
+        // on another device, and re-dispatches each one on window as a
+        // library-event. A reset event means some were missed and the
+        // library should be reloaded. The stream reconnects after errors,
+        // resuming from the last event.
+        async connectLive() {
+            this.live?.abort();
+            const controller = new AbortController();
+            this.live = controller;
+
+            let retry = 3000;
+            while (!controller.signal.aborted) {
+                try {
+                    const body = await api.openEventStream(this.lastEventId, controller.signal);
+                    retry = await this.readEvents(body, retry);
+                } catch (error) {
+                    if (controller.signal.aborted || error.status === 401) {
+                        return;
+                    }
+                    console.error('Live updates disconnected:', error);
+                }
+                await new Promise(resolve => setTimeout(resolve, retry));
+            }
+        },
+
+        disconnectLive() {
+            this.live?.abort();
+            this.live = null;
+        },
+
+        // readEvents parses the stream until it ends and returns the retry
+        // delay the server asked for
+        async readEvents(body, retry) {
+            const reader = body.pipeThrough(new TextDecoderStream()).getReader();
+            let buffer = '';
+            let event = { name: 'message', data: [] };
+            for (;;) {
+                const { value, done } = await reader.read();
+                if (done) {
+                    return retry;
+                }
+                buffer += value;
+                const lines = buffer.split(/\r\n|\r|\n/);
+                buffer = lines.pop();
+                for (const line of lines) {
+                    if (line === '') {
+                        if (event.data.length > 0) {
+                            window.dispatchEvent(new CustomEvent('library-event', {
+                                detail: { name: event.name, data: JSON.parse(event.data.join('\n')) }
+                            }));
+                        }
+                        event = { name: 'message', data: [] };
+                        continue;
+                    }
+                    if (line.startsWith(':')) {
+                        continue;
+                    }
+                    const colon = line.indexOf(':');
+                    const field = colon === -1 ? line : line.slice(0, colon);
+                    const value = colon === -1 ? '' : line.slice(colon + 1).replace(/^ /, '');
+                    switch (field) {
+                        case 'event': event.name = value; break;
+                        case 'data': event.data.push(value); break;
+                        case 'id': this.lastEventId = value; break;
+                        case 'retry': retry = Number(value) || retry; break;
+                    }
+                }
+            }
         },
 
         toggleSearch(value) {
@@ -30,6 +108,7 @@ document.addEventListener('alpine:init', () => {
         },
 
         logout() {
+            this.disconnectLive();
             localStorage.removeItem('token');
             this.user = null;
             window.location.reload();
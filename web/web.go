@@ -0,0 +1,28 @@
+// Package web embeds the frontend so the server binary can serve it without
+// depending on the working directory.
+package web
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed assets templates docs
+var files embed.FS
+
+// Assets holds the JavaScript and CSS served under /assets/
+var Assets = sub("assets")
+
+// Templates holds the HTML pages of the single-page app
+var Templates = sub("templates")
+
+// Docs holds the API documentation page
+var Docs = sub("docs")
+
+func sub(dir string) fs.FS {
+	f, err := fs.Sub(files, dir)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}